@@ -0,0 +1,19 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients"
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+)
+
+func NewGatewayClients(gatewayAddr string, httpDoer clients.HttpDoer) (*clients.Clients, error) {
+	return clients.NewClientsWithHttpClient(gatewayAddr, CorrelationIDRequestEditor, httpDoer)
+}
+
+// CorrelationIDRequestEditor passes the correlation ID from the context to the gateway.
+func CorrelationIDRequestEditor(ctx context.Context, req *http.Request) error {
+	req.Header.Set(log.CorrelationIDHttpHeader, log.CorrelationIDFromContext(ctx))
+	return nil
+}
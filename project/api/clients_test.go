@@ -0,0 +1,222 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"tickets/api"
+	"tickets/entities"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients"
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiptsServiceClient_IssueReceipt(t *testing.T) {
+	issuedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Name          string
+		Status        int
+		Body          string
+		ExpectedError bool
+	}{
+		{
+			Name:   "receipt_created",
+			Status: http.StatusCreated,
+			Body:   `{"number":"RCPT-1","issued_at":"2024-05-01T12:00:00Z","ticket_id":"ticket-1","price":{"money_amount":"10.00","money_currency":"EUR"}}`,
+		},
+		{
+			Name:   "receipt_already_exists",
+			Status: http.StatusOK,
+			Body:   `{"number":"RCPT-1","issued_at":"2024-05-01T12:00:00Z","ticket_id":"ticket-1","price":{"money_amount":"10.00","money_currency":"EUR"}}`,
+		},
+		{
+			Name:          "bad_request",
+			Status:        http.StatusBadRequest,
+			Body:          `{"error":"invalid price"}`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "server_error",
+			Status:        http.StatusInternalServerError,
+			ExpectedError: true,
+		},
+		{
+			Name:          "success_without_body",
+			Status:        http.StatusOK,
+			ExpectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var receivedBody map[string]any
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPut, r.Method)
+				assert.Equal(t, "/receipts-api/receipts", r.URL.Path)
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&receivedBody))
+
+				if tc.Body != "" {
+					w.Header().Set("Content-Type", "application/json")
+				}
+				w.WriteHeader(tc.Status)
+				_, _ = io.WriteString(w, tc.Body)
+			}))
+			defer server.Close()
+
+			client := api.NewReceiptsServiceClient(newClients(t, server))
+
+			resp, err := client.IssueReceipt(context.Background(), entities.IssueReceiptRequest{
+				TicketID: "ticket-1",
+				Price: entities.Money{
+					Amount:   "10.00",
+					Currency: "EUR",
+				},
+			})
+
+			assert.Equal(t, map[string]any{
+				"ticket_id": "ticket-1",
+				"price": map[string]any{
+					"money_amount":   "10.00",
+					"money_currency": "EUR",
+				},
+			}, receivedBody)
+
+			if tc.ExpectedError {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "RCPT-1", resp.ReceiptNumber)
+			assert.True(t, issuedAt.Equal(resp.IssuedAt))
+		})
+	}
+}
+
+func TestSpreadsheetsAPIClient_AppendRow(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Status        int
+		ExpectedError bool
+	}{
+		{
+			Name:   "row_added",
+			Status: http.StatusOK,
+		},
+		{
+			Name:          "created_is_unexpected",
+			Status:        http.StatusCreated,
+			ExpectedError: true,
+		},
+		{
+			Name:          "not_found",
+			Status:        http.StatusNotFound,
+			ExpectedError: true,
+		},
+		{
+			Name:          "server_error",
+			Status:        http.StatusInternalServerError,
+			ExpectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var receivedBody map[string]any
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "/spreadsheets-api/sheets/tickets-to-print/rows", r.URL.Path)
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&receivedBody))
+
+				w.WriteHeader(tc.Status)
+			}))
+			defer server.Close()
+
+			client := api.NewSpreadsheetsAPIClient(newClients(t, server))
+
+			err := client.AppendRow(context.Background(), "tickets-to-print", []string{"ticket-1", "email@example.com"})
+
+			assert.Equal(t, map[string]any{
+				"columns": []any{"ticket-1", "email@example.com"},
+			}, receivedBody)
+
+			if tc.ExpectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClients_timeout(t *testing.T) {
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	apiClients := newClients(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := api.NewSpreadsheetsAPIClient(apiClients).AppendRow(ctx, "tickets-to-print", []string{"ticket-1"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = api.NewReceiptsServiceClient(apiClients).IssueReceipt(ctx, entities.IssueReceiptRequest{TicketID: "ticket-1"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestClients_propagate_correlation_id(t *testing.T) {
+	receivedCorrelationIDs := make(chan string, 2)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedCorrelationIDs <- r.Header.Get("Correlation-ID")
+
+		if r.URL.Path == "/receipts-api/receipts" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{"number":"RCPT-1","issued_at":"2024-05-01T12:00:00Z"}`)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	apiClients := newClients(t, server)
+	ctx := log.ContextWithCorrelationID(context.Background(), "test-correlation-id")
+
+	err := api.NewSpreadsheetsAPIClient(apiClients).AppendRow(ctx, "tickets-to-print", []string{"ticket-1"})
+	require.NoError(t, err)
+
+	_, err = api.NewReceiptsServiceClient(apiClients).IssueReceipt(ctx, entities.IssueReceiptRequest{TicketID: "ticket-1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "test-correlation-id", <-receivedCorrelationIDs)
+	assert.Equal(t, "test-correlation-id", <-receivedCorrelationIDs)
+}
+
+func newClients(t *testing.T, server *httptest.Server) *clients.Clients {
+	t.Helper()
+
+	apiClients, err := api.NewGatewayClients(server.URL, server.Client())
+	require.NoError(t, err)
+
+	return apiClients
+}
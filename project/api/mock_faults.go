@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// StatusError imitates the gateway responding with an unexpected status code.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d", e.StatusCode)
+}
+
+type MockCall struct {
+	At       time.Time
+	TicketID string
+	// Attempt is the 1-based number of the call for this ticket.
+	Attempt int
+	Err     error
+}
+
+// faults scripts failures of a mock. The zero value makes all calls succeed immediately.
+type faults struct {
+	lock sync.Mutex
+
+	failFirst    int
+	failFirstErr error
+
+	failTickets map[string]error
+
+	latency time.Duration
+
+	calls []MockCall
+}
+
+// FailFirst makes the next n calls fail with err (StatusError 500 if err is nil).
+func (f *faults) FailFirst(n int, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.failFirst = n
+	f.failFirstErr = errOrDefault(err)
+}
+
+// FailForTicket makes all calls for the ticket fail with err (StatusError 500 if err is nil).
+func (f *faults) FailForTicket(ticketID string, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.failTickets == nil {
+		f.failTickets = make(map[string]error)
+	}
+	f.failTickets[ticketID] = errOrDefault(err)
+}
+
+// SetLatency delays every call; the delay is cut short when the call's context is cancelled.
+func (f *faults) SetLatency(latency time.Duration) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.latency = latency
+}
+
+// Calls returns all calls made so far, including failed ones.
+func (f *faults) Calls() []MockCall {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	calls := make([]MockCall, len(f.calls))
+	copy(calls, f.calls)
+
+	return calls
+}
+
+// Attempts returns how many times the mock was called for the ticket.
+func (f *faults) Attempts(ticketID string) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.attempts(ticketID)
+}
+
+func (f *faults) attempts(ticketID string) int {
+	attempts := 0
+	for _, c := range f.calls {
+		if c.TicketID == ticketID {
+			attempts++
+		}
+	}
+
+	return attempts
+}
+
+// call records the call and returns the scripted error, if any.
+func (f *faults) call(ctx context.Context, ticketID string) error {
+	f.lock.Lock()
+	latency := f.latency
+	f.lock.Unlock()
+
+	var err error
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if err == nil {
+		if ticketErr, ok := f.failTickets[ticketID]; ok {
+			err = ticketErr
+		} else if f.failFirst > 0 {
+			f.failFirst--
+			err = f.failFirstErr
+		}
+	}
+
+	f.calls = append(f.calls, MockCall{
+		At:       time.Now(),
+		TicketID: ticketID,
+		Attempt:  f.attempts(ticketID) + 1,
+		Err:      err,
+	})
+
+	return err
+}
+
+func errOrDefault(err error) error {
+	if err != nil {
+		return err
+	}
+
+	return StatusError{StatusCode: http.StatusInternalServerError}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"tickets/entities"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients"
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients/payments"
+)
+
+type PaymentsServiceClient struct {
+	// we are not mocking this client: it's pointless to use interface here
+	clients *clients.Clients
+}
+
+func NewPaymentsServiceClient(clients *clients.Clients) *PaymentsServiceClient {
+	if clients == nil {
+		panic("NewPaymentsServiceClient: clients is nil")
+	}
+
+	return &PaymentsServiceClient{clients: clients}
+}
+
+// CapturePayment returns entities.ErrCaptureNotSupported: the gateway's payments API supports only refunds,
+// payments are captured by the payment provider at checkout.
+func (c PaymentsServiceClient) CapturePayment(ctx context.Context, request entities.PaymentCapture) (string, error) {
+	return "", fmt.Errorf("gateway payments API: %w", entities.ErrCaptureNotSupported)
+}
+
+func (c PaymentsServiceClient) RefundPayment(ctx context.Context, request entities.PaymentRefund) error {
+	var deduplicationID *string
+	if request.IdempotencyKey != "" {
+		deduplicationID = &request.IdempotencyKey
+	}
+
+	resp, err := c.clients.Payments.PutRefundsWithResponse(ctx, payments.PaymentRefundRequest{
+		DeduplicationId:  deduplicationID,
+		PaymentReference: request.PaymentReference,
+		Reason:           request.RefundReason,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to refund payment: %w", err)
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("unexpected status code for PUT payments-api/refunds: %d", resp.StatusCode())
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"tickets/entities"
+)
+
+// PaymentsMock treats the booking ID of captures and the payment reference of refunds as the ticket ID
+// for scripted failures.
+type PaymentsMock struct {
+	faults
+
+	lock sync.Mutex
+
+	Captures []entities.PaymentCapture
+	Refunds  []entities.PaymentRefund
+}
+
+// CapturePayment returns the booking ID as the payment reference.
+func (c *PaymentsMock) CapturePayment(ctx context.Context, request entities.PaymentCapture) (string, error) {
+	if err := c.call(ctx, request.BookingID); err != nil {
+		return "", fmt.Errorf("failed to capture payment: %w", err)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.Captures = append(c.Captures, request)
+
+	return request.BookingID, nil
+}
+
+func (c *PaymentsMock) RefundPayment(ctx context.Context, request entities.PaymentRefund) error {
+	if err := c.call(ctx, request.PaymentReference); err != nil {
+		return fmt.Errorf("failed to refund payment: %w", err)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.Refunds = append(c.Refunds, request)
+
+	return nil
+}
+
+// Captured returns a copy of captured payments, safe to use while the mock is being called.
+func (c *PaymentsMock) Captured() []entities.PaymentCapture {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]entities.PaymentCapture(nil), c.Captures...)
+}
+
+// Refunded returns a copy of refunded payments, safe to use while the mock is being called.
+func (c *PaymentsMock) Refunded() []entities.PaymentRefund {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]entities.PaymentRefund(nil), c.Refunds...)
+}
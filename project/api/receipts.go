@@ -38,17 +38,47 @@ func (c ReceiptsServiceClient) IssueReceipt(ctx context.Context, request entitie
 	switch resp.StatusCode() {
 	case http.StatusOK:
 		// receipt already exists
+		if resp.JSON200 == nil {
+			return entities.IssueReceiptResponse{}, fmt.Errorf("missing receipt in response from PUT receipts-api/receipts: %d", resp.StatusCode())
+		}
 		return entities.IssueReceiptResponse{
 			ReceiptNumber: resp.JSON200.Number,
 			IssuedAt:      resp.JSON200.IssuedAt,
 		}, nil
 	case http.StatusCreated:
 		// receipt was created
+		if resp.JSON201 == nil {
+			return entities.IssueReceiptResponse{}, fmt.Errorf("missing receipt in response from PUT receipts-api/receipts: %d", resp.StatusCode())
+		}
 		return entities.IssueReceiptResponse{
 			ReceiptNumber: resp.JSON201.Number,
 			IssuedAt:      resp.JSON201.IssuedAt,
 		}, nil
 	default:
-		return entities.IssueReceiptResponse{}, fmt.Errorf("unexpected status code for POST receipts-api/receipts: %d", resp.StatusCode())
+		return entities.IssueReceiptResponse{}, fmt.Errorf("unexpected status code for PUT receipts-api/receipts: %d", resp.StatusCode())
+	}
+}
+
+// VoidReceipt voids the receipt of the ticket. Tickets without a receipt have nothing to void, it's not an error.
+func (c ReceiptsServiceClient) VoidReceipt(ctx context.Context, request entities.VoidReceipt) error {
+	var idempotencyKey *string
+	if request.IdempotencyKey != "" {
+		idempotencyKey = &request.IdempotencyKey
+	}
+
+	resp, err := c.clients.Receipts.PutVoidReceiptWithResponse(ctx, receipts.VoidReceiptRequest{
+		IdempotentId: idempotencyKey,
+		Reason:       request.Reason,
+		TicketId:     request.TicketID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to void receipt: %w", err)
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return fmt.Errorf("unexpected status code for PUT receipts-api/void-receipt: %d", resp.StatusCode())
 	}
 }
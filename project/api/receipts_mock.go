@@ -2,18 +2,26 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"tickets/entities"
 	"time"
 )
 
 type ReceiptsMock struct {
+	faults
+
 	mock sync.Mutex
 
 	IssuedReceipts []entities.IssueReceiptRequest
+	VoidedReceipts []entities.VoidReceipt
 }
 
 func (c *ReceiptsMock) IssueReceipt(ctx context.Context, request entities.IssueReceiptRequest) (entities.IssueReceiptResponse, error) {
+	if err := c.call(ctx, request.TicketID); err != nil {
+		return entities.IssueReceiptResponse{}, fmt.Errorf("failed to post receipt: %w", err)
+	}
+
 	c.mock.Lock()
 	defer c.mock.Unlock()
 
@@ -24,3 +32,38 @@ func (c *ReceiptsMock) IssueReceipt(ctx context.Context, request entities.IssueR
 		IssuedAt:      time.Now(),
 	}, nil
 }
+
+// Issued returns a copy of issued receipts, safe to use while the mock is being called.
+func (c *ReceiptsMock) Issued() []entities.IssueReceiptRequest {
+	c.mock.Lock()
+	defer c.mock.Unlock()
+
+	issued := make([]entities.IssueReceiptRequest, len(c.IssuedReceipts))
+	copy(issued, c.IssuedReceipts)
+
+	return issued
+}
+
+func (c *ReceiptsMock) VoidReceipt(ctx context.Context, request entities.VoidReceipt) error {
+	if err := c.call(ctx, request.TicketID); err != nil {
+		return fmt.Errorf("failed to void receipt: %w", err)
+	}
+
+	c.mock.Lock()
+	defer c.mock.Unlock()
+
+	c.VoidedReceipts = append(c.VoidedReceipts, request)
+
+	return nil
+}
+
+// Voided returns a copy of voided receipts, safe to use while the mock is being called.
+func (c *ReceiptsMock) Voided() []entities.VoidReceipt {
+	c.mock.Lock()
+	defer c.mock.Unlock()
+
+	voided := make([]entities.VoidReceipt, len(c.VoidedReceipts))
+	copy(voided, c.VoidedReceipts)
+
+	return voided
+}
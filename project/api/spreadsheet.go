@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"tickets/entities"
 
 	"github.com/ThreeDotsLabs/go-event-driven/common/clients"
 	"github.com/ThreeDotsLabs/go-event-driven/common/clients/spreadsheets"
@@ -36,3 +37,29 @@ func (c SpreadsheetsAPIClient) AppendRow(ctx context.Context, spreadsheetName st
 
 	return nil
 }
+
+// Rows returns all rows of the sheet, the header row first.
+func (c SpreadsheetsAPIClient) Rows(ctx context.Context, spreadsheetName string) ([][]string, error) {
+	resp, err := c.clients.Spreadsheets.GetSheetsSheetRowsWithResponse(ctx, spreadsheetName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows: %w", err)
+	}
+
+	if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
+		return nil, fmt.Errorf("failed to get rows: unexpected status code %d", resp.StatusCode())
+	}
+
+	return resp.JSON200.Rows, nil
+}
+
+// AppendRows appends rows in order. The spreadsheets API appends one row per request, so they are posted one by one,
+// and when one fails, entities.AppendRowsError tells how many were appended before it.
+func (c SpreadsheetsAPIClient) AppendRows(ctx context.Context, spreadsheetName string, rows [][]string) error {
+	for i, row := range rows {
+		if err := c.AppendRow(ctx, spreadsheetName, row); err != nil {
+			return &entities.AppendRowsError{Appended: i, Err: err}
+		}
+	}
+
+	return nil
+}
@@ -2,23 +2,84 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"sync"
+	"tickets/entities"
 )
 
+// SpreadsheetsMock treats the first column of a row as the ticket ID for scripted failures.
 type SpreadsheetsMock struct {
-	lock sync.Mutex
-	Rows map[string][][]string
+	faults
+
+	lock    sync.Mutex
+	Sheets  map[string][][]string
+	Batches map[string][]int
 }
 
 func (c *SpreadsheetsMock) AppendRow(ctx context.Context, spreadsheetName string, row []string) error {
+	var ticketID string
+	if len(row) > 0 {
+		ticketID = row[0]
+	}
+
+	if err := c.call(ctx, ticketID); err != nil {
+		return fmt.Errorf("failed to post row: %w", err)
+	}
+
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	if c.Rows == nil {
-		c.Rows = make(map[string][][]string)
+	if c.Sheets == nil {
+		c.Sheets = make(map[string][][]string)
+	}
+
+	c.Sheets[spreadsheetName] = append(c.Sheets[spreadsheetName], row)
+
+	return nil
+}
+
+// AppendRows appends rows one by one, like the real client. Batches records sizes of the calls.
+func (c *SpreadsheetsMock) AppendRows(ctx context.Context, spreadsheetName string, rows [][]string) error {
+	c.lock.Lock()
+	if c.Batches == nil {
+		c.Batches = make(map[string][]int)
 	}
+	c.Batches[spreadsheetName] = append(c.Batches[spreadsheetName], len(rows))
+	c.lock.Unlock()
 
-	c.Rows[spreadsheetName] = append(c.Rows[spreadsheetName], row)
+	for i, row := range rows {
+		if err := c.AppendRow(ctx, spreadsheetName, row); err != nil {
+			return &entities.AppendRowsError{Appended: i, Err: err}
+		}
+	}
 
 	return nil
 }
+
+// BatchSizes returns how many rows were appended to the sheet with each AppendRows call.
+func (c *SpreadsheetsMock) BatchSizes(spreadsheetName string) []int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]int(nil), c.Batches[spreadsheetName]...)
+}
+
+// Rows returns rows of the sheet, like SheetRows. It fails with FailFirst, not for tickets.
+func (c *SpreadsheetsMock) Rows(ctx context.Context, spreadsheetName string) ([][]string, error) {
+	if err := c.call(ctx, ""); err != nil {
+		return nil, fmt.Errorf("failed to get rows: %w", err)
+	}
+
+	return c.SheetRows(spreadsheetName), nil
+}
+
+// SheetRows returns a copy of rows appended to the sheet, safe to use while the mock is being called.
+func (c *SpreadsheetsMock) SheetRows(spreadsheetName string) [][]string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	rows := make([][]string, len(c.Sheets[spreadsheetName]))
+	copy(rows, c.Sheets[spreadsheetName])
+
+	return rows
+}
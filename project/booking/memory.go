@@ -0,0 +1,136 @@
+package booking
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"tickets/entities"
+	"time"
+)
+
+// MemoryRepository keeps sagas in memory, for running without PostgreSQL.
+type MemoryRepository struct {
+	lock  sync.Mutex
+	sagas map[string]State
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{sagas: map[string]State{}}
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, state State) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.sagas[state.BookingID]; !ok {
+		r.sagas[state.BookingID] = copyState(state)
+	}
+
+	return nil
+}
+
+// Update holds the lock of all sagas while update runs, including the messages it sends.
+func (r *MemoryRepository) Update(ctx context.Context, bookingID string, update func(state *State) error) (State, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	state, ok := r.sagas[bookingID]
+	if !ok {
+		return State{}, ErrSagaNotFound
+	}
+
+	state = copyState(state)
+	if err := update(&state); err != nil {
+		return State{}, err
+	}
+
+	r.sagas[bookingID] = copyState(state)
+
+	return state, nil
+}
+
+func (r *MemoryRepository) ListTimedOut(ctx context.Context, now time.Time, limit int) ([]State, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var timedOut []State
+	for _, state := range r.sagas {
+		if inProgress(state.Status) && state.Deadline.Before(now) {
+			timedOut = append(timedOut, copyState(state))
+		}
+	}
+
+	sort.Slice(timedOut, func(i, j int) bool {
+		return timedOut[i].Deadline.Before(timedOut[j].Deadline)
+	})
+	if len(timedOut) > limit {
+		timedOut = timedOut[:limit]
+	}
+
+	return timedOut, nil
+}
+
+// Get returns the saga of the booking.
+func (r *MemoryRepository) Get(ctx context.Context, bookingID string) (State, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	state, ok := r.sagas[bookingID]
+	if !ok {
+		return State{}, ErrSagaNotFound
+	}
+
+	return copyState(state), nil
+}
+
+// MemoryTicketsRepository keeps reserved tickets in memory, for running without PostgreSQL.
+type MemoryTicketsRepository struct {
+	lock sync.Mutex
+	// tickets are by booking ID
+	tickets map[string][]entities.Ticket
+}
+
+func NewMemoryTicketsRepository() *MemoryTicketsRepository {
+	return &MemoryTicketsRepository{tickets: map[string][]entities.Ticket{}}
+}
+
+func (r *MemoryTicketsRepository) ReserveTickets(ctx context.Context, tenantID string, bookingID string, tickets []entities.Ticket) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.tickets[bookingID] = append([]entities.Ticket(nil), tickets...)
+
+	return nil
+}
+
+func (r *MemoryTicketsRepository) ReleaseTickets(ctx context.Context, bookingID string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.tickets, bookingID)
+
+	return nil
+}
+
+// Reserved returns tickets reserved for the booking.
+func (r *MemoryTicketsRepository) Reserved(bookingID string) []entities.Ticket {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]entities.Ticket(nil), r.tickets[bookingID]...)
+}
+
+func inProgress(status Status) bool {
+	for _, s := range inProgressStatuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+func copyState(state State) State {
+	state.TicketIDs = append([]string(nil), state.TicketIDs...)
+	return state
+}
@@ -0,0 +1,28 @@
+package booking
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"tickets/entities"
+)
+
+// multiply returns price times n, with as many decimal places as the price has.
+func multiply(price entities.Money, n int) (entities.Money, error) {
+	amount, ok := new(big.Rat).SetString(price.Amount)
+	if !ok {
+		return entities.Money{}, fmt.Errorf("invalid amount %q", price.Amount)
+	}
+
+	decimals := 0
+	if _, fraction, ok := strings.Cut(price.Amount, "."); ok {
+		decimals = len(fraction)
+	}
+
+	total := amount.Mul(amount, new(big.Rat).SetInt64(int64(n)))
+
+	return entities.Money{
+		Amount:   total.FloatString(decimals),
+		Currency: price.Currency,
+	}, nil
+}
@@ -0,0 +1,35 @@
+package booking
+
+import (
+	"testing"
+	"tickets/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiply(t *testing.T) {
+	testCases := []struct {
+		Amount   string
+		N        int
+		Expected string
+	}{
+		{Amount: "25.00", N: 2, Expected: "50.00"},
+		{Amount: "0.10", N: 3, Expected: "0.30"},
+		{Amount: "19.99", N: 7, Expected: "139.93"},
+		{Amount: "30", N: 4, Expected: "120"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Amount, func(t *testing.T) {
+			total, err := multiply(entities.Money{Amount: tc.Amount, Currency: "EUR"}, tc.N)
+			require.NoError(t, err)
+			assert.Equal(t, entities.Money{Amount: tc.Expected, Currency: "EUR"}, total)
+		})
+	}
+}
+
+func TestMultiply_invalid_amount(t *testing.T) {
+	_, err := multiply(entities.Money{Amount: "abc", Currency: "EUR"}, 2)
+	assert.Error(t, err)
+}
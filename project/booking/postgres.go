@@ -0,0 +1,228 @@
+package booking
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"tickets/entities"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PostgresRepository keeps sagas in the booking_sagas table created by db/schema.sql.
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	if db == nil {
+		panic("missing db")
+	}
+
+	return &PostgresRepository{db: db}
+}
+
+type stateRow struct {
+	BookingID        string         `db:"booking_id"`
+	TenantID         string         `db:"tenant_id"`
+	Status           string         `db:"status"`
+	Booking          []byte         `db:"booking"`
+	TicketIDs        pq.StringArray `db:"ticket_ids"`
+	PaymentReference string         `db:"payment_reference"`
+	FailureReason    string         `db:"failure_reason"`
+	Deadline         time.Time      `db:"deadline"`
+	CreatedAt        time.Time      `db:"created_at"`
+	UpdatedAt        time.Time      `db:"updated_at"`
+}
+
+func (r stateRow) state() (State, error) {
+	var booking entities.BookingMade
+	if err := json.Unmarshal(r.Booking, &booking); err != nil {
+		return State{}, fmt.Errorf("failed to unmarshal booking of saga %s: %w", r.BookingID, err)
+	}
+
+	return State{
+		BookingID:        r.BookingID,
+		TenantID:         r.TenantID,
+		Status:           Status(r.Status),
+		Booking:          booking,
+		TicketIDs:        r.TicketIDs,
+		PaymentReference: r.PaymentReference,
+		FailureReason:    r.FailureReason,
+		Deadline:         r.Deadline,
+		CreatedAt:        r.CreatedAt,
+		UpdatedAt:        r.UpdatedAt,
+	}, nil
+}
+
+const stateColumns = `booking_id, tenant_id, status, booking, ticket_ids, payment_reference, failure_reason, deadline, created_at, updated_at`
+
+func (r *PostgresRepository) Create(ctx context.Context, state State) error {
+	booking, err := json.Marshal(state.Booking)
+	if err != nil {
+		return fmt.Errorf("failed to marshal booking: %w", err)
+	}
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO booking_sagas (`+stateColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (booking_id) DO NOTHING`,
+		state.BookingID,
+		state.TenantID,
+		state.Status,
+		booking,
+		pq.StringArray(state.TicketIDs),
+		state.PaymentReference,
+		state.FailureReason,
+		state.Deadline,
+		state.CreatedAt,
+		state.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create booking saga: %w", err)
+	}
+
+	return nil
+}
+
+// Update locks the row of the saga with SELECT FOR UPDATE, in a transaction open while update runs,
+// including the messages it sends: slow publishing keeps the transaction and its connection busy.
+func (r *PostgresRepository) Update(ctx context.Context, bookingID string, update func(state *State) error) (State, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return State{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var row stateRow
+	err = tx.GetContext(ctx, &row, `SELECT `+stateColumns+` FROM booking_sagas WHERE booking_id = $1 FOR UPDATE`, bookingID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return State{}, ErrSagaNotFound
+	}
+	if err != nil {
+		return State{}, fmt.Errorf("failed to get booking saga: %w", err)
+	}
+
+	state, err := row.state()
+	if err != nil {
+		return State{}, err
+	}
+
+	if err := update(&state); err != nil {
+		return State{}, err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE booking_sagas
+		SET status = $2, ticket_ids = $3, payment_reference = $4, failure_reason = $5, deadline = $6, updated_at = $7
+		WHERE booking_id = $1`,
+		state.BookingID,
+		state.Status,
+		pq.StringArray(state.TicketIDs),
+		state.PaymentReference,
+		state.FailureReason,
+		state.Deadline,
+		state.UpdatedAt,
+	)
+	if err != nil {
+		return State{}, fmt.Errorf("failed to update booking saga: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return State{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return state, nil
+}
+
+func (r *PostgresRepository) ListTimedOut(ctx context.Context, now time.Time, limit int) ([]State, error) {
+	statuses := make(pq.StringArray, 0, len(inProgressStatuses))
+	for _, status := range inProgressStatuses {
+		statuses = append(statuses, string(status))
+	}
+
+	var rows []stateRow
+	err := r.db.SelectContext(
+		ctx,
+		&rows,
+		`SELECT `+stateColumns+` FROM booking_sagas
+		WHERE status = ANY($1) AND deadline < $2
+		ORDER BY deadline
+		LIMIT $3`,
+		statuses,
+		now,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list timed out booking sagas: %w", err)
+	}
+
+	states := make([]State, 0, len(rows))
+	for _, row := range rows {
+		state, err := row.state()
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+
+	return states, nil
+}
+
+// PostgresTicketsRepository keeps reserved tickets in the tickets table created by db/schema.sql.
+type PostgresTicketsRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresTicketsRepository(db *sqlx.DB) *PostgresTicketsRepository {
+	if db == nil {
+		panic("missing db")
+	}
+
+	return &PostgresTicketsRepository{db: db}
+}
+
+func (r *PostgresTicketsRepository) ReserveTickets(ctx context.Context, tenantID string, bookingID string, tickets []entities.Ticket) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, ticket := range tickets {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO tickets (ticket_id, price_amount, price_currency, customer_email, tenant_id, booking_id)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (ticket_id) DO NOTHING`,
+			ticket.TicketID,
+			ticket.Price.Amount,
+			ticket.Price.Currency,
+			ticket.CustomerEmail,
+			tenantID,
+			bookingID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to reserve ticket %s: %w", ticket.TicketID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresTicketsRepository) ReleaseTickets(ctx context.Context, bookingID string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM tickets WHERE booking_id = $1`, bookingID); err != nil {
+		return fmt.Errorf("failed to release tickets: %w", err)
+	}
+
+	return nil
+}
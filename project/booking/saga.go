@@ -0,0 +1,356 @@
+// Package booking coordinates bookings with a saga: tickets are reserved, the payment is captured,
+// receipts are issued, and then each ticket is confirmed with TicketBookingConfirmed.
+//
+// Steps run as commands, and report their results with events. When a step fails, or the booking
+// isn't confirmed before its deadline, compensating commands undo the steps that may have completed.
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"tickets/config"
+	"tickets/entities"
+	"tickets/tenant"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// timedOutBatch is how many timed out sagas are compensated per check.
+const timedOutBatch = 100
+
+type CommandBus interface {
+	Send(ctx context.Context, command any) error
+}
+
+type EventBus interface {
+	Publish(ctx context.Context, event any) error
+}
+
+// Saga is the process manager of bookings. Its state is kept in Repository, so it survives restarts.
+//
+// Commands and events are sent before the state is saved: if saving fails, the message is redelivered and they
+// are sent again. Their IDs are derived from the booking, so messages sent again have the same IDs, and
+// handlers of the steps are idempotent, so delivery is at-least-once.
+//
+// Messages are sent while Repository.Update holds the lock of the saga, so messages of a booking are handled
+// one at a time, and the next state is saved only with the messages sent. The lock is held for as long as
+// the broker takes to accept them, which is bounded by the context of the handled message.
+type Saga struct {
+	repo       Repository
+	commandBus CommandBus
+	eventBus   EventBus
+
+	config config.BookingSaga
+	now    func() time.Time
+}
+
+func NewSaga(repo Repository, commandBus CommandBus, eventBus EventBus, cfg config.BookingSaga) *Saga {
+	if repo == nil {
+		panic("missing repo")
+	}
+	if commandBus == nil {
+		panic("missing commandBus")
+	}
+	if eventBus == nil {
+		panic("missing eventBus")
+	}
+
+	return &Saga{
+		repo:       repo,
+		commandBus: commandBus,
+		eventBus:   eventBus,
+		config:     cfg,
+		now:        time.Now,
+	}
+}
+
+func (s *Saga) OnBookingMade(ctx context.Context, event *entities.BookingMade) error {
+	if event.NumberOfTickets < 1 {
+		log.FromContext(ctx).WithField("booking_id", event.BookingID).Warn("Ignoring booking without tickets")
+		return nil
+	}
+
+	bookingID := event.BookingID.String()
+	now := s.now().UTC()
+
+	err := s.repo.Create(ctx, State{
+		BookingID: bookingID,
+		TenantID:  tenantID(event.Header),
+		Status:    StatusStarted,
+		Booking:   *event,
+		TicketIDs: ticketIDs(event.BookingID, event.NumberOfTickets),
+		Deadline:  now.Add(s.config.Timeout),
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	// a redelivered BookingMade finds the saga already created, and sends the command only if it wasn't sent yet
+	return s.update(ctx, bookingID, func(state *State) error {
+		if state.Status != StatusStarted {
+			return nil
+		}
+
+		err := s.commandBus.Send(ctx, entities.ReserveTickets{
+			Header:        s.header(state, "reserve-tickets"),
+			BookingID:     state.BookingID,
+			ShowID:        state.Booking.ShowId.String(),
+			CustomerEmail: state.Booking.CustomerEmail,
+			TicketIDs:     state.TicketIDs,
+			TicketPrice:   state.Booking.TicketPrice,
+		})
+		if err != nil {
+			return err
+		}
+
+		state.Status = StatusReservingTickets
+		return nil
+	})
+}
+
+func (s *Saga) OnTicketsReserved(ctx context.Context, event *entities.TicketsReserved) error {
+	return s.update(ctx, event.BookingID, func(state *State) error {
+		switch state.Status {
+		case StatusReservingTickets:
+		case StatusFailed:
+			// the reservation completed after the booking was failed
+			return s.commandBus.Send(ctx, entities.ReleaseTickets{
+				Header:    s.header(state, "release-tickets"),
+				BookingID: state.BookingID,
+			})
+		default:
+			return nil
+		}
+
+		amount, err := multiply(state.Booking.TicketPrice, len(state.TicketIDs))
+		if err != nil {
+			return err
+		}
+
+		err = s.commandBus.Send(ctx, entities.CapturePayment{
+			Header:        s.header(state, "capture-payment"),
+			BookingID:     state.BookingID,
+			CustomerEmail: state.Booking.CustomerEmail,
+			Amount:        amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		state.Status = StatusCapturingPayment
+		return nil
+	})
+}
+
+func (s *Saga) OnPaymentCaptured(ctx context.Context, event *entities.PaymentCaptured) error {
+	return s.update(ctx, event.BookingID, func(state *State) error {
+		switch state.Status {
+		case StatusCapturingPayment:
+		case StatusFailed:
+			// the payment was captured after the booking was failed
+			return s.commandBus.Send(ctx, entities.RefundPayment{
+				Header:           s.header(state, "refund-payment"),
+				BookingID:        state.BookingID,
+				PaymentReference: event.PaymentReference,
+				Reason:           state.FailureReason,
+			})
+		default:
+			return nil
+		}
+
+		err := s.commandBus.Send(ctx, entities.IssueReceipts{
+			Header:      s.header(state, "issue-receipts"),
+			BookingID:   state.BookingID,
+			TicketIDs:   state.TicketIDs,
+			TicketPrice: state.Booking.TicketPrice,
+		})
+		if err != nil {
+			return err
+		}
+
+		state.Status = StatusIssuingReceipts
+		state.PaymentReference = event.PaymentReference
+		return nil
+	})
+}
+
+func (s *Saga) OnReceiptsIssued(ctx context.Context, event *entities.ReceiptsIssued) error {
+	return s.update(ctx, event.BookingID, func(state *State) error {
+		switch state.Status {
+		case StatusIssuingReceipts:
+		case StatusFailed:
+			// receipts were issued after the booking was failed
+			return s.commandBus.Send(ctx, entities.VoidReceipts{
+				Header:    s.header(state, "void-receipts"),
+				BookingID: state.BookingID,
+				TicketIDs: state.TicketIDs,
+				Reason:    state.FailureReason,
+			})
+		default:
+			return nil
+		}
+
+		for _, ticketID := range state.TicketIDs {
+			err := s.eventBus.Publish(ctx, entities.TicketBookingConfirmed{
+				Header:        s.header(state, "confirmed/"+ticketID),
+				TicketID:      ticketID,
+				CustomerEmail: state.Booking.CustomerEmail,
+				Price:         state.Booking.TicketPrice,
+				BookingID:     state.BookingID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		state.Status = StatusConfirmed
+		return nil
+	})
+}
+
+func (s *Saga) OnBookingStepFailed(ctx context.Context, event *entities.BookingStepFailed) error {
+	return s.update(ctx, event.BookingID, func(state *State) error {
+		return s.compensate(ctx, state, fmt.Sprintf("%s failed: %s", event.Step, event.Reason))
+	})
+}
+
+// Run compensates timed out bookings every check interval, until ctx is done.
+func (s *Saga) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := s.CompensateTimedOut(ctx); err != nil {
+				log.FromContext(ctx).WithError(err).Error("Failed to compensate timed out bookings")
+			}
+		}
+	}
+}
+
+// CompensateTimedOut fails bookings which weren't confirmed before their deadline.
+func (s *Saga) CompensateTimedOut(ctx context.Context) error {
+	timedOut, err := s.repo.ListTimedOut(ctx, s.now(), timedOutBatch)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, state := range timedOut {
+		err := s.update(ctx, state.BookingID, func(state *State) error {
+			// the saga could move on since it was listed
+			if !inProgress(state.Status) || !state.Deadline.Before(s.now()) {
+				return nil
+			}
+
+			return s.compensate(ctx, state, "timed out in "+string(state.Status))
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("booking %s: %w", state.BookingID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// compensate sends commands undoing steps that may have completed, or may still complete, and fails the booking.
+func (s *Saga) compensate(ctx context.Context, state *State, reason string) error {
+	if !inProgress(state.Status) {
+		return nil
+	}
+
+	// compensating commands have the same IDs as the ones sent when a step completes after the booking failed,
+	// the step is undone once
+	var commands []any
+	if state.Status == StatusIssuingReceipts {
+		commands = append(commands,
+			entities.VoidReceipts{Header: s.header(state, "void-receipts"), BookingID: state.BookingID, TicketIDs: state.TicketIDs, Reason: reason},
+			entities.RefundPayment{Header: s.header(state, "refund-payment"), BookingID: state.BookingID, PaymentReference: state.PaymentReference, Reason: reason},
+		)
+	}
+	// a payment still being captured is refunded when PaymentCaptured arrives
+	if state.Status != StatusStarted {
+		commands = append(commands, entities.ReleaseTickets{Header: s.header(state, "release-tickets"), BookingID: state.BookingID})
+	}
+
+	for _, command := range commands {
+		if err := s.commandBus.Send(ctx, command); err != nil {
+			return err
+		}
+	}
+
+	err := s.eventBus.Publish(ctx, entities.BookingFailed{
+		Header:    s.header(state, "failed"),
+		BookingID: state.BookingID,
+		Reason:    reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).WithFields(logrus.Fields{
+		"booking_id": state.BookingID,
+		"status":     state.Status,
+		"reason":     reason,
+	}).Warn("Booking failed, compensating")
+
+	state.Status = StatusFailed
+	state.FailureReason = reason
+	return nil
+}
+
+// update ignores events of unknown bookings, like ones made before the saga was enabled.
+func (s *Saga) update(ctx context.Context, bookingID string, update func(state *State) error) error {
+	_, err := s.repo.Update(ctx, bookingID, func(state *State) error {
+		if err := update(state); err != nil {
+			return err
+		}
+
+		state.UpdatedAt = s.now().UTC()
+		return nil
+	})
+	if errors.Is(err, ErrSagaNotFound) {
+		log.FromContext(ctx).WithField("booking_id", bookingID).Warn("Ignoring event of unknown booking")
+		return nil
+	}
+
+	return err
+}
+
+// header returns the header of the message of the booking named name. Its ID is derived from the booking ID
+// and name, so the message has the same ID when it's sent again.
+func (s *Saga) header(state *State, name string) entities.EventHeader {
+	return entities.EventHeader{
+		ID:          uuid.NewSHA1(state.Booking.BookingID, []byte(name)).String(),
+		PublishedAt: s.now().UTC(),
+		TenantID:    state.TenantID,
+	}
+}
+
+// ticketIDs are derived from the booking ID, so they are the same when BookingMade is redelivered.
+func ticketIDs(bookingID uuid.UUID, numberOfTickets int) []string {
+	ids := make([]string, 0, numberOfTickets)
+	for i := 0; i < numberOfTickets; i++ {
+		ids = append(ids, uuid.NewSHA1(bookingID, []byte(strconv.Itoa(i))).String())
+	}
+
+	return ids
+}
+
+func tenantID(header entities.EventHeader) string {
+	if header.TenantID == "" {
+		return tenant.DefaultID
+	}
+
+	return header.TenantID
+}
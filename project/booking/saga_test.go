@@ -0,0 +1,118 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"tickets/config"
+	"tickets/entities"
+	"tickets/message/event"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaga_sends_messages_again_with_the_same_IDs(t *testing.T) {
+	ctx := context.Background()
+
+	repo := &failingSaveRepository{Repository: NewMemoryRepository()}
+	commandBus := &event.BusMock{}
+	eventBus := &event.BusMock{}
+	saga := NewSaga(repo, commandBusFunc(commandBus.Publish), eventBus, config.BookingSaga{Timeout: time.Minute})
+
+	issueReceipts := func(t *testing.T) string {
+		bookingID := uuid.New()
+		require.NoError(t, saga.OnBookingMade(ctx, &entities.BookingMade{
+			BookingID:       bookingID,
+			NumberOfTickets: 2,
+			TicketPrice:     entities.Money{Amount: "10.00", Currency: "EUR"},
+		}))
+		require.NoError(t, saga.OnTicketsReserved(ctx, &entities.TicketsReserved{BookingID: bookingID.String()}))
+		require.NoError(t, saga.OnPaymentCaptured(ctx, &entities.PaymentCaptured{BookingID: bookingID.String(), PaymentReference: "payment-1"}))
+
+		return bookingID.String()
+	}
+
+	t.Run("confirmed_tickets", func(t *testing.T) {
+		bookingID := issueReceipts(t)
+
+		repo.FailNextSave()
+		require.Error(t, saga.OnReceiptsIssued(ctx, &entities.ReceiptsIssued{BookingID: bookingID}))
+		require.NoError(t, saga.OnReceiptsIssued(ctx, &entities.ReceiptsIssued{BookingID: bookingID}))
+
+		published := eventBus.Published()
+		require.Len(t, published, 4)
+		first, second := published[0].(entities.TicketBookingConfirmed), published[1].(entities.TicketBookingConfirmed)
+		assert.NotEqual(t, first.Header.ID, second.Header.ID)
+		assert.Equal(t, first.Header.ID, published[2].(entities.TicketBookingConfirmed).Header.ID)
+		assert.Equal(t, second.Header.ID, published[3].(entities.TicketBookingConfirmed).Header.ID)
+	})
+
+	t.Run("compensation", func(t *testing.T) {
+		bookingID := issueReceipts(t)
+		sentBefore := len(commandBus.Published())
+
+		failed := &entities.BookingStepFailed{BookingID: bookingID, Step: "IssueReceipts", Reason: "gateway down"}
+		repo.FailNextSave()
+		require.Error(t, saga.OnBookingStepFailed(ctx, failed))
+		require.NoError(t, saga.OnBookingStepFailed(ctx, failed))
+		// receipts issued after all are voided by the same command
+		require.NoError(t, saga.OnReceiptsIssued(ctx, &entities.ReceiptsIssued{BookingID: bookingID}))
+
+		sent := commandBus.Published()[sentBefore:]
+		require.Len(t, sent, 7)
+		for i := 0; i < 3; i++ {
+			assert.NotEmpty(t, commandID(sent[i]))
+			assert.Equal(t, commandID(sent[i]), commandID(sent[i+3]))
+		}
+		assert.Equal(t, commandID(sent[0]), commandID(sent[6]))
+		assert.IsType(t, entities.VoidReceipts{}, sent[6])
+	})
+}
+
+var errSaveFailed = errors.New("save failed")
+
+// failingSaveRepository fails saving the next update, after update ran.
+type failingSaveRepository struct {
+	Repository
+
+	failNextSave bool
+}
+
+func (r *failingSaveRepository) FailNextSave() {
+	r.failNextSave = true
+}
+
+func (r *failingSaveRepository) Update(ctx context.Context, bookingID string, update func(state *State) error) (State, error) {
+	return r.Repository.Update(ctx, bookingID, func(state *State) error {
+		if err := update(state); err != nil {
+			return err
+		}
+		if r.failNextSave {
+			r.failNextSave = false
+			return errSaveFailed
+		}
+		return nil
+	})
+}
+
+type commandBusFunc func(ctx context.Context, command any) error
+
+func (f commandBusFunc) Send(ctx context.Context, command any) error {
+	return f(ctx, command)
+}
+
+func commandID(command any) string {
+	switch c := command.(type) {
+	case entities.VoidReceipts:
+		return c.Header.ID
+	case entities.RefundPayment:
+		return c.Header.ID
+	case entities.ReleaseTickets:
+		return c.Header.ID
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,59 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"tickets/entities"
+	"time"
+)
+
+var ErrSagaNotFound = errors.New("booking saga not found")
+
+type Status string
+
+const (
+	StatusStarted          Status = "started"
+	StatusReservingTickets Status = "reserving_tickets"
+	StatusCapturingPayment Status = "capturing_payment"
+	StatusIssuingReceipts  Status = "issuing_receipts"
+	// StatusConfirmed and StatusFailed are final.
+	StatusConfirmed Status = "confirmed"
+	StatusFailed    Status = "failed"
+)
+
+// inProgressStatuses can time out.
+var inProgressStatuses = []Status{
+	StatusStarted,
+	StatusReservingTickets,
+	StatusCapturingPayment,
+	StatusIssuingReceipts,
+}
+
+// State of the saga of a booking.
+type State struct {
+	BookingID string
+	TenantID  string
+	Status    Status
+
+	Booking   entities.BookingMade
+	TicketIDs []string
+	// PaymentReference is set once the payment is captured.
+	PaymentReference string
+	// FailureReason tells why the booking failed.
+	FailureReason string
+
+	// Deadline is when the booking is failed unless it's confirmed.
+	Deadline  time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Repository interface {
+	// Create stores the saga, unless there is one for the booking already.
+	Create(ctx context.Context, state State) error
+	// Update applies update to the saga atomically, holding the lock of the saga while update runs.
+	// It returns ErrSagaNotFound if there is no such saga.
+	Update(ctx context.Context, bookingID string, update func(state *State) error) (State, error)
+	// ListTimedOut returns up to limit of in-progress sagas past their deadline.
+	ListTimedOut(ctx context.Context, now time.Time, limit int) ([]State, error)
+}
@@ -0,0 +1,46 @@
+// Command fake-gateway serves an in-memory stand-in for the gateway, so the service can run locally without it.
+//
+//	go run ./cmd/fake-gateway -addr :8888
+//	GATEWAY_ADDR=http://localhost:8888 go run .
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"tickets/fakegateway"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	addr := flag.String("addr", ":8888", "address to listen on")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	server := &http.Server{
+		Addr:    *addr,
+		Handler: fakegateway.NewServer().Handler(),
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	logrus.WithField("addr", *addr).Info("Fake gateway listening")
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logrus.WithError(err).Fatal("Fake gateway failed")
+	}
+}
@@ -0,0 +1,109 @@
+// Command streams-lag reports length, pending messages and lag of the service's consumer groups.
+// It takes the same configuration as the service.
+//
+//	REDIS_ADDR=localhost:6379 go run ./cmd/streams-lag -json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"tickets/config"
+	"tickets/message"
+	"tickets/message/streams"
+	"time"
+)
+
+func main() {
+	args := os.Args[1:]
+
+	asJSON := false
+	if len(args) > 0 && args[0] == "-json" {
+		asJSON = true
+		args = args[1:]
+	}
+
+	cfg, err := config.LoadPartial(args)
+	if err == nil && cfg.Redis.Addr == "" {
+		err = errors.New("redis.addr is required")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err)
+		os.Exit(2)
+	}
+
+	redisClient := message.NewRedisClient(cfg.Redis)
+	defer redisClient.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	reports, err := streams.NewInspector(redisClient, cfg.Messaging.ConsumerGroupPrefix).Inspect(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(reports); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	printTable(reports)
+}
+
+func printTable(reports []streams.StreamReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "TOPIC\tLENGTH\tGROUP\tLAST DELIVERED\tLAG\tPENDING\tOLDEST PENDING AGE\tOLDEST PENDING IDLE\tCONSUMERS PENDING")
+
+	for _, stream := range reports {
+		for _, group := range stream.Groups {
+			fmt.Fprintf(
+				w,
+				"%s\t%d\t%s\t%s\t%d\t%d\t%s\t%s\t%s\n",
+				stream.Topic,
+				stream.Length,
+				group.Name,
+				group.LastDeliveredID,
+				group.Lag,
+				group.Pending,
+				time.Duration(group.OldestPendingAgeMs)*time.Millisecond,
+				time.Duration(group.OldestPendingIdleMs)*time.Millisecond,
+				formatConsumers(group.ConsumersPending),
+			)
+		}
+	}
+}
+
+func formatConsumers(consumers map[string]int64) string {
+	if len(consumers) == 0 {
+		return "-"
+	}
+
+	names := make([]string, 0, len(consumers))
+	for name := range consumers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	formatted := ""
+	for i, name := range names {
+		if i > 0 {
+			formatted += ","
+		}
+		formatted += fmt.Sprintf("%s=%d", name, consumers[name])
+	}
+
+	return formatted
+}
@@ -0,0 +1,1028 @@
+package config
+
+import (
+	"crypto/subtle"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"tickets/tenant"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	HTTP            HTTP            `yaml:"http"`
+	Gateway         Gateway         `yaml:"gateway"`
+	Redis           Redis           `yaml:"redis"`
+	Postgres        Postgres        `yaml:"postgres"`
+	Auth            Auth            `yaml:"auth"`
+	Messaging       Messaging       `yaml:"messaging"`
+	Spreadsheets    Spreadsheets    `yaml:"spreadsheets"`
+	Health          Health          `yaml:"health"`
+	Shutdown        Shutdown        `yaml:"shutdown"`
+	Retention       Retention       `yaml:"retention"`
+	Webhooks        Webhooks        `yaml:"webhooks"`
+	InboundWebhooks InboundWebhooks `yaml:"inbound_webhooks"`
+	EventsStream    EventsStream    `yaml:"events_stream"`
+	Tenants         Tenants         `yaml:"tenants"`
+	BookingSaga     BookingSaga     `yaml:"booking_saga"`
+	Scheduler       Scheduler       `yaml:"scheduler"`
+	Notifications   Notifications   `yaml:"notifications"`
+	Reconciliation  Reconciliation  `yaml:"reconciliation"`
+	DailySummary    DailySummary    `yaml:"daily_summary"`
+	Log             Log             `yaml:"log"`
+}
+
+// minSecretLength makes signing secrets hard to guess.
+const minSecretLength = 16
+
+type HTTP struct {
+	// Port 0 picks any free port.
+	Port int `yaml:"port"`
+	// StrictResponseValidation replaces responses not matching the OpenAPI spec with 500, instead of only logging them.
+	StrictResponseValidation bool `yaml:"strict_response_validation"`
+}
+
+func (h HTTP) Addr() string {
+	return fmt.Sprintf(":%d", h.Port)
+}
+
+// Auth configures API keys, sent by clients as "Authorization: Bearer <key>".
+// Endpoints are closed to everyone when there are no keys for them.
+type Auth struct {
+	// AdminKeys authorize the /admin endpoints.
+	AdminKeys []string `yaml:"admin_keys"`
+	// TenantKeys authorize webhook subscriptions and the events stream of a tenant, by tenant ID.
+	// The key identifies the tenant, the Tenant-ID header can't select another one.
+	TenantKeys map[string][]string `yaml:"tenant_keys"`
+}
+
+// Admin tells if key is one of the admin keys.
+func (a Auth) Admin(key string) bool {
+	return matchKey(a.AdminKeys, key)
+}
+
+// Tenant returns the tenant whose key it is, false if it's no tenant's key.
+func (a Auth) Tenant(key string) (string, bool) {
+	tenantID := ""
+	for id, keys := range a.TenantKeys {
+		// all tenants are compared, so the time doesn't reveal which one matched
+		if matchKey(keys, key) {
+			tenantID = id
+		}
+	}
+
+	return tenantID, tenantID != ""
+}
+
+// matchKey compares key with all keys in constant time, so the comparison doesn't reveal how much of a key matched.
+func matchKey(keys []string, key string) bool {
+	matched := 0
+	for _, k := range keys {
+		matched |= subtle.ConstantTimeCompare([]byte(k), []byte(key))
+	}
+
+	return key != "" && matched == 1
+}
+
+type Gateway struct {
+	Addr string `yaml:"addr"`
+}
+
+type Redis struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+}
+
+type Postgres struct {
+	URL string `yaml:"url"`
+}
+
+type Messaging struct {
+	// Transport is one of: redis, postgres, gochannel.
+	Transport           string `yaml:"transport"`
+	ConsumerGroupPrefix string `yaml:"consumer_group_prefix"`
+	Retry               Retry  `yaml:"retry"`
+	// SpreadsheetsBatch configures batching of rows appended to spreadsheets.
+	SpreadsheetsBatch Batch `yaml:"spreadsheets_batch"`
+	// ProtobufEvents are names of events published as protobuf instead of JSON.
+	ProtobufEvents []string `yaml:"protobuf_events"`
+	// CloudEventsMode is empty for plain events, or the CloudEvents content mode: binary or structured.
+	CloudEventsMode string `yaml:"cloudevents_mode"`
+}
+
+type Retry struct {
+	MaxRetries      int           `yaml:"max_retries"`
+	InitialInterval time.Duration `yaml:"initial_interval"`
+	MaxInterval     time.Duration `yaml:"max_interval"`
+	Multiplier      float64       `yaml:"multiplier"`
+}
+
+// Batch configures handling messages in batches.
+type Batch struct {
+	// MaxSize is how many messages are handled at most at once, 1 disables batching.
+	MaxSize int `yaml:"max_size"`
+	// MaxWait is how long the first message of a batch waits for others.
+	MaxWait time.Duration `yaml:"max_wait"`
+}
+
+type Spreadsheets struct {
+	TicketsToPrint  string `yaml:"tickets_to_print"`
+	TicketsToRefund string `yaml:"tickets_to_refund"`
+	DailySummary    string `yaml:"daily_summary"`
+}
+
+// Tenants are the tenants accepted besides tenant.DefaultID, by ID.
+type Tenants map[string]Tenant
+
+type Tenant struct {
+	// Spreadsheets override sheet names of the tenant. By default, the global names are prefixed with "<tenant ID>-".
+	Spreadsheets Spreadsheets `yaml:"spreadsheets"`
+	// DedicatedTopics routes events of the tenant to their own topics, consumed by their own consumer groups,
+	// so a busy tenant doesn't delay others.
+	DedicatedTopics bool `yaml:"dedicated_topics"`
+}
+
+// Known tells if requests of the tenant are accepted.
+func (t Tenants) Known(tenantID string) bool {
+	if tenantID == tenant.DefaultID {
+		return true
+	}
+
+	_, ok := t[tenantID]
+	return ok
+}
+
+// Spreadsheets returns sheet names of the tenant. The default tenant uses the global names, unless overridden.
+func (t Tenants) Spreadsheets(tenantID string, global Spreadsheets) Spreadsheets {
+	sheets := t[tenantID].Spreadsheets
+
+	prefix := ""
+	if tenantID != tenant.DefaultID {
+		prefix = tenantID + "-"
+	}
+
+	if sheets.TicketsToPrint == "" {
+		sheets.TicketsToPrint = prefix + global.TicketsToPrint
+	}
+	if sheets.TicketsToRefund == "" {
+		sheets.TicketsToRefund = prefix + global.TicketsToRefund
+	}
+	if sheets.DailySummary == "" {
+		sheets.DailySummary = prefix + global.DailySummary
+	}
+
+	return sheets
+}
+
+// DedicatedTopic returns the tenant ID if its events have dedicated topics, or "" if they use the shared ones.
+func (t Tenants) DedicatedTopic(tenantID string) string {
+	if t[tenantID].DedicatedTopics {
+		return tenantID
+	}
+
+	return ""
+}
+
+// WithDedicatedTopics returns IDs of tenants with dedicated topics, sorted.
+func (t Tenants) WithDedicatedTopics() []string {
+	var ids []string
+	for id, settings := range t {
+		if settings.DedicatedTopics {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+type Health struct {
+	CheckTimeout time.Duration `yaml:"check_timeout"`
+	CheckGateway bool          `yaml:"check_gateway"`
+}
+
+type Shutdown struct {
+	// HTTPTimeout is how long in-flight HTTP requests may take after the server stops accepting new ones.
+	HTTPTimeout time.Duration `yaml:"http_timeout"`
+	// DrainTimeout is how long in-flight messages may take before their handlers are cancelled.
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
+	// CloseTimeout is how long closing the router may take after draining.
+	CloseTimeout time.Duration `yaml:"close_timeout"`
+}
+
+// Webhooks configures delivery of events to partners' subscriptions.
+type Webhooks struct {
+	// Timeout of a single delivery request.
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxAttempts of delivering an event to a subscription, with exponential backoff in between.
+	// Retries are scheduled, so they may be up to Scheduler.CheckInterval later than the backoff.
+	MaxAttempts    int           `yaml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+	// DisableAfterFailures is the number of consecutive events that couldn't be delivered after which
+	// a subscription is disabled.
+	DisableAfterFailures int `yaml:"disable_after_failures"`
+	// AllowPrivateAddresses lets subscriptions deliver to loopback, private and link-local addresses,
+	// which are refused by default, so subscriptions can't reach the service's own network.
+	AllowPrivateAddresses bool `yaml:"allow_private_addresses"`
+}
+
+// InboundWebhooks configures verification of /tickets-status requests sent by the upstream system.
+type InboundWebhooks struct {
+	// Secrets verify signatures of /tickets-status requests. Any of them may sign a request, so they can be rotated.
+	// They are required, unless AllowUnsigned is set.
+	Secrets []string `yaml:"secrets"`
+	// AllowUnsigned accepts requests without verifying them when there are no secrets, for local development.
+	AllowUnsigned bool `yaml:"allow_unsigned"`
+	// Tolerance is how far a request timestamp may be from the current time.
+	Tolerance time.Duration `yaml:"tolerance"`
+}
+
+// EventsStream configures the Server-Sent Events stream of live events.
+type EventsStream struct {
+	// HeartbeatInterval is how often a comment is sent on idle connections, so proxies don't close them.
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval"`
+	// MaxConnections is how many streams are served at once with the Redis streams transport,
+	// each holds its own Redis connection.
+	MaxConnections int `yaml:"max_connections"`
+}
+
+// BookingSaga configures the process manager coordinating bookings: reserving tickets, capturing payment,
+// issuing receipts and confirming tickets, with compensation of completed steps when a booking fails.
+type BookingSaga struct {
+	// Enabled makes bookings processed by the saga, it needs a payments service capturing payments.
+	Enabled bool `yaml:"enabled"`
+	// Timeout is how long a booking may take before it's failed and compensated.
+	Timeout time.Duration `yaml:"timeout"`
+	// CheckInterval is how often timed out bookings are looked for.
+	CheckInterval time.Duration `yaml:"check_interval"`
+}
+
+// Scheduler releases messages published for later delivery.
+type Scheduler struct {
+	// CheckInterval is how often due messages are looked for, so it's how late they can be delivered.
+	CheckInterval time.Duration `yaml:"check_interval"`
+	// ClaimTimeout is after how long a message claimed by an instance which didn't publish it is released again.
+	ClaimTimeout time.Duration `yaml:"claim_timeout"`
+	// BatchSize is how many due messages are claimed at once.
+	BatchSize int `yaml:"batch_size"`
+}
+
+// Notifications configures emails sent to customers about their tickets.
+type Notifications struct {
+	// Transport is one of: smtp, outbox (files in OutboxDir, for local development), or empty to send no emails.
+	Transport string `yaml:"transport"`
+	// From is the sender address of emails.
+	From      string `yaml:"from"`
+	SMTP      SMTP   `yaml:"smtp"`
+	OutboxDir string `yaml:"outbox_dir"`
+	// DeduplicationTTL is how long sent emails are remembered, so redelivered events don't send them again.
+	DeduplicationTTL time.Duration `yaml:"deduplication_ttl"`
+}
+
+type SMTP struct {
+	// Addr is host:port of the server. STARTTLS is used when the server supports it.
+	Addr string `yaml:"addr"`
+	// Username and Password authenticate with PLAIN auth, which needs TLS unless the server is on localhost.
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// Timeout of sending a single email.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Reconciliation compares tickets confirmed and canceled by events with rows of the tracker spreadsheets.
+type Reconciliation struct {
+	// Interval between runs, 0 disables periodic runs: they can still be triggered with the HTTP API.
+	Interval time.Duration `yaml:"interval"`
+	// GracePeriod is how long rows of tickets may be missing, before events appending them are handled.
+	// It applies again after events of tickets with missing rows are republished.
+	GracePeriod time.Duration `yaml:"grace_period"`
+	// Window is how long before the grace period tickets are checked, older ones are not.
+	Window time.Duration `yaml:"window"`
+	// Republish publishes events of tickets with missing rows again, so their rows are appended.
+	Republish bool `yaml:"republish"`
+}
+
+// DailySummary summarizes sales of the previous day, once a day.
+type DailySummary struct {
+	// Enabled makes the service append summaries to the daily-summary sheets of all tenants.
+	Enabled bool `yaml:"enabled"`
+	// RunAt is the time of day, HH:MM in TimeZone, after which the previous day is summarized.
+	RunAt string `yaml:"run_at"`
+	// TimeZone is the IANA name of the time zone days are in.
+	TimeZone string `yaml:"time_zone"`
+	// CheckInterval is how often it's checked if the previous day should be summarized.
+	CheckInterval time.Duration `yaml:"check_interval"`
+	// LockTTL is how long a replica summarizing holds the lock, if it stops without releasing it.
+	LockTTL time.Duration `yaml:"lock_ttl"`
+}
+
+// Location returns the time zone of days, UTC if it's invalid.
+func (d DailySummary) Location() *time.Location {
+	location, err := time.LoadLocation(d.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+// RunAfter returns how long after midnight the previous day is summarized.
+func (d DailySummary) RunAfter() time.Duration {
+	runAt, err := time.Parse("15:04", d.RunAt)
+	if err != nil {
+		return 0
+	}
+
+	return time.Duration(runAt.Hour())*time.Hour + time.Duration(runAt.Minute())*time.Minute
+}
+
+// Retention of Redis streams consumed by the service's consumer groups. Entries not yet delivered to,
+// or acknowledged by, all consumer groups are never trimmed, whatever the policy.
+type Retention struct {
+	// Interval between trimming runs, 0 (the default) disables trimming.
+	Interval time.Duration `yaml:"interval"`
+	// Default policy applies to topics without their own policy.
+	Default RetentionPolicy            `yaml:"default"`
+	Topics  map[string]RetentionPolicy `yaml:"topics"`
+}
+
+// RetentionPolicy trims entries exceeding any of the limits; zero means no limit.
+type RetentionPolicy struct {
+	// MaxLen is applied approximately, so a stream can be slightly longer.
+	MaxLen int64         `yaml:"max_len"`
+	MaxAge time.Duration `yaml:"max_age"`
+}
+
+func (r Retention) Policy(topic string) RetentionPolicy {
+	if policy, ok := r.Topics[topic]; ok {
+		return policy
+	}
+
+	return r.Default
+}
+
+type Log struct {
+	Level string `yaml:"level"`
+}
+
+// ParsedLevel returns the logrus level, falling back to info for invalid values (they are reported by Validate).
+func (l Log) ParsedLevel() logrus.Level {
+	level, err := logrus.ParseLevel(l.Level)
+	if err != nil {
+		return logrus.InfoLevel
+	}
+
+	return level
+}
+
+func Default() Config {
+	return Config{
+		HTTP: HTTP{
+			Port: 8080,
+		},
+		Messaging: Messaging{
+			Transport:           "redis",
+			ConsumerGroupPrefix: "svc-tickets.",
+			Retry: Retry{
+				MaxRetries:      10,
+				InitialInterval: time.Millisecond * 100,
+				MaxInterval:     time.Second,
+				Multiplier:      2,
+			},
+			SpreadsheetsBatch: Batch{
+				MaxSize: 10,
+				MaxWait: time.Millisecond * 200,
+			},
+		},
+		Spreadsheets: Spreadsheets{
+			TicketsToPrint:  "tickets-to-print",
+			TicketsToRefund: "tickets-to-refund",
+			DailySummary:    "daily-summary",
+		},
+		Health: Health{
+			CheckTimeout: time.Second * 2,
+		},
+		Shutdown: Shutdown{
+			HTTPTimeout:  time.Second * 10,
+			DrainTimeout: time.Second * 30,
+			CloseTimeout: time.Second * 5,
+		},
+		Webhooks: Webhooks{
+			Timeout:              time.Second * 10,
+			MaxAttempts:          4,
+			InitialBackoff:       time.Millisecond * 500,
+			MaxBackoff:           time.Second * 5,
+			DisableAfterFailures: 5,
+		},
+		InboundWebhooks: InboundWebhooks{
+			Tolerance: time.Minute * 5,
+		},
+		EventsStream: EventsStream{
+			HeartbeatInterval: time.Second * 15,
+			MaxConnections:    100,
+		},
+		BookingSaga: BookingSaga{
+			Timeout:       time.Minute * 5,
+			CheckInterval: time.Second * 10,
+		},
+		Scheduler: Scheduler{
+			CheckInterval: time.Second,
+			ClaimTimeout:  time.Second * 30,
+			BatchSize:     100,
+		},
+		Notifications: Notifications{
+			SMTP: SMTP{
+				Timeout: time.Second * 10,
+			},
+			DeduplicationTTL: time.Hour * 24 * 7,
+		},
+		Reconciliation: Reconciliation{
+			Interval:    time.Hour,
+			GracePeriod: time.Minute * 10,
+			Window:      time.Hour * 24 * 7,
+		},
+		DailySummary: DailySummary{
+			RunAt:         "01:00",
+			TimeZone:      "UTC",
+			CheckInterval: time.Minute,
+			LockTTL:       time.Minute * 5,
+		},
+		Retention: Retention{
+			Default: RetentionPolicy{
+				MaxAge: time.Hour * 24 * 7,
+			},
+		},
+		Log: Log{
+			Level: "info",
+		},
+	}
+}
+
+// Load builds the config from defaults, an optional YAML file, environment variables and flags
+// (in order of increasing precedence) and validates the result.
+//
+// The YAML file is read from the -config flag or the CONFIG_FILE environment variable.
+func Load(args []string) (Config, error) {
+	cfg, envErrs, err := load(args)
+	if err != nil {
+		return Config{}, err
+	}
+
+	// invalid environment variables are reported together with validation errors
+	if err := errors.Join(append(envErrs, cfg.Validate())...); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// LoadPartial loads the config like Load, but doesn't validate it.
+// It's meant for tools which use only a part of the config and validate it themselves.
+func LoadPartial(args []string) (Config, error) {
+	cfg, envErrs, err := load(args)
+	if err != nil {
+		return Config{}, err
+	}
+	if len(envErrs) > 0 {
+		return Config{}, errors.Join(envErrs...)
+	}
+
+	return cfg, nil
+}
+
+// load returns errors of environment variables separately, as they don't stop loading the rest of the config.
+func load(args []string) (Config, []error, error) {
+	cfg := Default()
+
+	if configFile := configFilePath(args); configFile != "" {
+		if err := cfg.loadFile(configFile); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	fs, bindings := cfg.flagSet()
+
+	var envErrs []error
+	for _, b := range bindings {
+		value, ok := os.LookupEnv(b.env)
+		if !ok {
+			continue
+		}
+
+		if err := fs.Set(b.flag, value); err != nil {
+			envErrs = append(envErrs, fmt.Errorf("invalid %s: %w", b.env, err))
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	return cfg, envErrs, nil
+}
+
+// Validate reports all configuration problems at once.
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.HTTP.Port < 0 || c.HTTP.Port > 65535 {
+		errs = append(errs, fmt.Errorf("http.port must be between 0 (any free port) and 65535, got %d", c.HTTP.Port))
+	}
+	if c.Gateway.Addr == "" {
+		errs = append(errs, errors.New("gateway.addr is required"))
+	}
+	if c.UsesRedis() && c.Redis.Addr == "" {
+		errs = append(errs, errors.New("redis.addr is required"))
+	}
+	if c.Redis.DB < 0 {
+		errs = append(errs, fmt.Errorf("redis.db must not be negative, got %d", c.Redis.DB))
+	}
+	if c.Postgres.URL == "" {
+		errs = append(errs, errors.New("postgres.url is required"))
+	}
+	switch c.Messaging.Transport {
+	case "redis", "postgres", "gochannel":
+	default:
+		errs = append(errs, fmt.Errorf("messaging.transport must be one of redis, postgres, gochannel, got %q", c.Messaging.Transport))
+	}
+	if c.Messaging.ConsumerGroupPrefix == "" {
+		errs = append(errs, errors.New("messaging.consumer_group_prefix is required"))
+	}
+	if c.Messaging.Retry.MaxRetries < 0 {
+		errs = append(errs, fmt.Errorf("messaging.retry.max_retries must not be negative, got %d", c.Messaging.Retry.MaxRetries))
+	}
+	if c.Messaging.Retry.InitialInterval <= 0 {
+		errs = append(errs, errors.New("messaging.retry.initial_interval must be positive"))
+	}
+	if c.Messaging.Retry.MaxInterval < c.Messaging.Retry.InitialInterval {
+		errs = append(errs, errors.New("messaging.retry.max_interval must not be lower than initial_interval"))
+	}
+	if c.Messaging.Retry.Multiplier < 1 {
+		errs = append(errs, fmt.Errorf("messaging.retry.multiplier must be at least 1, got %v", c.Messaging.Retry.Multiplier))
+	}
+	if c.Messaging.SpreadsheetsBatch.MaxSize < 1 {
+		errs = append(errs, fmt.Errorf("messaging.spreadsheets_batch.max_size must be at least 1, got %d", c.Messaging.SpreadsheetsBatch.MaxSize))
+	}
+	if c.Messaging.SpreadsheetsBatch.MaxWait <= 0 {
+		errs = append(errs, errors.New("messaging.spreadsheets_batch.max_wait must be positive"))
+	}
+	switch c.Messaging.CloudEventsMode {
+	case "", "binary", "structured":
+	default:
+		errs = append(errs, fmt.Errorf("messaging.cloudevents_mode must be empty, binary or structured, got %q", c.Messaging.CloudEventsMode))
+	}
+	if c.Spreadsheets.TicketsToPrint == "" {
+		errs = append(errs, errors.New("spreadsheets.tickets_to_print is required"))
+	}
+	if c.Spreadsheets.TicketsToRefund == "" {
+		errs = append(errs, errors.New("spreadsheets.tickets_to_refund is required"))
+	}
+	if c.Spreadsheets.DailySummary == "" {
+		errs = append(errs, errors.New("spreadsheets.daily_summary is required"))
+	}
+	if c.Health.CheckTimeout <= 0 {
+		errs = append(errs, errors.New("health.check_timeout must be positive"))
+	}
+	if c.Shutdown.HTTPTimeout <= 0 {
+		errs = append(errs, errors.New("shutdown.http_timeout must be positive"))
+	}
+	if c.Shutdown.DrainTimeout <= 0 {
+		errs = append(errs, errors.New("shutdown.drain_timeout must be positive"))
+	}
+	if c.Shutdown.CloseTimeout <= 0 {
+		errs = append(errs, errors.New("shutdown.close_timeout must be positive"))
+	}
+	if c.Webhooks.Timeout <= 0 {
+		errs = append(errs, errors.New("webhooks.timeout must be positive"))
+	}
+	if c.Webhooks.MaxAttempts < 1 {
+		errs = append(errs, errors.New("webhooks.max_attempts must be at least 1"))
+	}
+	if c.Webhooks.InitialBackoff < 0 || c.Webhooks.MaxBackoff < c.Webhooks.InitialBackoff {
+		errs = append(errs, errors.New("webhooks.initial_backoff must not be negative nor greater than webhooks.max_backoff"))
+	}
+	if c.Webhooks.DisableAfterFailures < 1 {
+		errs = append(errs, errors.New("webhooks.disable_after_failures must be at least 1"))
+	}
+	for i, key := range c.Auth.AdminKeys {
+		if len(key) < minSecretLength {
+			errs = append(errs, fmt.Errorf("auth.admin_keys[%d] must have at least %d characters", i, minSecretLength))
+		}
+	}
+	keyOwners := map[string]string{}
+	for _, key := range c.Auth.AdminKeys {
+		keyOwners[key] = "admin"
+	}
+	tenantIDs := make([]string, 0, len(c.Auth.TenantKeys))
+	for id := range c.Auth.TenantKeys {
+		tenantIDs = append(tenantIDs, id)
+	}
+	sort.Strings(tenantIDs)
+	for _, id := range tenantIDs {
+		if !c.Tenants.Known(id) {
+			errs = append(errs, fmt.Errorf("auth.tenant_keys: unknown tenant %q", id))
+		}
+		for i, key := range c.Auth.TenantKeys[id] {
+			if len(key) < minSecretLength {
+				errs = append(errs, fmt.Errorf("auth.tenant_keys.%s[%d] must have at least %d characters", id, i, minSecretLength))
+			}
+			if owner, ok := keyOwners[key]; ok && owner != id {
+				errs = append(errs, fmt.Errorf("auth.tenant_keys.%s[%d] is also a key of %s", id, i, owner))
+			}
+			keyOwners[key] = id
+		}
+	}
+	for i, secret := range c.InboundWebhooks.Secrets {
+		if len(secret) < minSecretLength {
+			errs = append(errs, fmt.Errorf("inbound_webhooks.secrets[%d] must have at least %d characters", i, minSecretLength))
+		}
+	}
+	if len(c.InboundWebhooks.Secrets) == 0 && !c.InboundWebhooks.AllowUnsigned {
+		errs = append(errs, errors.New("inbound_webhooks.secrets are required, unless inbound_webhooks.allow_unsigned is set"))
+	}
+	if c.InboundWebhooks.Tolerance <= 0 {
+		errs = append(errs, errors.New("inbound_webhooks.tolerance must be positive"))
+	}
+	for id := range c.Tenants {
+		if !tenant.ValidID(id) {
+			errs = append(errs, fmt.Errorf("tenants: invalid tenant ID %q, expected lowercase letters, digits and dashes", id))
+		}
+	}
+	if c.BookingSaga.Timeout <= 0 {
+		errs = append(errs, errors.New("booking_saga.timeout must be positive"))
+	}
+	if c.BookingSaga.CheckInterval <= 0 {
+		errs = append(errs, errors.New("booking_saga.check_interval must be positive"))
+	}
+	if c.Scheduler.CheckInterval <= 0 {
+		errs = append(errs, errors.New("scheduler.check_interval must be positive"))
+	}
+	if c.Scheduler.ClaimTimeout <= 0 {
+		errs = append(errs, errors.New("scheduler.claim_timeout must be positive"))
+	}
+	if c.Scheduler.BatchSize < 1 {
+		errs = append(errs, errors.New("scheduler.batch_size must be at least 1"))
+	}
+	switch c.Notifications.Transport {
+	case "":
+	case "smtp", "outbox":
+		if c.Notifications.From == "" {
+			errs = append(errs, errors.New("notifications.from is required"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("notifications.transport must be empty, smtp or outbox, got %q", c.Notifications.Transport))
+	}
+	if c.Notifications.Transport == "smtp" && c.Notifications.SMTP.Addr == "" {
+		errs = append(errs, errors.New("notifications.smtp.addr is required"))
+	}
+	if c.Notifications.Transport == "outbox" && c.Notifications.OutboxDir == "" {
+		errs = append(errs, errors.New("notifications.outbox_dir is required"))
+	}
+	if c.Notifications.SMTP.Timeout <= 0 {
+		errs = append(errs, errors.New("notifications.smtp.timeout must be positive"))
+	}
+	if c.Notifications.DeduplicationTTL <= 0 {
+		errs = append(errs, errors.New("notifications.deduplication_ttl must be positive"))
+	}
+	if c.Reconciliation.Interval < 0 {
+		errs = append(errs, errors.New("reconciliation.interval must not be negative"))
+	}
+	if c.Reconciliation.GracePeriod < 0 {
+		errs = append(errs, errors.New("reconciliation.grace_period must not be negative"))
+	}
+	if c.Reconciliation.Window <= 0 {
+		errs = append(errs, errors.New("reconciliation.window must be positive"))
+	}
+	if _, err := time.Parse("15:04", c.DailySummary.RunAt); err != nil {
+		errs = append(errs, fmt.Errorf("daily_summary.run_at must be HH:MM, got %q", c.DailySummary.RunAt))
+	}
+	if _, err := time.LoadLocation(c.DailySummary.TimeZone); err != nil {
+		errs = append(errs, fmt.Errorf("daily_summary.time_zone is invalid: %w", err))
+	}
+	if c.DailySummary.CheckInterval <= 0 {
+		errs = append(errs, errors.New("daily_summary.check_interval must be positive"))
+	}
+	if c.DailySummary.LockTTL <= 0 {
+		errs = append(errs, errors.New("daily_summary.lock_ttl must be positive"))
+	}
+	if c.EventsStream.HeartbeatInterval <= 0 {
+		errs = append(errs, errors.New("events_stream.heartbeat_interval must be positive"))
+	}
+	if c.EventsStream.MaxConnections < 1 {
+		errs = append(errs, fmt.Errorf("events_stream.max_connections must be at least 1, got %d", c.EventsStream.MaxConnections))
+	}
+	if c.Retention.Interval < 0 {
+		errs = append(errs, errors.New("retention.interval must not be negative"))
+	}
+	for topic, policy := range c.Retention.Topics {
+		if policy.MaxLen < 0 || policy.MaxAge < 0 {
+			errs = append(errs, fmt.Errorf("retention.topics.%s limits must not be negative", topic))
+		}
+	}
+	if c.Retention.Default.MaxLen < 0 || c.Retention.Default.MaxAge < 0 {
+		errs = append(errs, errors.New("retention.default limits must not be negative"))
+	}
+	if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// UsesRedis tells if the service needs a Redis connection.
+func (c Config) UsesRedis() bool {
+	return c.Messaging.Transport == "redis"
+}
+
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("failed to decode config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+type binding struct {
+	env  string
+	flag string
+}
+
+func (c *Config) flagSet() (*flag.FlagSet, []binding) {
+	fs := flag.NewFlagSet("tickets", flag.ContinueOnError)
+	fs.String("config", "", "path to YAML config file")
+
+	var bindings []binding
+	bind := func(env, name string) {
+		bindings = append(bindings, binding{env: env, flag: name})
+	}
+
+	fs.IntVar(&c.HTTP.Port, "http-port", c.HTTP.Port, "HTTP server port")
+	bind("HTTP_PORT", "http-port")
+	fs.BoolVar(&c.HTTP.StrictResponseValidation, "http-strict-response-validation", c.HTTP.StrictResponseValidation, "replace responses not matching the OpenAPI spec with 500")
+	bind("HTTP_STRICT_RESPONSE_VALIDATION", "http-strict-response-validation")
+
+	fs.StringVar(&c.Gateway.Addr, "gateway-addr", c.Gateway.Addr, "gateway address")
+	bind("GATEWAY_ADDR", "gateway-addr")
+
+	fs.StringVar(&c.Redis.Addr, "redis-addr", c.Redis.Addr, "Redis address")
+	bind("REDIS_ADDR", "redis-addr")
+	fs.StringVar(&c.Redis.Password, "redis-password", c.Redis.Password, "Redis password")
+	bind("REDIS_PASSWORD", "redis-password")
+	fs.IntVar(&c.Redis.DB, "redis-db", c.Redis.DB, "Redis database")
+	bind("REDIS_DB", "redis-db")
+
+	fs.StringVar(&c.Postgres.URL, "postgres-url", c.Postgres.URL, "PostgreSQL connection URL")
+	bind("POSTGRES_URL", "postgres-url")
+
+	fs.Var(stringList{&c.Auth.AdminKeys}, "admin-api-keys", "comma-separated API keys authorizing the /admin endpoints, empty to close them")
+	bind("ADMIN_API_KEYS", "admin-api-keys")
+	fs.Var(tenantKeyList{&c.Auth.TenantKeys}, "tenant-api-keys", "comma-separated tenant:key API keys authorizing webhook subscriptions and the events stream of tenants")
+	bind("TENANT_API_KEYS", "tenant-api-keys")
+
+	fs.StringVar(&c.Messaging.Transport, "transport", c.Messaging.Transport, "Pub/Sub transport: redis, postgres or gochannel")
+	bind("MESSAGING_TRANSPORT", "transport")
+	fs.StringVar(&c.Messaging.ConsumerGroupPrefix, "consumer-group-prefix", c.Messaging.ConsumerGroupPrefix, "prefix of consumer group names")
+	bind("CONSUMER_GROUP_PREFIX", "consumer-group-prefix")
+	fs.Var(stringList{&c.Messaging.ProtobufEvents}, "protobuf-events", "comma-separated names of events published as protobuf")
+	bind("MESSAGING_PROTOBUF_EVENTS", "protobuf-events")
+	fs.StringVar(&c.Messaging.CloudEventsMode, "cloudevents-mode", c.Messaging.CloudEventsMode, "CloudEvents content mode of published events: binary or structured, empty to disable")
+	bind("MESSAGING_CLOUDEVENTS_MODE", "cloudevents-mode")
+	fs.IntVar(&c.Messaging.Retry.MaxRetries, "retry-max-retries", c.Messaging.Retry.MaxRetries, "max retries of a failed message")
+	bind("RETRY_MAX_RETRIES", "retry-max-retries")
+	fs.DurationVar(&c.Messaging.Retry.InitialInterval, "retry-initial-interval", c.Messaging.Retry.InitialInterval, "initial retry interval")
+	bind("RETRY_INITIAL_INTERVAL", "retry-initial-interval")
+	fs.DurationVar(&c.Messaging.Retry.MaxInterval, "retry-max-interval", c.Messaging.Retry.MaxInterval, "max retry interval")
+	bind("RETRY_MAX_INTERVAL", "retry-max-interval")
+	fs.Float64Var(&c.Messaging.Retry.Multiplier, "retry-multiplier", c.Messaging.Retry.Multiplier, "retry interval multiplier")
+	bind("RETRY_MULTIPLIER", "retry-multiplier")
+	fs.IntVar(&c.Messaging.SpreadsheetsBatch.MaxSize, "spreadsheets-batch-max-size", c.Messaging.SpreadsheetsBatch.MaxSize, "max rows appended to spreadsheets at once")
+	bind("SPREADSHEETS_BATCH_MAX_SIZE", "spreadsheets-batch-max-size")
+	fs.DurationVar(&c.Messaging.SpreadsheetsBatch.MaxWait, "spreadsheets-batch-max-wait", c.Messaging.SpreadsheetsBatch.MaxWait, "max wait for rows to batch")
+	bind("SPREADSHEETS_BATCH_MAX_WAIT", "spreadsheets-batch-max-wait")
+
+	fs.StringVar(&c.Spreadsheets.TicketsToPrint, "sheet-tickets-to-print", c.Spreadsheets.TicketsToPrint, "name of the tickets to print sheet")
+	bind("SHEET_TICKETS_TO_PRINT", "sheet-tickets-to-print")
+	fs.StringVar(&c.Spreadsheets.TicketsToRefund, "sheet-tickets-to-refund", c.Spreadsheets.TicketsToRefund, "name of the tickets to refund sheet")
+	bind("SHEET_TICKETS_TO_REFUND", "sheet-tickets-to-refund")
+	fs.StringVar(&c.Spreadsheets.DailySummary, "sheet-daily-summary", c.Spreadsheets.DailySummary, "name of the daily sales summary sheet")
+	bind("SHEET_DAILY_SUMMARY", "sheet-daily-summary")
+
+	fs.DurationVar(&c.Health.CheckTimeout, "health-check-timeout", c.Health.CheckTimeout, "timeout of a single readiness check")
+	bind("HEALTH_CHECK_TIMEOUT", "health-check-timeout")
+	fs.BoolVar(&c.Health.CheckGateway, "health-check-gateway", c.Health.CheckGateway, "include gateway reachability in readiness")
+	bind("HEALTH_CHECK_GATEWAY", "health-check-gateway")
+
+	fs.DurationVar(&c.Shutdown.HTTPTimeout, "shutdown-http-timeout", c.Shutdown.HTTPTimeout, "time for in-flight HTTP requests on shutdown")
+	bind("SHUTDOWN_HTTP_TIMEOUT", "shutdown-http-timeout")
+	fs.DurationVar(&c.Shutdown.DrainTimeout, "shutdown-drain-timeout", c.Shutdown.DrainTimeout, "time for in-flight messages on shutdown")
+	bind("SHUTDOWN_DRAIN_TIMEOUT", "shutdown-drain-timeout")
+	fs.DurationVar(&c.Shutdown.CloseTimeout, "shutdown-close-timeout", c.Shutdown.CloseTimeout, "time for closing the router after draining")
+	bind("SHUTDOWN_CLOSE_TIMEOUT", "shutdown-close-timeout")
+
+	fs.DurationVar(&c.Webhooks.Timeout, "webhooks-timeout", c.Webhooks.Timeout, "timeout of a webhook delivery request")
+	bind("WEBHOOKS_TIMEOUT", "webhooks-timeout")
+	fs.IntVar(&c.Webhooks.MaxAttempts, "webhooks-max-attempts", c.Webhooks.MaxAttempts, "max attempts of delivering an event to a subscription")
+	bind("WEBHOOKS_MAX_ATTEMPTS", "webhooks-max-attempts")
+	fs.DurationVar(&c.Webhooks.InitialBackoff, "webhooks-initial-backoff", c.Webhooks.InitialBackoff, "backoff after the first failed delivery attempt")
+	bind("WEBHOOKS_INITIAL_BACKOFF", "webhooks-initial-backoff")
+	fs.DurationVar(&c.Webhooks.MaxBackoff, "webhooks-max-backoff", c.Webhooks.MaxBackoff, "max backoff between delivery attempts")
+	bind("WEBHOOKS_MAX_BACKOFF", "webhooks-max-backoff")
+	fs.BoolVar(&c.Webhooks.AllowPrivateAddresses, "webhooks-allow-private-addresses", c.Webhooks.AllowPrivateAddresses, "allow webhook subscriptions to loopback, private and link-local addresses")
+	bind("WEBHOOKS_ALLOW_PRIVATE_ADDRESSES", "webhooks-allow-private-addresses")
+	fs.IntVar(&c.Webhooks.DisableAfterFailures, "webhooks-disable-after-failures", c.Webhooks.DisableAfterFailures, "consecutive failed events after which a subscription is disabled")
+	bind("WEBHOOKS_DISABLE_AFTER_FAILURES", "webhooks-disable-after-failures")
+
+	fs.Var(stringList{&c.InboundWebhooks.Secrets}, "inbound-webhook-secrets", "comma-separated secrets verifying /tickets-status requests")
+	bind("INBOUND_WEBHOOK_SECRETS", "inbound-webhook-secrets")
+	fs.BoolVar(&c.InboundWebhooks.AllowUnsigned, "inbound-webhook-allow-unsigned", c.InboundWebhooks.AllowUnsigned, "accept /tickets-status requests without verifying them when there are no secrets, for local development")
+	bind("INBOUND_WEBHOOK_ALLOW_UNSIGNED", "inbound-webhook-allow-unsigned")
+	fs.DurationVar(&c.InboundWebhooks.Tolerance, "inbound-webhook-tolerance", c.InboundWebhooks.Tolerance, "max difference between a request timestamp and the current time")
+	bind("INBOUND_WEBHOOK_TOLERANCE", "inbound-webhook-tolerance")
+
+	fs.DurationVar(&c.EventsStream.HeartbeatInterval, "events-stream-heartbeat-interval", c.EventsStream.HeartbeatInterval, "interval of heartbeats on the events stream")
+	bind("EVENTS_STREAM_HEARTBEAT_INTERVAL", "events-stream-heartbeat-interval")
+	fs.IntVar(&c.EventsStream.MaxConnections, "events-stream-max-connections", c.EventsStream.MaxConnections, "max events streams served at once with Redis streams")
+	bind("EVENTS_STREAM_MAX_CONNECTIONS", "events-stream-max-connections")
+
+	fs.BoolVar(&c.BookingSaga.Enabled, "booking-saga", c.BookingSaga.Enabled, "process bookings with the booking saga")
+	bind("BOOKING_SAGA", "booking-saga")
+	fs.DurationVar(&c.BookingSaga.Timeout, "booking-saga-timeout", c.BookingSaga.Timeout, "how long a booking may take before it's compensated")
+	bind("BOOKING_SAGA_TIMEOUT", "booking-saga-timeout")
+
+	fs.DurationVar(&c.Scheduler.CheckInterval, "scheduler-check-interval", c.Scheduler.CheckInterval, "interval of releasing due scheduled messages")
+	bind("SCHEDULER_CHECK_INTERVAL", "scheduler-check-interval")
+
+	fs.StringVar(&c.Notifications.Transport, "notifications-transport", c.Notifications.Transport, "how emails are sent to customers: smtp, outbox or empty to disable them")
+	bind("NOTIFICATIONS_TRANSPORT", "notifications-transport")
+	fs.StringVar(&c.Notifications.From, "notifications-from", c.Notifications.From, "sender address of emails")
+	bind("NOTIFICATIONS_FROM", "notifications-from")
+	fs.StringVar(&c.Notifications.OutboxDir, "notifications-outbox-dir", c.Notifications.OutboxDir, "directory emails are written to with the outbox transport")
+	bind("NOTIFICATIONS_OUTBOX_DIR", "notifications-outbox-dir")
+	fs.StringVar(&c.Notifications.SMTP.Addr, "smtp-addr", c.Notifications.SMTP.Addr, "SMTP server host:port")
+	bind("SMTP_ADDR", "smtp-addr")
+	fs.StringVar(&c.Notifications.SMTP.Username, "smtp-username", c.Notifications.SMTP.Username, "SMTP username, empty for no authentication")
+	bind("SMTP_USERNAME", "smtp-username")
+	fs.StringVar(&c.Notifications.SMTP.Password, "smtp-password", c.Notifications.SMTP.Password, "SMTP password")
+	bind("SMTP_PASSWORD", "smtp-password")
+
+	fs.DurationVar(&c.Reconciliation.Interval, "reconciliation-interval", c.Reconciliation.Interval, "interval of reconciling tickets with spreadsheets, 0 disables it")
+	bind("RECONCILIATION_INTERVAL", "reconciliation-interval")
+	fs.BoolVar(&c.Reconciliation.Republish, "reconciliation-republish", c.Reconciliation.Republish, "republish events of tickets missing in spreadsheets")
+	bind("RECONCILIATION_REPUBLISH", "reconciliation-republish")
+
+	fs.BoolVar(&c.DailySummary.Enabled, "daily-summary-enabled", c.DailySummary.Enabled, "summarize sales of the previous day, once a day")
+	bind("DAILY_SUMMARY_ENABLED", "daily-summary-enabled")
+	fs.StringVar(&c.DailySummary.RunAt, "daily-summary-run-at", c.DailySummary.RunAt, "time of day, HH:MM, after which the previous day is summarized")
+	bind("DAILY_SUMMARY_RUN_AT", "daily-summary-run-at")
+	fs.StringVar(&c.DailySummary.TimeZone, "daily-summary-time-zone", c.DailySummary.TimeZone, "IANA time zone of summarized days")
+	bind("DAILY_SUMMARY_TIME_ZONE", "daily-summary-time-zone")
+
+	fs.DurationVar(&c.Retention.Interval, "retention-interval", c.Retention.Interval, "interval of trimming streams, 0 disables it")
+	bind("RETENTION_INTERVAL", "retention-interval")
+	fs.Int64Var(&c.Retention.Default.MaxLen, "retention-max-len", c.Retention.Default.MaxLen, "default max length of a stream, 0 for no limit")
+	bind("RETENTION_MAX_LEN", "retention-max-len")
+	fs.DurationVar(&c.Retention.Default.MaxAge, "retention-max-age", c.Retention.Default.MaxAge, "default max age of stream entries, 0 for no limit")
+	bind("RETENTION_MAX_AGE", "retention-max-age")
+
+	fs.Var(tenantList{&c.Tenants}, "tenants", "comma-separated IDs of accepted tenants besides the default one, configured in the file")
+	bind("TENANTS", "tenants")
+
+	fs.StringVar(&c.Log.Level, "log-level", c.Log.Level, "log level")
+	bind("LOG_LEVEL", "log-level")
+
+	return fs, bindings
+}
+
+// stringList is a comma-separated flag value. Setting it replaces the list, so flags override env and file.
+type stringList struct {
+	values *[]string
+}
+
+func (l stringList) String() string {
+	if l.values == nil {
+		return ""
+	}
+
+	return strings.Join(*l.values, ",")
+}
+
+func (l stringList) Set(s string) error {
+	var values []string
+	for _, value := range strings.Split(s, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	*l.values = values
+
+	return nil
+}
+
+// tenantList is a comma-separated flag value of tenant IDs. Like stringList, it replaces the tenants,
+// keeping the settings of listed tenants from the file.
+type tenantList struct {
+	tenants *Tenants
+}
+
+func (l tenantList) String() string {
+	if l.tenants == nil {
+		return ""
+	}
+
+	ids := make([]string, 0, len(*l.tenants))
+	for id := range *l.tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return strings.Join(ids, ",")
+}
+
+func (l tenantList) Set(s string) error {
+	var ids []string
+	if err := (stringList{&ids}).Set(s); err != nil {
+		return err
+	}
+
+	tenants := Tenants{}
+	for _, id := range ids {
+		tenants[id] = (*l.tenants)[id]
+	}
+	*l.tenants = tenants
+
+	return nil
+}
+
+// tenantKeyList is a comma-separated flag value of tenant:key pairs. Like stringList, it replaces the keys.
+type tenantKeyList struct {
+	keys *map[string][]string
+}
+
+func (l tenantKeyList) String() string {
+	if l.keys == nil {
+		return ""
+	}
+
+	var pairs []string
+	for id, keys := range *l.keys {
+		for _, key := range keys {
+			pairs = append(pairs, id+":"+key)
+		}
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+func (l tenantKeyList) Set(s string) error {
+	var pairs []string
+	if err := (stringList{&pairs}).Set(s); err != nil {
+		return err
+	}
+
+	keys := map[string][]string{}
+	for _, pair := range pairs {
+		id, key, ok := strings.Cut(pair, ":")
+		if !ok || id == "" || key == "" {
+			return fmt.Errorf("expected tenant:key, got %q", pair)
+		}
+		keys[id] = append(keys[id], key)
+	}
+	*l.keys = keys
+
+	return nil
+}
+
+func configFilePath(args []string) string {
+	var scratch Config
+	fs, _ := scratch.flagSet()
+	fs.SetOutput(io.Discard)
+
+	// parse errors are reported by the second, real parse
+	_ = fs.Parse(args)
+
+	if path := fs.Lookup("config").Value.String(); path != "" {
+		return path
+	}
+
+	return os.Getenv("CONFIG_FILE")
+}
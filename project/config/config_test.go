@@ -0,0 +1,126 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"tickets/config"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_precedence(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configFile, []byte(`
+http:
+  port: 9090
+gateway:
+  addr: http://gateway-from-file
+redis:
+  addr: redis-from-file:6379
+postgres:
+  url: postgres://file
+messaging:
+  retry:
+    initial_interval: 50ms
+inbound_webhooks:
+  secrets: [secret-from-file-0123456789]
+log:
+  level: debug
+`), 0o600)
+	require.NoError(t, err)
+
+	t.Setenv("CONFIG_FILE", configFile)
+	t.Setenv("REDIS_ADDR", "redis-from-env:6379")
+	t.Setenv("LOG_LEVEL", "warn")
+
+	cfg, err := config.Load([]string{"-log-level", "error"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 9090, cfg.HTTP.Port)
+	assert.Equal(t, "http://gateway-from-file", cfg.Gateway.Addr)
+	assert.Equal(t, "redis-from-env:6379", cfg.Redis.Addr)
+	assert.Equal(t, "error", cfg.Log.Level)
+	assert.Equal(t, 50*time.Millisecond, cfg.Messaging.Retry.InitialInterval)
+	assert.Equal(t, "svc-tickets.", cfg.Messaging.ConsumerGroupPrefix)
+	assert.Equal(t, "tickets-to-print", cfg.Spreadsheets.TicketsToPrint)
+}
+
+func TestLoad_reports_all_errors(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("GATEWAY_ADDR", "")
+	t.Setenv("REDIS_ADDR", "")
+	t.Setenv("POSTGRES_URL", "")
+	t.Setenv("REDIS_DB", "first")
+
+	_, err := config.Load([]string{"-http-port", "-1", "-log-level", "loud", "-inbound-webhook-secrets", "0123456789abcdef,short", "-admin-api-keys", "short", "-tenant-api-keys", "reseller-x:short"})
+	require.Error(t, err)
+
+	for _, expected := range []string{
+		"http.port",
+		"gateway.addr",
+		"redis.addr",
+		"postgres.url",
+		"log.level",
+		"inbound_webhooks.secrets[1]",
+		"REDIS_DB",
+		"auth.admin_keys[0]",
+		`auth.tenant_keys: unknown tenant "reseller-x"`,
+		"auth.tenant_keys.reseller-x[0]",
+	} {
+		assert.Contains(t, err.Error(), expected)
+	}
+}
+
+func TestLoad_requires_inbound_webhook_secrets(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("INBOUND_WEBHOOK_SECRETS", "")
+
+	_, err := config.Load([]string{"-gateway-addr", "http://gateway", "-redis-addr", "redis:6379", "-postgres-url", "postgres://db"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "inbound_webhooks.secrets are required")
+
+	_, err = config.Load([]string{"-gateway-addr", "http://gateway", "-redis-addr", "redis:6379", "-postgres-url", "postgres://db", "-inbound-webhook-allow-unsigned"})
+	assert.NoError(t, err)
+}
+
+func TestLoad_tenants(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configFile, []byte(`
+gateway:
+  addr: http://gateway
+redis:
+  addr: redis:6379
+postgres:
+  url: postgres://db
+inbound_webhooks:
+  allow_unsigned: true
+tenants:
+  reseller-a:
+    dedicated_topics: true
+  reseller-b:
+    spreadsheets:
+      tickets_to_print: printing-b
+`), 0o600)
+	require.NoError(t, err)
+
+	t.Setenv("CONFIG_FILE", configFile)
+
+	// the flag selects accepted tenants, keeping their settings from the file
+	cfg, err := config.Load([]string{"-tenants", "reseller-b,reseller-c"})
+	require.NoError(t, err)
+
+	assert.False(t, cfg.Tenants.Known("reseller-a"))
+	assert.True(t, cfg.Tenants.Known("reseller-b"))
+	assert.True(t, cfg.Tenants.Known("reseller-c"))
+	assert.True(t, cfg.Tenants.Known("default"))
+
+	assert.Equal(t, config.Spreadsheets{TicketsToPrint: "printing-b", TicketsToRefund: "reseller-b-tickets-to-refund", DailySummary: "reseller-b-daily-summary"}, cfg.Tenants.Spreadsheets("reseller-b", cfg.Spreadsheets))
+	assert.Equal(t, cfg.Spreadsheets, cfg.Tenants.Spreadsheets("default", cfg.Spreadsheets))
+	assert.Empty(t, cfg.Tenants.WithDedicatedTopics())
+
+	_, err = config.Load([]string{"-tenants", "Reseller_A"})
+	assert.ErrorContains(t, err, `invalid tenant ID "Reseller_A"`)
+}
@@ -0,0 +1,9 @@
+// Package db holds the PostgreSQL schema of the service.
+package db
+
+import _ "embed"
+
+// Schema creates all tables, it's safe to run on every start.
+//
+//go:embed schema.sql
+var Schema string
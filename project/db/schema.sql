@@ -8,3 +8,140 @@ CREATE TABLE IF NOT EXISTS tickets (
 	customer_email
 		VARCHAR(255) NOT NULL
 );
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+	id
+		UUID PRIMARY KEY,
+	url
+		TEXT NOT NULL,
+	event_types
+		TEXT[] NOT NULL,
+	secret
+		TEXT NOT NULL,
+	disabled
+		BOOLEAN NOT NULL DEFAULT FALSE,
+	disabled_reason
+		TEXT NOT NULL DEFAULT '',
+	consecutive_failures
+		INT NOT NULL DEFAULT 0,
+	created_at
+		TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id
+		UUID PRIMARY KEY,
+	subscription_id
+		UUID NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+	event_id
+		TEXT NOT NULL,
+	event_type
+		TEXT NOT NULL,
+	attempt
+		INT NOT NULL,
+	status_code
+		INT NOT NULL,
+	error
+		TEXT NOT NULL,
+	succeeded
+		BOOLEAN NOT NULL,
+	duration_ms
+		BIGINT NOT NULL,
+	attempted_at
+		TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_subscription_id_attempted_at
+	ON webhook_deliveries (subscription_id, attempted_at DESC);
+
+-- tables created before tenants were introduced hold rows of the default tenant
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS webhook_subscriptions_tenant_id
+	ON webhook_subscriptions (tenant_id, created_at);
+
+-- tickets reserved by the booking saga, tickets confirmed with /tickets-status don't have a booking
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS booking_id UUID;
+
+CREATE INDEX IF NOT EXISTS tickets_booking_id
+	ON tickets (booking_id);
+
+CREATE TABLE IF NOT EXISTS booking_sagas (
+	booking_id
+		UUID PRIMARY KEY,
+	tenant_id
+		TEXT NOT NULL,
+	status
+		TEXT NOT NULL,
+	booking
+		JSONB NOT NULL,
+	ticket_ids
+		TEXT[] NOT NULL,
+	payment_reference
+		TEXT NOT NULL DEFAULT '',
+	failure_reason
+		TEXT NOT NULL DEFAULT '',
+	deadline
+		TIMESTAMPTZ NOT NULL,
+	created_at
+		TIMESTAMPTZ NOT NULL,
+	updated_at
+		TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS booking_sagas_in_progress_deadline
+	ON booking_sagas (deadline)
+	WHERE status NOT IN ('confirmed', 'failed');
+
+CREATE TABLE IF NOT EXISTS scheduled_messages (
+	key
+		TEXT PRIMARY KEY,
+	topic
+		TEXT NOT NULL,
+	uuid
+		TEXT NOT NULL,
+	metadata
+		JSONB NOT NULL,
+	payload
+		BYTEA NOT NULL,
+	deliver_at
+		TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS scheduled_messages_deliver_at
+	ON scheduled_messages (deliver_at);
+
+-- tickets confirmed with /tickets-status have IDs and prices in any format
+ALTER TABLE tickets
+	ALTER COLUMN ticket_id TYPE TEXT,
+	ALTER COLUMN booking_id TYPE TEXT,
+	ALTER COLUMN price_amount TYPE TEXT,
+	ALTER COLUMN price_currency TYPE TEXT;
+
+-- the first events which confirmed and canceled tickets, compared with the tracker spreadsheets,
+-- and when reconciliation last republished them
+ALTER TABLE tickets
+	ADD COLUMN IF NOT EXISTS confirmed_event_id TEXT,
+	ADD COLUMN IF NOT EXISTS confirmed_at TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS confirmed_republished_at TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS canceled_event_id TEXT,
+	ADD COLUMN IF NOT EXISTS canceled_at TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS canceled_republished_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS tickets_confirmed_at
+	ON tickets (confirmed_at);
+
+CREATE INDEX IF NOT EXISTS tickets_canceled_at
+	ON tickets (canceled_at);
+
+-- locks shared by replicas, see the locks package
+CREATE TABLE IF NOT EXISTS locks (
+	key
+		TEXT PRIMARY KEY,
+	token
+		TEXT NOT NULL,
+	expires_at
+		TIMESTAMPTZ NOT NULL
+);
@@ -1,7 +1,75 @@
 package entities
 
+import "encoding/json"
+
 type RefundTicket struct {
 	Header EventHeader `json:"header"`
 
 	TicketID string `json:"ticket_id"`
 }
+
+// Commands of the booking saga. Each step is idempotent, as commands are redelivered until handled.
+
+type ReserveTickets struct {
+	Header EventHeader `json:"header"`
+
+	BookingID     string   `json:"booking_id"`
+	ShowID        string   `json:"show_id"`
+	CustomerEmail string   `json:"customer_email"`
+	TicketIDs     []string `json:"ticket_ids"`
+	TicketPrice   Money    `json:"ticket_price"`
+}
+
+type CapturePayment struct {
+	Header EventHeader `json:"header"`
+
+	BookingID     string `json:"booking_id"`
+	CustomerEmail string `json:"customer_email"`
+	Amount        Money  `json:"amount"`
+}
+
+type IssueReceipts struct {
+	Header EventHeader `json:"header"`
+
+	BookingID   string   `json:"booking_id"`
+	TicketIDs   []string `json:"ticket_ids"`
+	TicketPrice Money    `json:"ticket_price"`
+}
+
+// ReleaseTickets compensates ReserveTickets.
+type ReleaseTickets struct {
+	Header EventHeader `json:"header"`
+
+	BookingID string `json:"booking_id"`
+}
+
+// RefundPayment compensates CapturePayment.
+type RefundPayment struct {
+	Header EventHeader `json:"header"`
+
+	BookingID        string `json:"booking_id"`
+	PaymentReference string `json:"payment_reference"`
+	Reason           string `json:"reason"`
+}
+
+// VoidReceipts compensates IssueReceipts.
+type VoidReceipts struct {
+	Header EventHeader `json:"header"`
+
+	BookingID string   `json:"booking_id"`
+	TicketIDs []string `json:"ticket_ids"`
+	Reason    string   `json:"reason"`
+}
+
+// DeliverWebhook is a single attempt of delivering an event to a webhook subscription.
+// Failed attempts are retried by sending it again with the next Attempt, delayed by the backoff.
+type DeliverWebhook struct {
+	Header EventHeader `json:"header"`
+
+	SubscriptionID string `json:"subscription_id"`
+	EventID        string `json:"event_id"`
+	EventType      string `json:"event_type"`
+	// Payload is the body of delivery requests, the same in all attempts.
+	Payload json.RawMessage `json:"payload"`
+	Attempt int             `json:"attempt"`
+}
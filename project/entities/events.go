@@ -9,12 +9,15 @@ import (
 type EventHeader struct {
 	ID          string    `json:"id"`
 	PublishedAt time.Time `json:"published_at"`
+	// TenantID is empty in events published before tenants were introduced, they belong to the default tenant.
+	TenantID string `json:"tenant_id,omitempty"`
 }
 
-func NewEventHeader() EventHeader {
+func NewEventHeader(tenantID string) EventHeader {
 	return EventHeader{
 		ID:          uuid.NewString(),
 		PublishedAt: time.Now().UTC(),
+		TenantID:    tenantID,
 	}
 }
 
@@ -51,4 +54,68 @@ type BookingMade struct {
 
 	CustomerEmail string    `json:"customer_email"`
 	ShowId        uuid.UUID `json:"show_id"`
+
+	// TicketPrice is the price of each ticket, empty in bookings made before the booking saga.
+	TicketPrice Money `json:"ticket_price"`
+}
+
+// Events of the booking saga, reporting results of its steps.
+
+type TicketsReserved struct {
+	Header EventHeader `json:"header"`
+
+	BookingID string `json:"booking_id"`
+}
+
+type PaymentCaptured struct {
+	Header EventHeader `json:"header"`
+
+	BookingID        string `json:"booking_id"`
+	PaymentReference string `json:"payment_reference"`
+}
+
+type ReceiptsIssued struct {
+	Header EventHeader `json:"header"`
+
+	BookingID string `json:"booking_id"`
+}
+
+// BookingStepFailed is published when a step of the booking saga fails in a way retrying won't fix.
+type BookingStepFailed struct {
+	Header EventHeader `json:"header"`
+
+	BookingID string `json:"booking_id"`
+	Step      string `json:"step"`
+	Reason    string `json:"reason"`
+}
+
+// BookingFailed is published when the booking saga gives up, after compensating commands are sent.
+type BookingFailed struct {
+	Header EventHeader `json:"header"`
+
+	BookingID string `json:"booking_id"`
+	Reason    string `json:"reason"`
+}
+
+// DailySalesSummarized is published once a day per tenant, with tickets confirmed and canceled the previous day.
+type DailySalesSummarized struct {
+	Header EventHeader `json:"header"`
+
+	// Date is the summarized day, YYYY-MM-DD in the time zone of the summary.
+	Date string `json:"date"`
+
+	ConfirmedTickets int `json:"confirmed_tickets"`
+	CanceledTickets  int `json:"canceled_tickets"`
+
+	// Currencies break the summary down by currency, sorted by currency code.
+	Currencies []CurrencySales `json:"currencies"`
+}
+
+type CurrencySales struct {
+	Currency         string `json:"currency"`
+	ConfirmedTickets int    `json:"confirmed_tickets"`
+	CanceledTickets  int    `json:"canceled_tickets"`
+	// Revenue is the price of confirmed tickets, Refunds the price of canceled ones.
+	Revenue string `json:"revenue"`
+	Refunds string `json:"refunds"`
 }
@@ -1,7 +1,23 @@
 package entities
 
-type PaymentRefund struct {
-	TicketID       string
-	RefundReason   string
+import "errors"
+
+// ErrPaymentDeclined is returned when the payment can't be captured, retrying won't help.
+var ErrPaymentDeclined = errors.New("payment declined")
+
+// ErrCaptureNotSupported is returned when the payments service can't capture payments at all, retrying won't help.
+var ErrCaptureNotSupported = errors.New("payment capture is not supported")
+
+type PaymentCapture struct {
+	BookingID      string
+	CustomerEmail  string
+	Amount         Money
 	IdempotencyKey string
 }
+
+type PaymentRefund struct {
+	// PaymentReference is the reference of a captured payment, or the ID of a ticket paid for on its own.
+	PaymentReference string
+	RefundReason     string
+	IdempotencyKey   string
+}
@@ -0,0 +1,17 @@
+package entities
+
+import "fmt"
+
+// AppendRowsError is returned when only the first Appended rows were appended, the others weren't.
+type AppendRowsError struct {
+	Appended int
+	Err      error
+}
+
+func (e *AppendRowsError) Error() string {
+	return fmt.Sprintf("appended %d rows: %s", e.Appended, e.Err)
+}
+
+func (e *AppendRowsError) Unwrap() error {
+	return e.Err
+}
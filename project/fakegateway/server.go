@@ -0,0 +1,266 @@
+// Package fakegateway is an in-memory stand-in for the gateway's spreadsheets, receipts and payments APIs.
+package fakegateway
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients/payments"
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients/receipts"
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients/spreadsheets"
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/labstack/echo/v4"
+)
+
+type Server struct {
+	lock sync.Mutex
+
+	sheets   map[string][]spreadsheets.SpreadsheetRow
+	receipts []receipts.Receipt
+	refunds  []payments.PaymentRefundRequest
+	requests []Request
+}
+
+// Request is a call made to the gateway, kept for inspection.
+type Request struct {
+	Method        string    `json:"method"`
+	Path          string    `json:"path"`
+	CorrelationID string    `json:"correlation_id"`
+	Status        int       `json:"status"`
+	At            time.Time `json:"at"`
+}
+
+type State struct {
+	Sheets   map[string][]spreadsheets.SpreadsheetRow `json:"sheets"`
+	Receipts []receipts.Receipt                       `json:"receipts"`
+	Refunds  []payments.PaymentRefundRequest          `json:"refunds"`
+	Requests []Request                                `json:"requests"`
+}
+
+func NewServer() *Server {
+	s := &Server{}
+	s.reset()
+
+	return s
+}
+
+// Handler serves the APIs under the same paths as the gateway, for example /receipts-api/receipts,
+// and the inspection endpoints under /inspect.
+func (s *Server) Handler() http.Handler {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+
+	e.Use(s.recordRequest)
+
+	e.GET("/spreadsheets-api/sheets/:sheet/rows", s.getSheetRows)
+	e.POST("/spreadsheets-api/sheets/:sheet/rows", s.postSheetRow)
+
+	e.GET("/receipts-api/receipts", s.getReceipts)
+	e.PUT("/receipts-api/receipts", s.putReceipt)
+	e.PUT("/receipts-api/void-receipt", s.putVoidReceipt)
+
+	e.GET("/payments-api/refunds", s.getRefunds)
+	e.PUT("/payments-api/refunds", s.putRefund)
+
+	e.GET("/inspect/state", s.getState)
+	e.POST("/inspect/reset", s.postReset)
+
+	return e
+}
+
+// State returns a copy of everything the gateway has received.
+func (s *Server) State() State {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	state := State{
+		Sheets:   make(map[string][]spreadsheets.SpreadsheetRow, len(s.sheets)),
+		Receipts: append([]receipts.Receipt{}, s.receipts...),
+		Refunds:  append([]payments.PaymentRefundRequest{}, s.refunds...),
+		Requests: append([]Request{}, s.requests...),
+	}
+	for name, rows := range s.sheets {
+		state.Sheets[name] = append([]spreadsheets.SpreadsheetRow{}, rows...)
+	}
+
+	return state
+}
+
+func (s *Server) reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.sheets = make(map[string][]spreadsheets.SpreadsheetRow)
+	s.receipts = nil
+	s.refunds = nil
+	s.requests = nil
+}
+
+func (s *Server) recordRequest(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		err := next(c)
+
+		status := c.Response().Status
+		if httpErr, ok := err.(*echo.HTTPError); ok {
+			status = httpErr.Code
+		}
+
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		s.requests = append(s.requests, Request{
+			Method:        c.Request().Method,
+			Path:          c.Request().URL.Path,
+			CorrelationID: c.Request().Header.Get(log.CorrelationIDHttpHeader),
+			Status:        status,
+			At:            time.Now().UTC(),
+		})
+
+		return err
+	}
+}
+
+func (s *Server) getSheetRows(c echo.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	rows := append([]spreadsheets.SpreadsheetRow{}, s.sheets[c.Param("sheet")]...)
+
+	return c.JSON(http.StatusOK, spreadsheets.SpreadsheetRows{Rows: rows})
+}
+
+func (s *Server) postSheetRow(c echo.Context) error {
+	var body spreadsheets.PostSheetsSheetRowsJSONRequestBody
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+	if len(body.Columns) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "columns are required")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	sheet := c.Param("sheet")
+	s.sheets[sheet] = append(s.sheets[sheet], body.Columns)
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (s *Server) getReceipts(c echo.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return c.JSON(http.StatusOK, append([]receipts.Receipt{}, s.receipts...))
+}
+
+// putReceipt is idempotent: an existing receipt for the ticket (or idempotency key) is returned with 200,
+// a new one with 201.
+func (s *Server) putReceipt(c echo.Context) error {
+	var body receipts.PutReceiptsJSONRequestBody
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+	if body.TicketId == "" {
+		return c.JSON(http.StatusBadRequest, receipts.ErrorResponse{Error: "ticket_id is required"})
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, receipt := range s.receipts {
+		if sameReceipt(receipt, body) {
+			return c.JSON(http.StatusOK, receipt)
+		}
+	}
+
+	receipt := receipts.Receipt{
+		IdempotencyKey: body.IdempotencyKey,
+		IssuedAt:       time.Now().UTC(),
+		Number:         fmt.Sprintf("RCPT-%06d", len(s.receipts)+1),
+		Price:          body.Price,
+		TicketId:       body.TicketId,
+	}
+	s.receipts = append(s.receipts, receipt)
+
+	return c.JSON(http.StatusCreated, receipt)
+}
+
+func sameReceipt(receipt receipts.Receipt, request receipts.CreateReceipt) bool {
+	if request.IdempotencyKey != nil {
+		return receipt.IdempotencyKey != nil && *receipt.IdempotencyKey == *request.IdempotencyKey
+	}
+
+	return receipt.TicketId == request.TicketId
+}
+
+func (s *Server) putVoidReceipt(c echo.Context) error {
+	var body receipts.PutVoidReceiptJSONRequestBody
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for i := range s.receipts {
+		if s.receipts[i].TicketId != body.TicketId {
+			continue
+		}
+
+		voided := true
+		reason := body.Reason
+		s.receipts[i].Voided = &voided
+		s.receipts[i].VoidReason = &reason
+
+		return c.NoContent(http.StatusOK)
+	}
+
+	return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("receipt for ticket %s not found", body.TicketId))
+}
+
+func (s *Server) getRefunds(c echo.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return c.JSON(http.StatusOK, append([]payments.PaymentRefundRequest{}, s.refunds...))
+}
+
+// putRefund deduplicates refunds by deduplication ID.
+func (s *Server) putRefund(c echo.Context) error {
+	var body payments.PutRefundsJSONRequestBody
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+	if body.PaymentReference == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "payment_reference is required")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if body.DeduplicationId != nil {
+		for _, refund := range s.refunds {
+			if refund.DeduplicationId != nil && *refund.DeduplicationId == *body.DeduplicationId {
+				return c.NoContent(http.StatusOK)
+			}
+		}
+	}
+
+	s.refunds = append(s.refunds, body)
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (s *Server) getState(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.State())
+}
+
+func (s *Server) postReset(c echo.Context) error {
+	s.reset()
+
+	return c.NoContent(http.StatusNoContent)
+}
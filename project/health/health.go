@@ -0,0 +1,184 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Run executes all checks concurrently, each with its own timeout.
+func Run(ctx context.Context, timeout time.Duration, checks []Check) Report {
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Check(ctx)
+			result := CheckResult{
+				Status:    StatusOK,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			report.Checks[check.Name] = result
+			if err != nil {
+				report.Status = StatusFail
+			}
+		}(check)
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+func RedisCheck(client *redis.Client) Check {
+	return Check{
+		Name: "redis",
+		Check: func(ctx context.Context) error {
+			return client.Ping(ctx).Err()
+		},
+	}
+}
+
+func PostgresCheck(db *sqlx.DB) Check {
+	return Check{
+		Name: "postgres",
+		Check: func(ctx context.Context) error {
+			return db.PingContext(ctx)
+		},
+	}
+}
+
+// RouterCheck verifies that the router is running and none of its handlers has stopped.
+func RouterCheck(router *message.Router, handlers *RunningHandlers) Check {
+	return Check{
+		Name: "router",
+		Check: func(ctx context.Context) error {
+			if router.IsClosed() {
+				return errors.New("router is closed")
+			}
+			if !router.IsRunning() {
+				return errors.New("router is not running")
+			}
+
+			if started, stopped := handlers.started.Load(), handlers.stopped.Load(); stopped > 0 {
+				return fmt.Errorf("%d of %d handlers are running", started-stopped, started)
+			}
+
+			return nil
+		},
+	}
+}
+
+// RunningHandlers counts handlers of a router by their subscriptions: the router stops a handler
+// when its subscription is closed.
+type RunningHandlers struct {
+	started atomic.Int64
+	stopped atomic.Int64
+}
+
+// SubscriberDecorator must be added to the router before it runs.
+func (h *RunningHandlers) SubscriberDecorator() message.SubscriberDecorator {
+	return func(sub message.Subscriber) (message.Subscriber, error) {
+		return trackedSubscriber{Subscriber: sub, handlers: h}, nil
+	}
+}
+
+type trackedSubscriber struct {
+	message.Subscriber
+	handlers *RunningHandlers
+}
+
+func (s trackedSubscriber) Subscribe(ctx context.Context, topic string) (<-chan *message.Message, error) {
+	messages, err := s.Subscriber.Subscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	s.handlers.started.Add(1)
+
+	// handlers read their messages until the channel is closed
+	tracked := make(chan *message.Message)
+	go func() {
+		defer s.handlers.stopped.Add(1)
+		defer close(tracked)
+
+		for msg := range messages {
+			tracked <- msg
+		}
+	}()
+
+	return tracked, nil
+}
+
+// GatewayCheck considers the gateway reachable if it responds with any status.
+func GatewayCheck(gatewayAddr string) Check {
+	return Check{
+		Name: "gateway",
+		Check: func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, gatewayAddr, nil)
+			if err != nil {
+				return err
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return err
+			}
+
+			return resp.Body.Close()
+		},
+	}
+}
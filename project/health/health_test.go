@@ -0,0 +1,49 @@
+package health_test
+
+import (
+	"context"
+	"testing"
+	"tickets/health"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouterCheck(t *testing.T) {
+	logger := watermill.NopLogger{}
+	pubSub := gochannel.NewGoChannel(gochannel.Config{}, logger)
+
+	router, err := message.NewRouter(message.RouterConfig{}, logger)
+	require.NoError(t, err)
+
+	handlers := &health.RunningHandlers{}
+	router.AddSubscriberDecorators(handlers.SubscriberDecorator())
+
+	noop := func(msg *message.Message) error { return nil }
+	stopping := router.AddNoPublisherHandler("stopping", "topic-1", pubSub, noop)
+	router.AddNoPublisherHandler("running", "topic-2", pubSub, noop)
+
+	check := health.RouterCheck(router, handlers)
+	ctx := context.Background()
+
+	go func() {
+		_ = router.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		_ = router.Close()
+	})
+	<-router.Running()
+
+	require.NoError(t, check.Check(ctx))
+
+	stopping.Stop()
+	<-stopping.Stopped()
+
+	require.EventuallyWithT(t, func(t *assert.CollectT) {
+		assert.EqualError(t, check.Check(ctx), "1 of 2 handlers are running")
+	}, time.Second, 10*time.Millisecond)
+}
@@ -2,6 +2,15 @@ package http
 
 import (
 	"context"
+	"net/http"
+	"tickets/config"
+	"tickets/health"
+	"tickets/message/feed"
+	"tickets/message/scheduler"
+	"tickets/message/streams"
+	"tickets/reconciliation"
+	"tickets/webhooks"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 )
@@ -9,6 +18,58 @@ import (
 type Handler struct {
 	eventBus              *cqrs.EventBus
 	spreadsheetsAPIClient SpreadsheetsAPI
+
+	readinessChecks  []health.Check
+	readinessTimeout time.Duration
+
+	streamsInspector  StreamsInspector
+	scheduledMessages ScheduledMessages
+	reconciliation    Reconciliation
+
+	webhooksRepository WebhooksRepository
+	webhookURLs        WebhookURLChecker
+
+	tenants config.Tenants
+
+	eventsFeed            EventsFeed
+	eventsStreamHeartbeat time.Duration
+	// shuttingDown is closed when the server shuts down, so long-lived streams don't hold it up
+	shuttingDown <-chan struct{}
+}
+
+type EventsFeed interface {
+	Subscribe(ctx context.Context, topics []string, afterID string) (<-chan feed.Event, error)
+}
+
+type RequestVerifier interface {
+	Verify(ctx context.Context, header http.Header, body []byte) error
+}
+
+type WebhookURLChecker interface {
+	CheckURL(ctx context.Context, rawURL string) error
+}
+
+type StreamsInspector interface {
+	Inspect(ctx context.Context) ([]streams.StreamReport, error)
+}
+
+type ScheduledMessages interface {
+	List(ctx context.Context, limit int) ([]scheduler.Message, error)
+	Cancel(ctx context.Context, key string) error
+}
+
+type Reconciliation interface {
+	Reconcile(ctx context.Context) (reconciliation.Report, error)
+	LastReport() (reconciliation.Report, bool)
+}
+
+type WebhooksRepository interface {
+	AddSubscription(ctx context.Context, subscription webhooks.Subscription) error
+	GetSubscription(ctx context.Context, id string) (webhooks.Subscription, error)
+	ListSubscriptions(ctx context.Context, tenantID string) ([]webhooks.Subscription, error)
+	UpdateSubscription(ctx context.Context, id string, update func(subscription *webhooks.Subscription) error) (webhooks.Subscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]webhooks.Delivery, error)
 }
 
 type SpreadsheetsAPI interface {
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"tickets/message/scheduler"
+	"tickets/reconciliation"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultScheduledMessagesLimit = 50
+	maxScheduledMessagesLimit     = 500
+)
+
+func (h Handler) GetAdminStreams(c echo.Context) error {
+	reports, err := h.streamsInspector.Inspect(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	response := make([]StreamReport, 0, len(reports))
+	for _, report := range reports {
+		groups := make([]StreamGroupReport, 0, len(report.Groups))
+		for _, group := range report.Groups {
+			groups = append(groups, StreamGroupReport{
+				Name:                group.Name,
+				LastDeliveredID:     group.LastDeliveredID,
+				Lag:                 group.Lag,
+				Pending:             group.Pending,
+				OldestPendingAgeMs:  group.OldestPendingAgeMs,
+				OldestPendingIdleMs: group.OldestPendingIdleMs,
+				ConsumersPending:    group.ConsumersPending,
+			})
+		}
+
+		response = append(response, StreamReport{
+			Topic:           report.Topic,
+			Length:          report.Length,
+			LastGeneratedID: report.LastGeneratedID,
+			Groups:          groups,
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h Handler) GetAdminScheduledMessages(c echo.Context) error {
+	limit := defaultScheduledMessagesLimit
+	if value := c.QueryParam("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxScheduledMessagesLimit {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxScheduledMessagesLimit))
+		}
+	}
+
+	messages, err := h.scheduledMessages.List(c.Request().Context(), limit)
+	if err != nil {
+		return err
+	}
+
+	response := make([]ScheduledMessage, 0, len(messages))
+	for _, msg := range messages {
+		metadata := msg.Metadata
+		if metadata == nil {
+			metadata = map[string]string{}
+		}
+
+		response = append(response, ScheduledMessage{
+			Key:         msg.Key,
+			Topic:       msg.Topic,
+			MessageUUID: msg.UUID,
+			Metadata:    metadata,
+			DeliverAt:   msg.DeliverAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h Handler) DeleteAdminScheduledMessage(c echo.Context) error {
+	// keys are free-form, so they may be escaped in the path
+	key, err := url.PathUnescape(c.Param("key"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid key")
+	}
+
+	err = h.scheduledMessages.Cancel(c.Request().Context(), key)
+	if errors.Is(err, scheduler.ErrMessageNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h Handler) GetAdminReconciliation(c echo.Context) error {
+	report, ok := h.reconciliation.LastReport()
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "tickets were not reconciled yet")
+	}
+
+	return c.JSON(http.StatusOK, reconciliationReportResponse(report))
+}
+
+func (h Handler) PostAdminReconciliation(c echo.Context) error {
+	report, err := h.reconciliation.Reconcile(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, reconciliationReportResponse(report))
+}
+
+func reconciliationReportResponse(report reconciliation.Report) ReconciliationReport {
+	sheets := make([]ReconciliationSheetReport, 0, len(report.Sheets))
+	for _, sheet := range report.Sheets {
+		duplicates := make([]ReconciliationDuplicate, 0, len(sheet.Duplicates))
+		for _, duplicate := range sheet.Duplicates {
+			duplicates = append(duplicates, ReconciliationDuplicate{
+				TicketID: duplicate.TicketID,
+				Rows:     duplicate.Rows,
+			})
+		}
+
+		missing := sheet.Missing
+		if missing == nil {
+			missing = []string{}
+		}
+
+		sheets = append(sheets, ReconciliationSheetReport{
+			TenantID:    sheet.TenantID,
+			Sheet:       sheet.Sheet,
+			Tickets:     sheet.Tickets,
+			Rows:        sheet.Rows,
+			Missing:     missing,
+			Duplicates:  duplicates,
+			Republished: sheet.Republished,
+		})
+	}
+
+	return ReconciliationReport{
+		StartedAt:  report.StartedAt,
+		FinishedAt: report.FinishedAt,
+		Sheets:     sheets,
+	}
+}
@@ -0,0 +1,215 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"tickets/entities"
+	"tickets/message/event"
+	"tickets/message/feed"
+	"tickets/tenant"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/labstack/echo/v4"
+)
+
+// streamedEvents are events available on the events stream, by name.
+var streamedEvents = map[string]func() any{
+	"TicketBookingConfirmed": func() any { return &entities.TicketBookingConfirmed{} },
+	"TicketBookingCanceled":  func() any { return &entities.TicketBookingCanceled{} },
+	"TicketRefunded":         func() any { return &entities.TicketRefunded{} },
+	"BookingMade":            func() any { return &entities.BookingMade{} },
+}
+
+// eventsMarshaler decodes events in any format they are published in.
+var eventsMarshaler = event.DefaultMarshaler()
+
+// GetEventsStream streams events as Server-Sent Events.
+//
+// Query parameters: types (comma-separated event names, all by default) and ticket_id.
+// Streaming resumes after the Last-Event-ID header, or the last_event_id parameter for the first connection.
+func (h Handler) GetEventsStream(c echo.Context) error {
+	types, err := streamedEventTypes(c.QueryParam("types"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	ticketID := c.QueryParam("ticket_id")
+
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.QueryParam("last_event_id")
+	}
+
+	ctx := c.Request().Context()
+
+	// events of tenants with dedicated topics are read from their topics, others are filtered from the shared ones
+	tenantID := tenant.FromContext(ctx)
+	dedicatedTenantID := h.tenants.DedicatedTopic(tenantID)
+
+	topics := make([]string, 0, len(types))
+	for _, name := range types {
+		topics = append(topics, tenant.Topic(name, dedicatedTenantID))
+	}
+
+	events, err := h.eventsFeed.Subscribe(ctx, topics, lastEventID)
+	if errors.Is(err, feed.ErrInvalidID) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if errors.Is(err, feed.ErrTooManySubscriptions) {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, "text/event-stream")
+	header.Set(echo.HeaderCacheControl, "no-cache")
+	header.Set(echo.HeaderConnection, "keep-alive")
+	// disables response buffering in nginx
+	header.Set("X-Accel-Buffering", "no")
+	c.Response().WriteHeader(http.StatusOK)
+
+	// writing past the middlewares, so the stream isn't kept in memory for request logs
+	w := unwrapResponseWriter(c.Response().Writer)
+	controller := http.NewResponseController(w)
+
+	if err := controller.Flush(); err != nil {
+		return err
+	}
+
+	heartbeat := time.NewTicker(h.eventsStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	logger := log.FromContext(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-h.shuttingDown:
+			return nil
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			// the event name without the dedicated topic suffix
+			name := strings.TrimSuffix(e.Topic, tenant.Topic("", dedicatedTenantID))
+
+			data, err := streamedEventData(name, e)
+			if err != nil {
+				logger.WithError(err).WithField("event_id", e.ID).Warn("Skipping event that can't be streamed")
+				continue
+			}
+			if eventTenantID(data) != tenantID {
+				continue
+			}
+			if ticketID != "" && eventTicketID(data) != ticketID {
+				continue
+			}
+
+			payload, err := json.Marshal(data)
+			if err != nil {
+				return err
+			}
+
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, name, payload); err != nil {
+				// client disconnected
+				return nil
+			}
+		}
+
+		if err := controller.Flush(); err != nil {
+			return nil
+		}
+	}
+}
+
+func streamedEventTypes(param string) ([]string, error) {
+	if param == "" {
+		types := make([]string, 0, len(streamedEvents))
+		for name := range streamedEvents {
+			types = append(types, name)
+		}
+		return types, nil
+	}
+
+	var types []string
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := streamedEvents[name]; !ok {
+			return nil, fmt.Errorf("unknown event type %s", name)
+		}
+		types = append(types, name)
+	}
+
+	return types, nil
+}
+
+func streamedEventData(name string, e feed.Event) (any, error) {
+	newEvent, ok := streamedEvents[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown event type %s", name)
+	}
+
+	data := newEvent()
+	if err := eventsMarshaler.Unmarshal(e.Message, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func eventTicketID(data any) string {
+	switch e := data.(type) {
+	case *entities.TicketBookingConfirmed:
+		return e.TicketID
+	case *entities.TicketBookingCanceled:
+		return e.TicketID
+	case *entities.TicketRefunded:
+		return e.TicketID
+	default:
+		return ""
+	}
+}
+
+// eventTenantID returns the tenant of the event, events without one belong to the default tenant.
+func eventTenantID(data any) string {
+	var header entities.EventHeader
+	switch e := data.(type) {
+	case *entities.TicketBookingConfirmed:
+		header = e.Header
+	case *entities.TicketBookingCanceled:
+		header = e.Header
+	case *entities.TicketRefunded:
+		header = e.Header
+	case *entities.BookingMade:
+		header = e.Header
+	}
+
+	if header.TenantID == "" {
+		return tenant.DefaultID
+	}
+
+	return header.TenantID
+}
+
+func unwrapResponseWriter(w http.ResponseWriter) http.ResponseWriter {
+	for {
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return w
+		}
+		w = unwrapper.Unwrap()
+	}
+}
@@ -0,0 +1,35 @@
+package http
+
+import (
+	"net/http"
+	"tickets/health"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h Handler) GetHealthLive(c echo.Context) error {
+	return c.String(http.StatusOK, "ok")
+}
+
+func (h Handler) GetHealthReady(c echo.Context) error {
+	report := health.Run(c.Request().Context(), h.readinessTimeout, h.readinessChecks)
+
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+	}
+
+	checks := make(map[string]CheckResult, len(report.Checks))
+	for name, check := range report.Checks {
+		checks[name] = CheckResult{
+			Status:    CheckResultStatus(check.Status),
+			LatencyMs: check.LatencyMs,
+			Error:     check.Error,
+		}
+	}
+
+	return c.JSON(status, ReadinessReport{
+		Status: ReadinessReportStatus(report.Status),
+		Checks: checks,
+	})
+}
@@ -4,36 +4,25 @@ import (
 	"fmt"
 	"net/http"
 	"tickets/entities"
+	"tickets/tenant"
 
 	"github.com/labstack/echo/v4"
 )
 
-type ticketsStatusRequest struct {
-	Tickets []ticketStatusRequest `json:"tickets"`
-}
-
-type ticketStatusRequest struct {
-	TicketID      string         `json:"ticket_id"`
-	Status        string         `json:"status"`
-	Price         entities.Money `json:"price"`
-	CustomerEmail string         `json:"customer_email"`
-	BookingID     string         `json:"booking_id"`
-}
-
 func (h Handler) PostTicketsStatus(c echo.Context) error {
-	var request ticketsStatusRequest
+	var request TicketsStatusRequest
 	err := c.Bind(&request)
 	if err != nil {
 		return err
 	}
 
 	for _, ticket := range request.Tickets {
-		if ticket.Status == "confirmed" {
+		if ticket.Status == TicketStatusStatusConfirmed {
 			event := entities.TicketBookingConfirmed{
-				Header: entities.NewEventHeader(),
+				Header: entities.NewEventHeader(tenant.FromContext(c.Request().Context())),
 
 				TicketID:      ticket.TicketID,
-				Price:         ticket.Price,
+				Price:         ticketPrice(ticket),
 				CustomerEmail: ticket.CustomerEmail,
 
 				BookingID: ticket.BookingID,
@@ -42,12 +31,12 @@ func (h Handler) PostTicketsStatus(c echo.Context) error {
 			if err := h.eventBus.Publish(c.Request().Context(), event); err != nil {
 				return fmt.Errorf("failed to publish TicketBookingConfirmed event: %w", err)
 			}
-		} else if ticket.Status == "canceled" {
+		} else if ticket.Status == TicketStatusStatusCanceled {
 			event := entities.TicketBookingCanceled{
-				Header:        entities.NewEventHeader(),
+				Header:        entities.NewEventHeader(tenant.FromContext(c.Request().Context())),
 				TicketID:      ticket.TicketID,
 				CustomerEmail: ticket.CustomerEmail,
-				Price:         ticket.Price,
+				Price:         ticketPrice(ticket),
 			}
 
 			if err := h.eventBus.Publish(c.Request().Context(), event); err != nil {
@@ -60,3 +49,14 @@ func (h Handler) PostTicketsStatus(c echo.Context) error {
 
 	return c.NoContent(http.StatusOK)
 }
+
+func ticketPrice(ticket TicketStatus) entities.Money {
+	if ticket.Price == nil {
+		return entities.Money{}
+	}
+
+	return entities.Money{
+		Amount:   ticket.Price.Amount,
+		Currency: ticket.Price.Currency,
+	}
+}
@@ -0,0 +1,293 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"tickets/tenant"
+	"tickets/webhooks"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	minWebhookSecretLength = 16
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+func (h Handler) PostWebhookSubscription(c echo.Context) error {
+	var request WebhookSubscriptionRequest
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := h.validateWebhookSubscription(c.Request().Context(), request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	secret := request.Secret
+	if secret == "" {
+		var err error
+		secret, err = generateWebhookSecret()
+		if err != nil {
+			return err
+		}
+	}
+
+	subscription := webhooks.Subscription{
+		ID:         uuid.NewString(),
+		TenantID:   tenant.FromContext(c.Request().Context()),
+		URL:        request.URL,
+		EventTypes: webhookEventTypeNames(request.EventTypes),
+		Secret:     secret,
+		Disabled:   request.Enabled != nil && !*request.Enabled,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	if err := h.webhooksRepository.AddSubscription(c.Request().Context(), subscription); err != nil {
+		return err
+	}
+
+	response := newWebhookSubscriptionResponse(subscription)
+	response.Secret = subscription.Secret
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+func (h Handler) GetWebhookSubscriptions(c echo.Context) error {
+	subscriptions, err := h.webhooksRepository.ListSubscriptions(c.Request().Context(), tenant.FromContext(c.Request().Context()))
+	if err != nil {
+		return err
+	}
+
+	response := make([]WebhookSubscription, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		response = append(response, newWebhookSubscriptionResponse(subscription))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h Handler) GetWebhookSubscription(c echo.Context) error {
+	id, err := webhookSubscriptionID(c)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := h.getTenantWebhookSubscription(c, id)
+	if err != nil {
+		return webhookSubscriptionError(err)
+	}
+
+	return c.JSON(http.StatusOK, newWebhookSubscriptionResponse(subscription))
+}
+
+func (h Handler) PutWebhookSubscription(c echo.Context) error {
+	id, err := webhookSubscriptionID(c)
+	if err != nil {
+		return err
+	}
+
+	var request WebhookSubscriptionRequest
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := h.validateWebhookSubscription(c.Request().Context(), request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	subscription, err := h.webhooksRepository.UpdateSubscription(
+		c.Request().Context(),
+		id,
+		func(subscription *webhooks.Subscription) error {
+			if subscription.TenantID != tenant.FromContext(c.Request().Context()) {
+				return webhooks.ErrSubscriptionNotFound
+			}
+
+			subscription.URL = request.URL
+			subscription.EventTypes = webhookEventTypeNames(request.EventTypes)
+			if request.Secret != "" {
+				subscription.Secret = request.Secret
+			}
+
+			if request.Enabled != nil {
+				subscription.Disabled = !*request.Enabled
+				subscription.DisabledReason = ""
+				subscription.ConsecutiveFailures = 0
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return webhookSubscriptionError(err)
+	}
+
+	return c.JSON(http.StatusOK, newWebhookSubscriptionResponse(subscription))
+}
+
+func (h Handler) DeleteWebhookSubscription(c echo.Context) error {
+	id, err := webhookSubscriptionID(c)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.getTenantWebhookSubscription(c, id); err != nil {
+		return webhookSubscriptionError(err)
+	}
+
+	if err := h.webhooksRepository.DeleteSubscription(c.Request().Context(), id); err != nil {
+		return webhookSubscriptionError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h Handler) GetWebhookDeliveries(c echo.Context) error {
+	id, err := webhookSubscriptionID(c)
+	if err != nil {
+		return err
+	}
+
+	limit := defaultDeliveriesLimit
+	if value := c.QueryParam("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxDeliveriesLimit {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxDeliveriesLimit))
+		}
+	}
+
+	if _, err := h.getTenantWebhookSubscription(c, id); err != nil {
+		return webhookSubscriptionError(err)
+	}
+
+	deliveries, err := h.webhooksRepository.ListDeliveries(c.Request().Context(), id, limit)
+	if err != nil {
+		return err
+	}
+
+	response := make([]WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		response = append(response, WebhookDelivery{
+			ID:          delivery.ID,
+			EventID:     delivery.EventID,
+			EventType:   WebhookEventType(delivery.EventType),
+			Attempt:     delivery.Attempt,
+			StatusCode:  delivery.StatusCode,
+			Error:       delivery.Error,
+			Succeeded:   delivery.Succeeded,
+			DurationMs:  delivery.Duration.Milliseconds(),
+			AttemptedAt: delivery.AttemptedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// getTenantWebhookSubscription returns webhooks.ErrSubscriptionNotFound for subscriptions of other tenants,
+// so their IDs can't be probed.
+func (h Handler) getTenantWebhookSubscription(c echo.Context, id string) (webhooks.Subscription, error) {
+	subscription, err := h.webhooksRepository.GetSubscription(c.Request().Context(), id)
+	if err != nil {
+		return webhooks.Subscription{}, err
+	}
+
+	if subscription.TenantID != tenant.FromContext(c.Request().Context()) {
+		return webhooks.Subscription{}, webhooks.ErrSubscriptionNotFound
+	}
+
+	return subscription, nil
+}
+
+func newWebhookSubscriptionResponse(subscription webhooks.Subscription) WebhookSubscription {
+	eventTypes := make([]WebhookEventType, 0, len(subscription.EventTypes))
+	for _, eventType := range subscription.EventTypes {
+		eventTypes = append(eventTypes, WebhookEventType(eventType))
+	}
+
+	return WebhookSubscription{
+		ID:                  subscription.ID,
+		URL:                 subscription.URL,
+		EventTypes:          eventTypes,
+		Enabled:             !subscription.Disabled,
+		DisabledReason:      subscription.DisabledReason,
+		ConsecutiveFailures: subscription.ConsecutiveFailures,
+		CreatedAt:           subscription.CreatedAt,
+	}
+}
+
+func (h Handler) validateWebhookSubscription(ctx context.Context, request WebhookSubscriptionRequest) error {
+	var errs []error
+
+	if err := h.webhookURLs.CheckURL(ctx, request.URL); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(request.EventTypes) == 0 {
+		errs = append(errs, errors.New("event_types must not be empty"))
+	}
+	for _, eventType := range request.EventTypes {
+		if !isWebhookEventType(string(eventType)) {
+			errs = append(errs, fmt.Errorf("unknown event type %s, supported: %v", eventType, webhooks.EventTypes))
+		}
+	}
+
+	if request.Secret != "" && len(request.Secret) < minWebhookSecretLength {
+		errs = append(errs, fmt.Errorf("secret must have at least %d characters", minWebhookSecretLength))
+	}
+
+	return errors.Join(errs...)
+}
+
+func webhookEventTypeNames(eventTypes []WebhookEventType) []string {
+	names := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		names = append(names, string(eventType))
+	}
+
+	return names
+}
+
+func isWebhookEventType(eventType string) bool {
+	for _, t := range webhooks.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func webhookSubscriptionID(c echo.Context) (string, error) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return "", echo.NewHTTPError(http.StatusNotFound, webhooks.ErrSubscriptionNotFound.Error())
+	}
+
+	return id, nil
+}
+
+func webhookSubscriptionError(err error) error {
+	if errors.Is(err, webhooks.ErrSubscriptionNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+
+	return err
+}
+
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	return hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+	"tickets/config"
+
+	"github.com/labstack/echo/v4"
+)
+
+// requireAdminKey rejects requests without one of the admin keys with 401.
+func requireAdminKey(auth config.Auth) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !auth.Admin(bearerToken(c.Request())) {
+				return unauthorized(c)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// bearerToken returns the token of the Authorization header, "" if there is none.
+func bearerToken(req *http.Request) string {
+	token, ok := strings.CutPrefix(req.Header.Get(echo.HeaderAuthorization), "Bearer ")
+	if !ok {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+func unauthorized(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+	return echo.NewHTTPError(http.StatusUnauthorized, "missing or invalid API key")
+}
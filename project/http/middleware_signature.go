@@ -0,0 +1,46 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"tickets/webhooks"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxSignedBodySize limits bodies read to verify their signature, before they are known to come from a trusted sender.
+const maxSignedBodySize = 1 << 20
+
+// verifySignature rejects requests not signed by a trusted sender with 401.
+func verifySignature(verifier RequestVerifier) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			body, err := io.ReadAll(http.MaxBytesReader(c.Response(), req.Body, maxSignedBodySize))
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("body is larger than %d bytes", maxBytesErr.Limit))
+			}
+			if err != nil {
+				return err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			err = verifier.Verify(req.Context(), req.Header, body)
+
+			var verificationErr webhooks.VerificationError
+			if errors.As(err, &verificationErr) {
+				return echo.NewHTTPError(http.StatusUnauthorized, verificationErr.Reason)
+			}
+			if err != nil {
+				return err
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"tickets/config"
+	"tickets/tenant"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/labstack/echo/v4"
+)
+
+// identifyTenant puts the tenant from the Tenant-ID header to the request context and logger.
+// Requests without the header belong to the default tenant, requests of unknown tenants are rejected with 400.
+func identifyTenant(tenants config.Tenants) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			tenantID := c.Request().Header.Get(tenant.HTTPHeader)
+			if tenantID == "" {
+				tenantID = tenant.DefaultID
+			}
+
+			if !tenant.ValidID(tenantID) || !tenants.Known(tenantID) {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unknown tenant %q", tenantID))
+			}
+
+			setTenant(c, tenantID)
+
+			return next(c)
+		}
+	}
+}
+
+// authenticateTenant identifies the tenant by its API key, so clients can act only as their own tenant.
+// Requests without a key of a tenant are rejected with 401, requests with the Tenant-ID header
+// of another tenant with 403.
+func authenticateTenant(auth config.Auth) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			tenantID, ok := auth.Tenant(bearerToken(c.Request()))
+			if !ok {
+				return unauthorized(c)
+			}
+
+			if header := c.Request().Header.Get(tenant.HTTPHeader); header != "" && header != tenantID {
+				return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("API key doesn't belong to tenant %q", header))
+			}
+
+			setTenant(c, tenantID)
+
+			return next(c)
+		}
+	}
+}
+
+func setTenant(c echo.Context, tenantID string) {
+	ctx := c.Request().Context()
+	ctx = tenant.ContextWithID(ctx, tenantID)
+	ctx = log.ToContext(ctx, log.FromContext(ctx).WithField(tenant.LogField, tenantID))
+	c.SetRequest(c.Request().WithContext(ctx))
+}
@@ -0,0 +1,302 @@
+// Package http provides primitives to interact with the openapi HTTP API.
+//
+// Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.4.1 DO NOT EDIT.
+package http
+
+import (
+	"time"
+)
+
+const (
+	AdminKeyScopes  = "AdminKey.Scopes"
+	TenantKeyScopes = "TenantKey.Scopes"
+)
+
+// Defines values for CheckResultStatus.
+const (
+	CheckResultStatusFail CheckResultStatus = "fail"
+	CheckResultStatusOk   CheckResultStatus = "ok"
+)
+
+// Defines values for ReadinessReportStatus.
+const (
+	ReadinessReportStatusFail ReadinessReportStatus = "fail"
+	ReadinessReportStatusOk   ReadinessReportStatus = "ok"
+)
+
+// Defines values for TicketStatusStatus.
+const (
+	TicketStatusStatusCanceled  TicketStatusStatus = "canceled"
+	TicketStatusStatusConfirmed TicketStatusStatus = "confirmed"
+)
+
+// Defines values for WebhookEventType.
+const (
+	WebhookEventTypeTicketBookingCanceled  WebhookEventType = "TicketBookingCanceled"
+	WebhookEventTypeTicketBookingConfirmed WebhookEventType = "TicketBookingConfirmed"
+	WebhookEventTypeTicketRefunded         WebhookEventType = "TicketRefunded"
+)
+
+// CheckResult defines model for CheckResult.
+type CheckResult struct {
+	Error     string            `json:"error,omitempty"`
+	LatencyMs float64           `json:"latency_ms"`
+	Status    CheckResultStatus `json:"status"`
+}
+
+// CheckResultStatus defines model for CheckResult.Status.
+type CheckResultStatus string
+
+// Error defines model for Error.
+type Error struct {
+	Error string `json:"error"`
+}
+
+// Money defines model for Money.
+type Money struct {
+	// Amount Decimal amount.
+	Amount string `json:"amount"`
+
+	// Currency ISO 4217 currency code.
+	Currency string `json:"currency"`
+}
+
+// ReadinessReport defines model for ReadinessReport.
+type ReadinessReport struct {
+	Checks map[string]CheckResult `json:"checks"`
+	Status ReadinessReportStatus  `json:"status"`
+}
+
+// ReadinessReportStatus defines model for ReadinessReport.Status.
+type ReadinessReportStatus string
+
+// ReconciliationDuplicate defines model for ReconciliationDuplicate.
+type ReconciliationDuplicate struct {
+	Rows     int    `json:"rows"`
+	TicketID string `json:"ticket_id"`
+}
+
+// ReconciliationReport defines model for ReconciliationReport.
+type ReconciliationReport struct {
+	FinishedAt time.Time                   `json:"finished_at"`
+	Sheets     []ReconciliationSheetReport `json:"sheets"`
+	StartedAt  time.Time                   `json:"started_at"`
+}
+
+// ReconciliationSheetReport defines model for ReconciliationSheetReport.
+type ReconciliationSheetReport struct {
+	// Duplicates Tickets with more than one row.
+	Duplicates []ReconciliationDuplicate `json:"duplicates"`
+
+	// Missing IDs of tickets without their row.
+	Missing []string `json:"missing"`
+
+	// Republished How many events of tickets with missing rows were published again.
+	Republished int `json:"republished"`
+
+	// Rows How many rows the sheet has, without the header row.
+	Rows     int    `json:"rows"`
+	Sheet    string `json:"sheet"`
+	TenantID string `json:"tenant_id"`
+
+	// Tickets How many tickets should have their row in the sheet.
+	Tickets int `json:"tickets"`
+}
+
+// ScheduledMessage defines model for ScheduledMessage.
+type ScheduledMessage struct {
+	// DeliverAt When the message is due, or when its delivery is retried if it's being delivered.
+	DeliverAt time.Time `json:"deliver_at"`
+
+	// Key Key the message can be canceled with.
+	Key         string            `json:"key"`
+	MessageUUID string            `json:"message_uuid"`
+	Metadata    map[string]string `json:"metadata"`
+	Topic       string            `json:"topic"`
+}
+
+// StreamGroupReport defines model for StreamGroupReport.
+type StreamGroupReport struct {
+	ConsumersPending    map[string]int64 `json:"consumers_pending"`
+	Lag                 int64            `json:"lag"`
+	LastDeliveredID     string           `json:"last_delivered_id"`
+	Name                string           `json:"name"`
+	OldestPendingAgeMs  int64            `json:"oldest_pending_age_ms"`
+	OldestPendingIdleMs int64            `json:"oldest_pending_idle_ms"`
+	Pending             int64            `json:"pending"`
+}
+
+// StreamReport defines model for StreamReport.
+type StreamReport struct {
+	Groups          []StreamGroupReport `json:"groups"`
+	LastGeneratedID string              `json:"last_generated_id"`
+	Length          int64               `json:"length"`
+	Topic           string              `json:"topic"`
+}
+
+// TicketStatus defines model for TicketStatus.
+type TicketStatus struct {
+	BookingID     string             `json:"booking_id,omitempty"`
+	CustomerEmail string             `json:"customer_email"`
+	Price         *Money             `json:"price,omitempty"`
+	Status        TicketStatusStatus `json:"status"`
+	TicketID      string             `json:"ticket_id"`
+}
+
+// TicketStatusStatus defines model for TicketStatus.Status.
+type TicketStatusStatus string
+
+// TicketsStatusRequest defines model for TicketsStatusRequest.
+type TicketsStatusRequest struct {
+	Tickets []TicketStatus `json:"tickets"`
+}
+
+// WebhookDelivery defines model for WebhookDelivery.
+type WebhookDelivery struct {
+	Attempt     int              `json:"attempt"`
+	AttemptedAt time.Time        `json:"attempted_at"`
+	DurationMs  int64            `json:"duration_ms"`
+	Error       string           `json:"error,omitempty"`
+	EventID     string           `json:"event_id"`
+	EventType   WebhookEventType `json:"event_type"`
+	ID          string           `json:"id"`
+
+	// StatusCode Response status, not set when no response was received.
+	StatusCode int  `json:"status_code,omitempty"`
+	Succeeded  bool `json:"succeeded"`
+}
+
+// WebhookEventType defines model for WebhookEventType.
+type WebhookEventType string
+
+// WebhookSubscription defines model for WebhookSubscription.
+type WebhookSubscription struct {
+	ConsecutiveFailures int                `json:"consecutive_failures"`
+	CreatedAt           time.Time          `json:"created_at"`
+	DisabledReason      string             `json:"disabled_reason,omitempty"`
+	Enabled             bool               `json:"enabled"`
+	EventTypes          []WebhookEventType `json:"event_types"`
+	ID                  string             `json:"id"`
+
+	// Secret Returned only when the subscription is created.
+	Secret string `json:"secret,omitempty"`
+	URL    string `json:"url"`
+}
+
+// WebhookSubscriptionRequest defines model for WebhookSubscriptionRequest.
+type WebhookSubscriptionRequest struct {
+	// Enabled Disables the subscription, or re-enables a disabled one. Enabled on creation by default.
+	Enabled    *bool              `json:"enabled,omitempty"`
+	EventTypes []WebhookEventType `json:"event_types"`
+
+	// Secret Secret signing the deliveries. It's generated when not set on creation, and kept when not set on update.
+	Secret string `json:"secret,omitempty"`
+
+	// URL Absolute http or https URL.
+	URL string `json:"url"`
+}
+
+// SubscriptionID defines model for SubscriptionID.
+type SubscriptionID = string
+
+// TenantID defines model for TenantID.
+type TenantID = string
+
+// GetAdminScheduledMessagesParams defines parameters for GetAdminScheduledMessages.
+type GetAdminScheduledMessagesParams struct {
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// GetEventsStreamParams defines parameters for GetEventsStream.
+type GetEventsStreamParams struct {
+	// Types Comma-separated event names, all events by default.
+	Types *string `form:"types,omitempty" json:"types,omitempty"`
+
+	// TicketID Stream only events of this ticket.
+	TicketID *string `form:"ticket_id,omitempty" json:"ticket_id,omitempty"`
+
+	// LastEventID Resume after this event ID. Ignored when the Last-Event-ID header is set.
+	LastEventID *string `form:"last_event_id,omitempty" json:"last_event_id,omitempty"`
+
+	// TenantID Tenant the request belongs to, the default tenant if not set. Unknown tenants are rejected with 400.
+	// Subscriptions of other tenants are not found. Where an API key of a tenant is required,
+	// the key identifies the tenant and the header may only repeat it.
+	TenantID *TenantID `json:"Tenant-ID,omitempty"`
+
+	// LastEventIDHeader Resume after this event ID, set by browsers when reconnecting.
+	LastEventIDHeader *string `json:"Last-Event-ID,omitempty"`
+}
+
+// PostTicketsStatusParams defines parameters for PostTicketsStatus.
+type PostTicketsStatusParams struct {
+	// TenantID Tenant the request belongs to, the default tenant if not set. Unknown tenants are rejected with 400.
+	// Subscriptions of other tenants are not found. Where an API key of a tenant is required,
+	// the key identifies the tenant and the header may only repeat it.
+	TenantID *TenantID `json:"Tenant-ID,omitempty"`
+
+	// WebhookID Unique request ID, each ID is accepted once.
+	WebhookID *string `json:"Webhook-Id,omitempty"`
+
+	// WebhookTimestamp Unix time in seconds when the request was signed, it must be within a few minutes of the current time.
+	WebhookTimestamp *int64  `json:"Webhook-Timestamp,omitempty"`
+	WebhookSignature *string `json:"Webhook-Signature,omitempty"`
+}
+
+// GetWebhookSubscriptionsParams defines parameters for GetWebhookSubscriptions.
+type GetWebhookSubscriptionsParams struct {
+	// TenantID Tenant the request belongs to, the default tenant if not set. Unknown tenants are rejected with 400.
+	// Subscriptions of other tenants are not found. Where an API key of a tenant is required,
+	// the key identifies the tenant and the header may only repeat it.
+	TenantID *TenantID `json:"Tenant-ID,omitempty"`
+}
+
+// PostWebhookSubscriptionParams defines parameters for PostWebhookSubscription.
+type PostWebhookSubscriptionParams struct {
+	// TenantID Tenant the request belongs to, the default tenant if not set. Unknown tenants are rejected with 400.
+	// Subscriptions of other tenants are not found. Where an API key of a tenant is required,
+	// the key identifies the tenant and the header may only repeat it.
+	TenantID *TenantID `json:"Tenant-ID,omitempty"`
+}
+
+// DeleteWebhookSubscriptionParams defines parameters for DeleteWebhookSubscription.
+type DeleteWebhookSubscriptionParams struct {
+	// TenantID Tenant the request belongs to, the default tenant if not set. Unknown tenants are rejected with 400.
+	// Subscriptions of other tenants are not found. Where an API key of a tenant is required,
+	// the key identifies the tenant and the header may only repeat it.
+	TenantID *TenantID `json:"Tenant-ID,omitempty"`
+}
+
+// GetWebhookSubscriptionParams defines parameters for GetWebhookSubscription.
+type GetWebhookSubscriptionParams struct {
+	// TenantID Tenant the request belongs to, the default tenant if not set. Unknown tenants are rejected with 400.
+	// Subscriptions of other tenants are not found. Where an API key of a tenant is required,
+	// the key identifies the tenant and the header may only repeat it.
+	TenantID *TenantID `json:"Tenant-ID,omitempty"`
+}
+
+// PutWebhookSubscriptionParams defines parameters for PutWebhookSubscription.
+type PutWebhookSubscriptionParams struct {
+	// TenantID Tenant the request belongs to, the default tenant if not set. Unknown tenants are rejected with 400.
+	// Subscriptions of other tenants are not found. Where an API key of a tenant is required,
+	// the key identifies the tenant and the header may only repeat it.
+	TenantID *TenantID `json:"Tenant-ID,omitempty"`
+}
+
+// GetWebhookDeliveriesParams defines parameters for GetWebhookDeliveries.
+type GetWebhookDeliveriesParams struct {
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// TenantID Tenant the request belongs to, the default tenant if not set. Unknown tenants are rejected with 400.
+	// Subscriptions of other tenants are not found. Where an API key of a tenant is required,
+	// the key identifies the tenant and the header may only repeat it.
+	TenantID *TenantID `json:"Tenant-ID,omitempty"`
+}
+
+// PostTicketsStatusJSONRequestBody defines body for PostTicketsStatus for application/json ContentType.
+type PostTicketsStatusJSONRequestBody = TicketsStatusRequest
+
+// PostWebhookSubscriptionJSONRequestBody defines body for PostWebhookSubscription for application/json ContentType.
+type PostWebhookSubscriptionJSONRequestBody = WebhookSubscriptionRequest
+
+// PutWebhookSubscriptionJSONRequestBody defines body for PutWebhookSubscription for application/json ContentType.
+type PutWebhookSubscriptionJSONRequestBody = WebhookSubscriptionRequest
@@ -0,0 +1,192 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/labstack/echo/v4"
+)
+
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.4.1 -config oapi-codegen.yaml openapi.yaml
+
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// LoadOpenAPISpec loads and validates the spec of the HTTP API.
+func LoadOpenAPISpec() (*openapi3.T, error) {
+	spec, err := openapi3.NewLoader().LoadFromData(openAPISpec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OpenAPI spec: %w", err)
+	}
+
+	if err := spec.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+
+	return spec, nil
+}
+
+// openAPIValidator validates requests and responses of operations in the spec, other routes are passed through.
+//
+// Invalid requests are rejected with 400. Invalid responses are logged, and replaced with 500 when strictResponses is set.
+// Streamed responses and error responses rendered by the HTTP error handler aren't validated.
+func openAPIValidator(spec *openapi3.T, strictResponses bool) (echo.MiddlewareFunc, error) {
+	router, err := gorillamux.NewRouter(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OpenAPI router: %w", err)
+	}
+
+	options := &openapi3filter.Options{
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+		IncludeResponseStatus: true,
+	}
+	options.WithCustomSchemaErrorFunc(schemaErrorMessage)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			route, pathParams, err := router.FindRoute(req)
+			if errors.Is(err, routers.ErrPathNotFound) || errors.Is(err, routers.ErrMethodNotAllowed) {
+				return next(c)
+			}
+			if err != nil {
+				return err
+			}
+
+			requestInput := &openapi3filter.RequestValidationInput{
+				Request:    req,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			}
+			if err := openapi3filter.ValidateRequest(req.Context(), requestInput); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+
+			if streamsResponse(route.Operation) {
+				return next(c)
+			}
+
+			response := c.Response()
+			writer := response.Writer
+			buffer := &bufferedResponseWriter{header: writer.Header()}
+
+			response.Writer = buffer
+			err = next(c)
+			response.Writer = writer
+
+			if err == nil && response.Committed {
+				validationErr := openapi3filter.ValidateResponse(req.Context(), (&openapi3filter.ResponseValidationInput{
+					RequestValidationInput: requestInput,
+					Status:                 buffer.status,
+					Header:                 buffer.header,
+					Options:                options,
+				}).SetBodyBytes(buffer.body.Bytes()))
+				if validationErr != nil {
+					log.FromContext(req.Context()).
+						WithError(validationErr).
+						WithField("operation", route.Operation.OperationID).
+						Error("Response doesn't match the OpenAPI spec")
+
+					if strictResponses {
+						buffer.reset()
+						response.Status = http.StatusInternalServerError
+						return buffer.writeError(writer, "Response doesn't match the OpenAPI spec")
+					}
+				}
+			}
+
+			return buffer.writeTo(writer, err)
+		}
+	}, nil
+}
+
+// openAPIJSON serves the spec as JSON.
+func openAPIJSON(spec *openapi3.T) (echo.HandlerFunc, error) {
+	data, err := spec.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal OpenAPI spec: %w", err)
+	}
+
+	return func(c echo.Context) error {
+		return c.JSONBlob(http.StatusOK, data)
+	}, nil
+}
+
+func streamsResponse(operation *openapi3.Operation) bool {
+	ok := operation.Responses.Status(http.StatusOK)
+	return ok != nil && ok.Value != nil && ok.Value.Content.Get("text/event-stream") != nil
+}
+
+func schemaErrorMessage(err *openapi3.SchemaError) string {
+	if pointer := err.JSONPointer(); len(pointer) > 0 {
+		return fmt.Sprintf("%s: %s", "/"+strings.Join(pointer, "/"), err.Reason)
+	}
+
+	return err.Reason
+}
+
+// bufferedResponseWriter keeps the response until it's validated. Headers are shared with the underlying writer.
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(data)
+}
+
+func (w *bufferedResponseWriter) reset() {
+	for key := range w.header {
+		if strings.HasPrefix(key, "Content-") {
+			w.header.Del(key)
+		}
+	}
+	w.status = 0
+	w.body.Reset()
+}
+
+func (w *bufferedResponseWriter) writeError(to http.ResponseWriter, message string) error {
+	w.header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	to.WriteHeader(http.StatusInternalServerError)
+
+	return json.NewEncoder(to).Encode(Error{Error: message})
+}
+
+// writeTo writes the buffered response, if any was written before the handler returned handlerErr.
+func (w *bufferedResponseWriter) writeTo(to http.ResponseWriter, handlerErr error) error {
+	if w.status == 0 {
+		return handlerErr
+	}
+
+	to.WriteHeader(w.status)
+	if _, err := to.Write(w.body.Bytes()); err != nil {
+		return err
+	}
+
+	return handlerErr
+}
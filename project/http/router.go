@@ -1,29 +1,128 @@
 package http
 
 import (
-	"net/http"
+	"context"
+	"tickets/config"
+	"tickets/health"
+	"tickets/observability"
+	"time"
 
 	libHttp "github.com/ThreeDotsLabs/go-event-driven/common/http"
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 )
 
+// maxBodySize limits request bodies, which are all small JSON documents.
+const maxBodySize = "1M"
+
 func NewHttpRouter(
 	eventBus *cqrs.EventBus,
 	spreadsheetsAPIClient SpreadsheetsAPI,
+	readinessChecks []health.Check,
+	readinessTimeout time.Duration,
+	streamsInspector StreamsInspector,
+	scheduledMessages ScheduledMessages,
+	reconciliation Reconciliation,
+	webhooksRepository WebhooksRepository,
+	webhookURLs WebhookURLChecker,
+	eventsFeed EventsFeed,
+	eventsStreamHeartbeat time.Duration,
+	strictResponseValidation bool,
+	ticketsStatusVerifier RequestVerifier,
+	tenants config.Tenants,
+	auth config.Auth,
 ) *echo.Echo {
+	spec, err := LoadOpenAPISpec()
+	if err != nil {
+		panic(err)
+	}
+	validator, err := openAPIValidator(spec, strictResponseValidation)
+	if err != nil {
+		panic(err)
+	}
+	specHandler, err := openAPIJSON(spec)
+	if err != nil {
+		panic(err)
+	}
+
 	e := libHttp.NewEcho()
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		// otelecho renders errors already, a second response body would be appended to the first one
+		if c.Response().Committed {
+			return
+		}
+		libHttp.HandleError(err, c)
+	}
+	e.Use(otelecho.Middleware(observability.ServiceName))
+	// the validator reads whole bodies, before any handler
+	e.Use(middleware.BodyLimit(maxBodySize))
+	e.Use(validator)
+	e.Use(identifyTenant(tenants))
 
-	e.GET("/health", func(c echo.Context) error {
-		return c.String(http.StatusOK, "ok")
-	})
+	shutdownCtx, shutdown := context.WithCancel(context.Background())
+	e.Server.RegisterOnShutdown(shutdown)
 
 	handler := Handler{
 		eventBus:              eventBus,
 		spreadsheetsAPIClient: spreadsheetsAPIClient,
+
+		readinessChecks:  readinessChecks,
+		readinessTimeout: readinessTimeout,
+
+		streamsInspector:  streamsInspector,
+		scheduledMessages: scheduledMessages,
+		reconciliation:    reconciliation,
+
+		webhooksRepository: webhooksRepository,
+		webhookURLs:        webhookURLs,
+
+		tenants: tenants,
+
+		eventsFeed:            eventsFeed,
+		eventsStreamHeartbeat: eventsStreamHeartbeat,
+		shuttingDown:          shutdownCtx.Done(),
 	}
 
-	e.POST("/tickets-status", handler.PostTicketsStatus)
+	e.GET("/health", handler.GetHealthLive)
+	e.GET("/health/live", handler.GetHealthLive)
+	e.GET("/health/ready", handler.GetHealthReady)
+
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+	e.GET("/openapi.json", specHandler)
+
+	// requests aren't verified only when unsigned requests are explicitly allowed
+	var ticketsStatusMiddlewares []echo.MiddlewareFunc
+	if ticketsStatusVerifier != nil {
+		ticketsStatusMiddlewares = append(ticketsStatusMiddlewares, verifySignature(ticketsStatusVerifier))
+	}
+	e.POST("/tickets-status", handler.PostTicketsStatus, ticketsStatusMiddlewares...)
+
+	subscriptions := e.Group("/webhooks/subscriptions", authenticateTenant(auth))
+	subscriptions.POST("", handler.PostWebhookSubscription)
+	subscriptions.GET("", handler.GetWebhookSubscriptions)
+	subscriptions.GET("/:id", handler.GetWebhookSubscription)
+	subscriptions.PUT("/:id", handler.PutWebhookSubscription)
+	subscriptions.DELETE("/:id", handler.DeleteWebhookSubscription)
+	subscriptions.GET("/:id/deliveries", handler.GetWebhookDeliveries)
+
+	// there's no events feed for transports that can't fan out
+	if eventsFeed != nil {
+		e.GET("/events/stream", handler.GetEventsStream, authenticateTenant(auth))
+	}
+
+	// streams can be inspected only when Redis streams are the transport
+	if streamsInspector != nil {
+		e.GET("/admin/streams", handler.GetAdminStreams, requireAdminKey(auth))
+	}
+
+	e.GET("/admin/scheduled-messages", handler.GetAdminScheduledMessages, requireAdminKey(auth))
+	e.DELETE("/admin/scheduled-messages/:key", handler.DeleteAdminScheduledMessage, requireAdminKey(auth))
+
+	e.GET("/admin/reconciliation", handler.GetAdminReconciliation, requireAdminKey(auth))
+	e.POST("/admin/reconciliation", handler.PostAdminReconciliation, requireAdminKey(auth))
 
 	return e
 }
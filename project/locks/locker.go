@@ -0,0 +1,30 @@
+// Package locks provides locks shared by replicas of the service, so only one of them runs a job at a time.
+package locks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotAcquired is returned when the lock is held by someone else.
+var ErrNotAcquired = errors.New("lock is held by someone else")
+
+// Locker acquires locks by key. A lock expires after its TTL, so a replica which stopped without releasing
+// it doesn't hold it forever: the TTL must be longer than the work done while holding it.
+type Locker interface {
+	// Acquire fails with ErrNotAcquired when the lock is held.
+	Acquire(ctx context.Context, key string, ttl time.Duration) (Lock, error)
+}
+
+type Lock interface {
+	// Release releases the lock, unless it expired and was acquired by someone else meanwhile.
+	Release(ctx context.Context) error
+}
+
+// newToken identifies a holder of a lock, so only it can release the lock.
+func newToken() string {
+	return uuid.NewString()
+}
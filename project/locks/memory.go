@@ -0,0 +1,58 @@
+package locks
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLocker locks within the process, for running a single replica.
+type MemoryLocker struct {
+	lock  sync.Mutex
+	locks map[string]memoryLock
+	now   func() time.Time
+}
+
+type memoryLock struct {
+	token     string
+	expiresAt time.Time
+}
+
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{
+		locks: map[string]memoryLock{},
+		now:   time.Now,
+	}
+}
+
+func (l *MemoryLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (Lock, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	if held, ok := l.locks[key]; ok && held.expiresAt.After(now) {
+		return nil, ErrNotAcquired
+	}
+
+	token := newToken()
+	l.locks[key] = memoryLock{token: token, expiresAt: now.Add(ttl)}
+
+	return &memoryHeldLock{locker: l, key: key, token: token}, nil
+}
+
+type memoryHeldLock struct {
+	locker *MemoryLocker
+	key    string
+	token  string
+}
+
+func (h *memoryHeldLock) Release(ctx context.Context) error {
+	h.locker.lock.Lock()
+	defer h.locker.lock.Unlock()
+
+	if h.locker.locks[h.key].token == h.token {
+		delete(h.locker.locks, h.key)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package locks_test
+
+import (
+	"context"
+	"testing"
+	"tickets/locks"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLocker(t *testing.T) {
+	ctx := context.Background()
+	locker := locks.NewMemoryLocker()
+
+	lock, err := locker.Acquire(ctx, "key", time.Minute)
+	require.NoError(t, err)
+
+	_, err = locker.Acquire(ctx, "key", time.Minute)
+	assert.ErrorIs(t, err, locks.ErrNotAcquired)
+
+	other, err := locker.Acquire(ctx, "other-key", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, other.Release(ctx))
+
+	require.NoError(t, lock.Release(ctx))
+
+	expiring, err := locker.Acquire(ctx, "key", time.Millisecond)
+	require.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+	taken, err := locker.Acquire(ctx, "key", time.Minute)
+	require.NoError(t, err, "expired lock should be acquired")
+
+	// releasing the expired lock doesn't release the lock of the new holder
+	require.NoError(t, expiring.Release(ctx))
+	_, err = locker.Acquire(ctx, "key", time.Minute)
+	assert.ErrorIs(t, err, locks.ErrNotAcquired)
+
+	require.NoError(t, taken.Release(ctx))
+}
@@ -0,0 +1,65 @@
+package locks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresLocker keeps locks in the locks table created by db/schema.sql.
+// Expired locks are taken over by the next Acquire.
+type PostgresLocker struct {
+	db *sqlx.DB
+}
+
+func NewPostgresLocker(db *sqlx.DB) *PostgresLocker {
+	if db == nil {
+		panic("missing db")
+	}
+
+	return &PostgresLocker{db: db}
+}
+
+func (l *PostgresLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (Lock, error) {
+	token := newToken()
+
+	var acquiredToken string
+	err := l.db.GetContext(
+		ctx,
+		&acquiredToken,
+		`INSERT INTO locks (key, token, expires_at)
+		VALUES ($1, $2, now() + $3 * interval '1 millisecond')
+		ON CONFLICT (key) DO UPDATE SET token = EXCLUDED.token, expires_at = EXCLUDED.expires_at
+			WHERE locks.expires_at <= now()
+		RETURNING token`,
+		key,
+		token,
+		ttl.Milliseconds(),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotAcquired
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", key, err)
+	}
+
+	return &postgresLock{db: l.db, key: key, token: acquiredToken}, nil
+}
+
+type postgresLock struct {
+	db    *sqlx.DB
+	key   string
+	token string
+}
+
+func (l *postgresLock) Release(ctx context.Context) error {
+	if _, err := l.db.ExecContext(ctx, `DELETE FROM locks WHERE key = $1 AND token = $2`, l.key, l.token); err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", l.key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package locks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix namespaces keys of locks.
+const redisKeyPrefix = "tickets:locks:"
+
+// releaseScript deletes the lock only if it's still held with the token.
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisLocker keeps locks in Redis, so they are shared by all service instances.
+type RedisLocker struct {
+	client *redis.Client
+}
+
+func NewRedisLocker(client *redis.Client) *RedisLocker {
+	if client == nil {
+		panic("missing redis client")
+	}
+
+	return &RedisLocker{client: client}
+}
+
+func (l *RedisLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (Lock, error) {
+	token := newToken()
+
+	acquired, err := l.client.SetNX(ctx, redisKeyPrefix+key, token, ttl).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", key, err)
+	}
+	if !acquired {
+		return nil, ErrNotAcquired
+	}
+
+	return &redisLock{client: l.client, key: key, token: token}, nil
+}
+
+type redisLock struct {
+	client *redis.Client
+	key    string
+	token  string
+}
+
+func (l *redisLock) Release(ctx context.Context) error {
+	if err := releaseScript.Run(ctx, l.client, []string{redisKeyPrefix + l.key}, l.token).Err(); err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", l.key, err)
+	}
+
+	return nil
+}
@@ -2,65 +2,95 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"tickets/api"
+	"tickets/config"
+	ticketsDB "tickets/db"
 	"tickets/message"
+	"tickets/message/transport"
+	"tickets/notifications"
+	"tickets/observability"
 	"tickets/service"
 
-	"github.com/ThreeDotsLabs/go-event-driven/common/clients"
 	"github.com/ThreeDotsLabs/go-event-driven/common/log"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
-	apiClients, err := clients.NewClients(
-		os.Getenv("GATEWAY_ADDR"),
-		func(ctx context.Context, req *http.Request) error {
-			req.Header.Set("Correlation-ID", log.CorrelationIDFromContext(ctx))
-			return nil
-		},
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err)
+		os.Exit(2)
+	}
+
+	traceProvider, err := observability.ConfigureOTLPTraceProvider(ctx)
+	if err != nil {
+		panic(err)
+	}
+	defer traceProvider.Shutdown(context.Background())
+
+	apiClients, err := api.NewGatewayClients(
+		cfg.Gateway.Addr,
+		&http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
 	)
 	if err != nil {
 		panic(err)
 	}
 
-	redisClient := message.NewRedisClient(os.Getenv("REDIS_ADDR"))
-	defer redisClient.Close()
+	var redisClient *redis.Client
+	if cfg.UsesRedis() {
+		redisClient = message.NewRedisClient(cfg.Redis)
+		defer redisClient.Close()
+	}
 
 	spreadsheetsService := api.NewSpreadsheetsAPIClient(apiClients)
 	receiptsService := api.NewReceiptsServiceClient(apiClients)
+	paymentsService := api.NewPaymentsServiceClient(apiClients)
 
-	db, err := sqlx.Open("postgres", os.Getenv("POSTGRES_URL"))
+	var notifier notifications.Notifier
+	switch cfg.Notifications.Transport {
+	case "smtp":
+		notifier = notifications.NewSMTPNotifier(cfg.Notifications.From, cfg.Notifications.SMTP)
+	case "outbox":
+		notifier = notifications.NewOutboxNotifier(cfg.Notifications.From, cfg.Notifications.OutboxDir)
+	}
+
+	db, err := sqlx.Open("postgres", cfg.Postgres.URL)
 	if err != nil {
 		panic(err)
 	}
 	defer db.Close()
 
-	var schema = `
-CREATE TABLE IF NOT EXISTS tickets (
-	ticket_id
-		UUID PRIMARY KEY,
-	price_amount
-		DECIMAL(10,2) NOT NULL,
-	price_currency
-		CHAR(3) NOT NULL,
-	customer_email
-		VARCHAR(255) NOT NULL
-    );
-`
+	db.MustExec(ticketsDB.Schema)
 
-	db.MustExec(schema)
+	eventsTransport, err := transport.New(
+		cfg.Messaging.Transport,
+		redisClient,
+		db,
+		log.NewWatermill(log.FromContext(ctx)),
+	)
+	if err != nil {
+		panic(err)
+	}
 
 	err = service.New(
+		cfg,
+		db,
 		redisClient,
+		eventsTransport,
 		spreadsheetsService,
 		receiptsService,
+		paymentsService,
+		notifier,
 	).Run(ctx)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,160 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"tickets/config"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// BatchHandlerFunc handles messages together. It returns an error for each message, nil for the handled ones,
+// or nil when all of them were handled. ctx is the context of the first message.
+type BatchHandlerFunc func(ctx context.Context, messages []*message.Message) []error
+
+// Batcher collects messages handled concurrently into batches, handled with a single call of BatchHandlerFunc.
+//
+// A batch is handled when it has MaxSize messages, or MaxWait after its first message came. Handle returns
+// only after the batch is handled, with the error of its message: the message is acknowledged only after its
+// batch succeeds, and if it failed, it's nacked on its own, so other messages of the batch aren't redelivered.
+type Batcher struct {
+	handle BatchHandlerFunc
+	config config.Batch
+
+	lock    sync.Mutex
+	pending *batch
+}
+
+type batch struct {
+	messages []*message.Message
+	timer    *time.Timer
+	errs     []error
+	done     chan struct{}
+}
+
+func NewBatcher(handle BatchHandlerFunc, cfg config.Batch) *Batcher {
+	if handle == nil {
+		panic("missing handle")
+	}
+
+	return &Batcher{
+		handle: handle,
+		config: cfg,
+	}
+}
+
+// Handle is a message.NoPublishHandlerFunc adding msg to the pending batch.
+func (b *Batcher) Handle(msg *message.Message) error {
+	b.lock.Lock()
+	current := b.pending
+	if current == nil {
+		current = &batch{done: make(chan struct{})}
+		current.timer = time.AfterFunc(b.config.MaxWait, func() { b.flush(current) })
+		b.pending = current
+	}
+	i := len(current.messages)
+	current.messages = append(current.messages, msg)
+	full := len(current.messages) >= b.config.MaxSize
+	b.lock.Unlock()
+
+	if full {
+		b.flush(current)
+	}
+
+	<-current.done
+
+	return current.errs[i]
+}
+
+func (b *Batcher) flush(current *batch) {
+	b.lock.Lock()
+	if b.pending != current {
+		// flushed already, when it was full just as MaxWait passed
+		b.lock.Unlock()
+		return
+	}
+	b.pending = nil
+	b.lock.Unlock()
+
+	current.timer.Stop()
+
+	// messages waiting for the batch must not hang, even when the handler panics
+	defer close(current.done)
+	defer func() {
+		if r := recover(); r != nil {
+			current.errs = batchErrors(len(current.messages), fmt.Errorf("batch handler panicked: %v", r))
+		}
+	}()
+
+	errs := b.handle(current.messages[0].Context(), current.messages)
+	if errs == nil {
+		errs = make([]error, len(current.messages))
+	}
+	if len(errs) != len(current.messages) {
+		errs = batchErrors(
+			len(current.messages),
+			fmt.Errorf("batch handler returned %d errors for %d messages", len(errs), len(current.messages)),
+		)
+	}
+
+	current.errs = errs
+}
+
+// batchErrors returns err for each of n messages.
+func batchErrors(n int, err error) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+// addBatchEventHandler consumes events with handle in batches. There are cfg.MaxSize handlers, handlerName
+// followed by ones named "<handlerName>-<n>", sharing the consumer group of handlerName, so up to that many messages are in flight.
+//
+// Subscribers of a consumer group must compete for its messages, like Redis streams consumers do, which is not
+// the case for GoChannel: it needs cfg.MaxSize of 1.
+func addBatchEventHandler(
+	router *message.Router,
+	processorConfig cqrs.EventProcessorConfig,
+	handlerName string,
+	event any,
+	handle BatchHandlerFunc,
+	cfg config.Batch,
+) {
+	eventName := processorConfig.Marshaler.Name(event)
+
+	topic, err := processorConfig.GenerateSubscribeTopic(cqrs.EventProcessorGenerateSubscribeTopicParams{
+		EventName: eventName,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	batcher := NewBatcher(handle, cfg)
+
+	for n := 1; n <= cfg.MaxSize; n++ {
+		subscriber, err := processorConfig.SubscriberConstructor(cqrs.EventProcessorSubscriberConstructorParams{
+			HandlerName: handlerName,
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		name := handlerName
+		if n > 1 {
+			name = fmt.Sprintf("%s-%d", handlerName, n)
+		}
+
+		router.AddNoPublisherHandler(
+			name,
+			topic,
+			subscriber,
+			batcher.Handle,
+		)
+	}
+}
@@ -0,0 +1,92 @@
+package message_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"tickets/config"
+	"tickets/message"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	watermillMessage "github.com/ThreeDotsLabs/watermill/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatcher(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	var lock sync.Mutex
+	var batches [][]string
+
+	batcher := message.NewBatcher(func(ctx context.Context, messages []*watermillMessage.Message) []error {
+		lock.Lock()
+		defer lock.Unlock()
+
+		var uuids []string
+		errs := make([]error, len(messages))
+		for i, msg := range messages {
+			uuids = append(uuids, msg.UUID)
+			if msg.UUID == "fail" {
+				errs[i] = errFailed
+			}
+		}
+		batches = append(batches, uuids)
+
+		return errs
+	}, config.Batch{MaxSize: 3, MaxWait: time.Second * 5})
+
+	uuids := []string{"ok-1", "fail", "ok-2"}
+	errs := make([]error, len(uuids))
+
+	var wg sync.WaitGroup
+	for i, uuid := range uuids {
+		wg.Add(1)
+		go func(i int, uuid string) {
+			defer wg.Done()
+			errs[i] = batcher.Handle(watermillMessage.NewMessage(uuid, nil))
+		}(i, uuid)
+	}
+	wg.Wait()
+
+	require.Len(t, batches, 1, "messages should be handled in one batch")
+	assert.ElementsMatch(t, uuids, batches[0])
+
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], errFailed)
+	assert.NoError(t, errs[2])
+}
+
+func TestBatcher_flushes_after_max_wait(t *testing.T) {
+	batcher := message.NewBatcher(func(ctx context.Context, messages []*watermillMessage.Message) []error {
+		return nil
+	}, config.Batch{MaxSize: 10, MaxWait: time.Millisecond * 50})
+
+	start := time.Now()
+	require.NoError(t, batcher.Handle(watermillMessage.NewMessage(watermill.NewUUID(), nil)))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestBatcher_nacks_batch_when_handler_panics(t *testing.T) {
+	batcher := message.NewBatcher(func(ctx context.Context, messages []*watermillMessage.Message) []error {
+		panic("spreadsheets client panicked")
+	}, config.Batch{MaxSize: 2, MaxWait: time.Second * 5})
+
+	errs := make([]error, 2)
+
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = batcher.Handle(watermillMessage.NewMessage(watermill.NewUUID(), nil))
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.ErrorContains(t, err, "spreadsheets client panicked")
+	}
+}
@@ -0,0 +1,26 @@
+package command
+
+import (
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// TopicPrefix separates topics of commands from topics of events, which are named after the events.
+const TopicPrefix = "commands."
+
+func NewBus(pub message.Publisher, marshaler cqrs.CommandEventMarshaler) *cqrs.CommandBus {
+	commandBus, err := cqrs.NewCommandBusWithConfig(
+		pub,
+		cqrs.CommandBusConfig{
+			GeneratePublishTopic: func(params cqrs.CommandBusGeneratePublishTopicParams) (string, error) {
+				return TopicPrefix + params.CommandName, nil
+			},
+			Marshaler: marshaler,
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return commandBus
+}
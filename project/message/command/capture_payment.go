@@ -0,0 +1,61 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"tickets/entities"
+	"tickets/tenant"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+)
+
+// StepCapturePayment is the step of BookingStepFailed published when the payment can't be captured.
+const StepCapturePayment = "capture_payment"
+
+// CapturePayment reports a declined payment, or a payments service which can't capture payments, with
+// BookingStepFailed, so the booking is compensated right away. Other errors are retried.
+func (h Handler) CapturePayment(ctx context.Context, command *entities.CapturePayment) error {
+	log.FromContext(ctx).WithField("booking_id", command.BookingID).Info("Capturing payment")
+
+	tenantID := tenant.FromContext(ctx)
+
+	paymentReference, err := h.payments.CapturePayment(ctx, entities.PaymentCapture{
+		BookingID:      command.BookingID,
+		CustomerEmail:  command.CustomerEmail,
+		Amount:         command.Amount,
+		IdempotencyKey: command.BookingID,
+	})
+	if errors.Is(err, entities.ErrPaymentDeclined) || errors.Is(err, entities.ErrCaptureNotSupported) {
+		return h.eventBus.Publish(ctx, entities.BookingStepFailed{
+			Header:    entities.NewEventHeader(tenantID),
+			BookingID: command.BookingID,
+			Step:      StepCapturePayment,
+			Reason:    err.Error(),
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to capture payment: %w", err)
+	}
+
+	return h.eventBus.Publish(ctx, entities.PaymentCaptured{
+		Header:           entities.NewEventHeader(tenantID),
+		BookingID:        command.BookingID,
+		PaymentReference: paymentReference,
+	})
+}
+
+func (h Handler) RefundPayment(ctx context.Context, command *entities.RefundPayment) error {
+	log.FromContext(ctx).WithField("booking_id", command.BookingID).Info("Refunding payment")
+
+	err := h.payments.RefundPayment(ctx, entities.PaymentRefund{
+		PaymentReference: command.PaymentReference,
+		RefundReason:     command.Reason,
+		IdempotencyKey:   command.BookingID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to refund payment: %w", err)
+	}
+
+	return nil
+}
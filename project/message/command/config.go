@@ -0,0 +1,27 @@
+package command
+
+import (
+	"tickets/message/transport"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+func NewProcessorConfig(
+	commandsTransport transport.Transport,
+	marshaler cqrs.CommandEventMarshaler,
+	consumerGroupPrefix string,
+	watermillLogger watermill.LoggerAdapter,
+) cqrs.CommandProcessorConfig {
+	return cqrs.CommandProcessorConfig{
+		GenerateSubscribeTopic: func(params cqrs.CommandProcessorGenerateSubscribeTopicParams) (string, error) {
+			return TopicPrefix + params.CommandName, nil
+		},
+		SubscriberConstructor: func(params cqrs.CommandProcessorSubscriberConstructorParams) (message.Subscriber, error) {
+			return commandsTransport.Subscriber(consumerGroupPrefix + params.HandlerName)
+		},
+		Marshaler: marshaler,
+		Logger:    watermillLogger,
+	}
+}
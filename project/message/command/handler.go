@@ -0,0 +1,62 @@
+package command
+
+import (
+	"context"
+	"tickets/entities"
+)
+
+// Handler runs steps of the booking saga, and reports their results with events.
+type Handler struct {
+	eventBus EventBus
+
+	tickets  TicketsRepository
+	payments PaymentsService
+	receipts ReceiptsService
+}
+
+func NewHandler(
+	eventBus EventBus,
+	tickets TicketsRepository,
+	payments PaymentsService,
+	receipts ReceiptsService,
+) Handler {
+	if eventBus == nil {
+		panic("missing eventBus")
+	}
+	if tickets == nil {
+		panic("missing tickets")
+	}
+	if payments == nil {
+		panic("missing payments")
+	}
+	if receipts == nil {
+		panic("missing receipts")
+	}
+
+	return Handler{
+		eventBus: eventBus,
+		tickets:  tickets,
+		payments: payments,
+		receipts: receipts,
+	}
+}
+
+type EventBus interface {
+	Publish(ctx context.Context, event any) error
+}
+
+type TicketsRepository interface {
+	ReserveTickets(ctx context.Context, tenantID string, bookingID string, tickets []entities.Ticket) error
+	ReleaseTickets(ctx context.Context, bookingID string) error
+}
+
+type PaymentsService interface {
+	// CapturePayment returns the payment reference, entities.ErrPaymentDeclined or entities.ErrCaptureNotSupported.
+	CapturePayment(ctx context.Context, request entities.PaymentCapture) (string, error)
+	RefundPayment(ctx context.Context, request entities.PaymentRefund) error
+}
+
+type ReceiptsService interface {
+	IssueReceipt(ctx context.Context, request entities.IssueReceiptRequest) (entities.IssueReceiptResponse, error)
+	VoidReceipt(ctx context.Context, request entities.VoidReceipt) error
+}
@@ -0,0 +1,47 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"tickets/entities"
+	"tickets/tenant"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+)
+
+func (h Handler) IssueReceipts(ctx context.Context, command *entities.IssueReceipts) error {
+	log.FromContext(ctx).WithField("booking_id", command.BookingID).Info("Issuing receipts")
+
+	// receipts are issued once per ticket, so receipts issued before a retry are not issued again
+	for _, ticketID := range command.TicketIDs {
+		_, err := h.receipts.IssueReceipt(ctx, entities.IssueReceiptRequest{
+			TicketID: ticketID,
+			Price:    command.TicketPrice,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to issue receipt: %w", err)
+		}
+	}
+
+	return h.eventBus.Publish(ctx, entities.ReceiptsIssued{
+		Header:    entities.NewEventHeader(tenant.FromContext(ctx)),
+		BookingID: command.BookingID,
+	})
+}
+
+func (h Handler) VoidReceipts(ctx context.Context, command *entities.VoidReceipts) error {
+	log.FromContext(ctx).WithField("booking_id", command.BookingID).Info("Voiding receipts")
+
+	for _, ticketID := range command.TicketIDs {
+		err := h.receipts.VoidReceipt(ctx, entities.VoidReceipt{
+			TicketID:       ticketID,
+			Reason:         command.Reason,
+			IdempotencyKey: command.BookingID + "-" + ticketID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to void receipt: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package command
+
+import (
+	"context"
+	"tickets/entities"
+	"tickets/tenant"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+)
+
+func (h Handler) ReserveTickets(ctx context.Context, command *entities.ReserveTickets) error {
+	log.FromContext(ctx).WithField("booking_id", command.BookingID).Info("Reserving tickets")
+
+	tickets := make([]entities.Ticket, 0, len(command.TicketIDs))
+	for _, ticketID := range command.TicketIDs {
+		tickets = append(tickets, entities.Ticket{
+			TicketID:      ticketID,
+			Price:         command.TicketPrice,
+			CustomerEmail: command.CustomerEmail,
+		})
+	}
+
+	tenantID := tenant.FromContext(ctx)
+
+	if err := h.tickets.ReserveTickets(ctx, tenantID, command.BookingID, tickets); err != nil {
+		return err
+	}
+
+	return h.eventBus.Publish(ctx, entities.TicketsReserved{
+		Header:    entities.NewEventHeader(tenantID),
+		BookingID: command.BookingID,
+	})
+}
+
+func (h Handler) ReleaseTickets(ctx context.Context, command *entities.ReleaseTickets) error {
+	log.FromContext(ctx).WithField("booking_id", command.BookingID).Info("Releasing tickets")
+
+	return h.tickets.ReleaseTickets(ctx, command.BookingID)
+}
@@ -0,0 +1,130 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+var errShuttingDown = errors.New("service is shutting down, message will be redelivered")
+
+type InFlightMessage struct {
+	MessageUUID string
+	HandlerName string
+	StartedAt   time.Time
+}
+
+// Drainer tracks messages being handled, so the service can stop taking new messages
+// and give the in-flight ones time to finish before the router is closed.
+//
+// Handlers get a context detached from the router's cancellation; it is cancelled only
+// when draining times out.
+type Drainer struct {
+	lock     sync.Mutex
+	draining bool
+	inFlight map[*message.Message]InFlightMessage
+	empty    chan struct{}
+
+	stopped  chan struct{}
+	hardStop context.Context
+	abort    context.CancelFunc
+}
+
+func NewDrainer() *Drainer {
+	hardStop, abort := context.WithCancel(context.Background())
+
+	return &Drainer{
+		inFlight: make(map[*message.Message]InFlightMessage),
+		empty:    make(chan struct{}),
+		stopped:  make(chan struct{}),
+		hardStop: hardStop,
+		abort:    abort,
+	}
+}
+
+func (d *Drainer) Middleware(h message.HandlerFunc) message.HandlerFunc {
+	return func(msg *message.Message) ([]*message.Message, error) {
+		if !d.admit(msg) {
+			// holding the message until the router is closed, so it's not redelivered in a loop meanwhile
+			<-d.stopped
+			return nil, errShuttingDown
+		}
+		defer d.release(msg)
+
+		ctx, cancel := context.WithCancel(context.WithoutCancel(msg.Context()))
+		defer cancel()
+
+		stop := context.AfterFunc(d.hardStop, cancel)
+		defer stop()
+
+		msg.SetContext(ctx)
+
+		return h(msg)
+	}
+}
+
+// Drain stops admitting new messages and waits up to timeout for in-flight messages to finish.
+// Messages still running after the timeout have their context cancelled and are returned as abandoned.
+func (d *Drainer) Drain(timeout time.Duration) []InFlightMessage {
+	defer close(d.stopped)
+
+	d.lock.Lock()
+	d.draining = true
+	if len(d.inFlight) == 0 {
+		close(d.empty)
+	}
+	d.lock.Unlock()
+
+	select {
+	case <-d.empty:
+		return nil
+	case <-time.After(timeout):
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	abandoned := make([]InFlightMessage, 0, len(d.inFlight))
+	for _, m := range d.inFlight {
+		abandoned = append(abandoned, m)
+	}
+
+	d.abort()
+
+	return abandoned
+}
+
+func (d *Drainer) admit(msg *message.Message) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.draining {
+		return false
+	}
+
+	d.inFlight[msg] = InFlightMessage{
+		MessageUUID: msg.UUID,
+		HandlerName: message.HandlerNameFromCtx(msg.Context()),
+		StartedAt:   time.Now(),
+	}
+
+	return true
+}
+
+func (d *Drainer) release(msg *message.Message) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.inFlight, msg)
+
+	if d.draining && len(d.inFlight) == 0 {
+		select {
+		case <-d.empty:
+		default:
+			close(d.empty)
+		}
+	}
+}
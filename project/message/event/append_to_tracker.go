@@ -3,16 +3,34 @@ package event
 import (
 	"context"
 	"tickets/entities"
+	"tickets/sheets"
+	"tickets/tenant"
 
 	"github.com/ThreeDotsLabs/go-event-driven/common/log"
 )
 
-func (h Handler) AppendToTracker(ctx context.Context, event *entities.TicketBookingConfirmed) error {
-	log.FromContext(ctx).Info("Generating ticket for booking")
+func (h Handler) AppendToTracker(ctx context.Context, events []Batched[entities.TicketBookingConfirmed]) []error {
+	log.FromContext(ctx).WithField("tickets", len(events)).Info("Generating tickets for bookings")
 
-	return h.spreadsheetsService.AppendRow(
-		ctx,
-		"tickets-to-print",
-		[]string{event.TicketID, event.CustomerEmail, event.Price.Amount, event.Price.Currency},
-	)
+	rows := make([]sheetRow, 0, len(events))
+	for _, e := range events {
+		sheet := h.tenants.Spreadsheets(tenant.FromContext(e.Ctx), h.sheets).TicketsToPrint
+
+		// the row is built once the layout of the sheet is known
+		event := e.Event
+		rows = append(rows, sheetRow{
+			sheet:  sheet,
+			header: sheets.TicketsToPrint.Header(),
+			values: func(layout sheets.Layout) []string {
+				return sheets.TicketsToPrint.Row(layout, sheets.TicketToPrint{
+					TicketID:      event.TicketID,
+					CustomerEmail: event.CustomerEmail,
+					Price:         event.Price,
+					ConfirmedAt:   event.Header.PublishedAt,
+				})
+			},
+		})
+	}
+
+	return h.appendRows(ctx, rows)
 }
@@ -0,0 +1,65 @@
+package event
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// Batched is an event of a batch, with the context of its message.
+type Batched[T any] struct {
+	Ctx   context.Context
+	Event *T
+}
+
+// BatchHandler unmarshals messages of a batch for handle, which returns an error for each event, or nil when
+// all of them were handled. Like with cqrs handlers, messages of other events are acknowledged without handling.
+func BatchHandler[T any](
+	marshaler cqrs.CommandEventMarshaler,
+	handle func(ctx context.Context, events []Batched[T]) []error,
+) func(ctx context.Context, messages []*message.Message) []error {
+	return func(ctx context.Context, messages []*message.Message) []error {
+		errs := make([]error, len(messages))
+
+		expectedName := marshaler.Name(new(T))
+
+		var events []Batched[T]
+		// indexes of messages of the events
+		var indexes []int
+		for i, msg := range messages {
+			if marshaler.NameFromMessage(msg) != expectedName {
+				continue
+			}
+
+			event := new(T)
+			if err := marshaler.Unmarshal(msg, event); err != nil {
+				errs[i] = fmt.Errorf("failed to unmarshal %s: %w", expectedName, err)
+				continue
+			}
+
+			events = append(events, Batched[T]{Ctx: msg.Context(), Event: event})
+			indexes = append(indexes, i)
+		}
+
+		if len(events) == 0 {
+			return errs
+		}
+
+		eventErrs := handle(ctx, events)
+		if eventErrs == nil {
+			return errs
+		}
+
+		for j, i := range indexes {
+			if len(eventErrs) != len(events) {
+				errs[i] = fmt.Errorf("batch handler returned %d errors for %d events", len(eventErrs), len(events))
+				continue
+			}
+			errs[i] = eventErrs[j]
+		}
+
+		return errs
+	}
+}
@@ -1,16 +1,20 @@
 package event
 
 import (
+	"tickets/config"
+	"tickets/tenant"
+
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/ThreeDotsLabs/watermill/message"
 )
 
-func NewBus(pub message.Publisher) *cqrs.EventBus {
+func NewBus(pub message.Publisher, marshaler cqrs.CommandEventMarshaler, tenants config.Tenants) *cqrs.EventBus {
 	eventBus, err := cqrs.NewEventBusWithConfig(
 		pub,
 		cqrs.EventBusConfig{
 			GeneratePublishTopic: func(params cqrs.GenerateEventPublishTopicParams) (string, error) {
-				return params.EventName, nil
+				tenantID := eventHeader(params.Event).TenantID
+				return tenant.Topic(params.EventName, tenants.DedicatedTopic(tenantID)), nil
 			},
 			Marshaler: marshaler,
 		},
@@ -0,0 +1,29 @@
+package event
+
+import (
+	"context"
+	"sync"
+)
+
+// BusMock records published events instead of publishing them.
+type BusMock struct {
+	lock      sync.Mutex
+	published []any
+}
+
+func (b *BusMock) Publish(ctx context.Context, event any) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.published = append(b.published, event)
+
+	return nil
+}
+
+// Published returns a copy of the published events, safe to use while the mock is being called.
+func (b *BusMock) Published() []any {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return append([]any(nil), b.published...)
+}
@@ -0,0 +1,200 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"tickets/entities"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// CloudEvents content modes (https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/spec.md#message).
+const (
+	// CloudEventsBinary keeps the event as the payload and puts CloudEvents attributes in ce_ prefixed metadata.
+	CloudEventsBinary = "binary"
+	// CloudEventsStructured puts the attributes and the event in a JSON envelope.
+	CloudEventsStructured = "structured"
+
+	ContentTypeCloudEventsJSON = "application/cloudevents+json"
+
+	cloudEventsSpecVersion    = "1.0"
+	cloudEventsMetadataPrefix = "ce_"
+)
+
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            *time.Time      `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      []byte          `json:"data_base64,omitempty"`
+	// TenantID is the tenantid extension attribute.
+	TenantID string `json:"tenantid,omitempty"`
+}
+
+// toCloudEvent converts a marshaled event to the CloudEvents content mode.
+func (m Marshaler) toCloudEvent(msg *message.Message, name string, v any) error {
+	header := eventHeader(v)
+
+	id := header.ID
+	if id == "" {
+		id = msg.UUID
+	}
+
+	contentType := msg.Metadata.Get(ContentTypeMetadataKey)
+
+	switch m.cloudEventsMode {
+	case CloudEventsBinary:
+		msg.Metadata.Set(cloudEventsMetadataPrefix+"specversion", cloudEventsSpecVersion)
+		msg.Metadata.Set(cloudEventsMetadataPrefix+"id", id)
+		msg.Metadata.Set(cloudEventsMetadataPrefix+"source", m.source)
+		msg.Metadata.Set(cloudEventsMetadataPrefix+"type", name)
+		if !header.PublishedAt.IsZero() {
+			msg.Metadata.Set(cloudEventsMetadataPrefix+"time", header.PublishedAt.Format(time.RFC3339Nano))
+		}
+		if header.TenantID != "" {
+			msg.Metadata.Set(cloudEventsMetadataPrefix+"tenantid", header.TenantID)
+		}
+	case CloudEventsStructured:
+		envelope := cloudEvent{
+			SpecVersion:     cloudEventsSpecVersion,
+			ID:              id,
+			Source:          m.source,
+			Type:            name,
+			DataContentType: contentType,
+			TenantID:        header.TenantID,
+		}
+		if !header.PublishedAt.IsZero() {
+			envelope.Time = &header.PublishedAt
+		}
+		if contentType == ContentTypeJSON {
+			envelope.Data = json.RawMessage(msg.Payload)
+		} else {
+			envelope.DataBase64 = msg.Payload
+		}
+
+		payload, err := json.Marshal(envelope)
+		if err != nil {
+			return fmt.Errorf("failed to marshal CloudEvent: %w", err)
+		}
+
+		msg.Payload = payload
+		msg.Metadata.Set(ContentTypeMetadataKey, ContentTypeCloudEventsJSON)
+	default:
+		return fmt.Errorf("unknown CloudEvents mode %s", m.cloudEventsMode)
+	}
+
+	return nil
+}
+
+// unmarshalStructuredCloudEvent decodes the data of a structured CloudEvent into v.
+// Header fields missing in the data are taken from the CloudEvent attributes.
+func unmarshalStructuredCloudEvent(payload []byte, v any) error {
+	var envelope cloudEvent
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return fmt.Errorf("failed to unmarshal CloudEvent: %w", err)
+	}
+
+	data := []byte(envelope.Data)
+	if envelope.DataBase64 != nil {
+		data = envelope.DataBase64
+	}
+
+	if err := unmarshalPayload(envelope.DataContentType, data, v); err != nil {
+		return err
+	}
+
+	fallback := entities.EventHeader{ID: envelope.ID, TenantID: envelope.TenantID}
+	if envelope.Time != nil {
+		fallback.PublishedAt = *envelope.Time
+	}
+	fillEventHeader(v, fallback)
+
+	return nil
+}
+
+// fillBinaryCloudEventHeader fills header fields missing in v with attributes of a binary CloudEvent.
+func fillBinaryCloudEventHeader(msg *message.Message, v any) {
+	id := msg.Metadata.Get(cloudEventsMetadataPrefix + "id")
+	if id == "" {
+		return
+	}
+
+	publishedAt, _ := time.Parse(time.RFC3339Nano, msg.Metadata.Get(cloudEventsMetadataPrefix+"time"))
+
+	fillEventHeader(v, entities.EventHeader{
+		ID:          id,
+		PublishedAt: publishedAt,
+		TenantID:    msg.Metadata.Get(cloudEventsMetadataPrefix + "tenantid"),
+	})
+}
+
+// cloudEventType returns the type of a CloudEvent published by another service, or "" if msg isn't one.
+func cloudEventType(msg *message.Message) string {
+	eventType := msg.Metadata.Get(cloudEventsMetadataPrefix + "type")
+
+	if eventType == "" && msg.Metadata.Get(ContentTypeMetadataKey) == ContentTypeCloudEventsJSON {
+		var envelope struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(msg.Payload, &envelope); err == nil {
+			eventType = envelope.Type
+		}
+	}
+
+	// other services may use reverse-DNS types, like com.example.TicketBookingConfirmed
+	if i := strings.LastIndex(eventType, "."); i >= 0 {
+		return eventType[i+1:]
+	}
+
+	return eventType
+}
+
+func eventHeader(v any) entities.EventHeader {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return entities.EventHeader{}
+	}
+
+	field := value.FieldByName("Header")
+	if !field.IsValid() {
+		return entities.EventHeader{}
+	}
+
+	header, _ := field.Interface().(entities.EventHeader)
+
+	return header
+}
+
+// fillEventHeader sets header fields of v which are empty to the fallback values.
+func fillEventHeader(v any, fallback entities.EventHeader) {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return
+	}
+
+	field := value.Elem().FieldByName("Header")
+	if !field.IsValid() {
+		return
+	}
+
+	header, ok := field.Addr().Interface().(*entities.EventHeader)
+	if !ok {
+		return
+	}
+
+	if header.ID == "" {
+		header.ID = fallback.ID
+	}
+	if header.PublishedAt.IsZero() {
+		header.PublishedAt = fallback.PublishedAt
+	}
+	if header.TenantID == "" {
+		header.TenantID = fallback.TenantID
+	}
+}
@@ -1,27 +1,39 @@
 package event
 
 import (
+	"tickets/message/transport"
+	"tickets/tenant"
+
 	"github.com/ThreeDotsLabs/watermill"
-	"github.com/ThreeDotsLabs/watermill-redisstream/pkg/redisstream"
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/ThreeDotsLabs/watermill/message"
-	"github.com/redis/go-redis/v9"
 )
 
-var marshaler = cqrs.JSONMarshaler{
-	GenerateName: cqrs.StructName,
+// DefaultMarshaler returns a marshaler encoding all events as plain JSON. Like any Marshaler, it decodes all formats.
+func DefaultMarshaler() Marshaler {
+	m, err := NewMarshaler(nil, "", "")
+	if err != nil {
+		panic(err)
+	}
+
+	return m
 }
 
-func NewProcessorConfig(redisClient *redis.Client, watermillLogger watermill.LoggerAdapter) cqrs.EventProcessorConfig {
+// NewProcessorConfig returns config of a processor consuming the shared topics, or with dedicatedTenantID set,
+// the dedicated topics of the tenant.
+func NewProcessorConfig(
+	eventsTransport transport.Transport,
+	marshaler cqrs.CommandEventMarshaler,
+	consumerGroupPrefix string,
+	dedicatedTenantID string,
+	watermillLogger watermill.LoggerAdapter,
+) cqrs.EventProcessorConfig {
 	return cqrs.EventProcessorConfig{
 		GenerateSubscribeTopic: func(params cqrs.EventProcessorGenerateSubscribeTopicParams) (string, error) {
-			return params.EventName, nil
+			return tenant.Topic(params.EventName, dedicatedTenantID), nil
 		},
 		SubscriberConstructor: func(params cqrs.EventProcessorSubscriberConstructorParams) (message.Subscriber, error) {
-			return redisstream.NewSubscriber(redisstream.SubscriberConfig{
-				Client:        redisClient,
-				ConsumerGroup: "svc-tickets." + params.HandlerName,
-			}, watermillLogger)
+			return eventsTransport.Subscriber(consumerGroupPrefix + params.HandlerName)
 		},
 		Marshaler: marshaler,
 		Logger:    watermillLogger,
@@ -0,0 +1,18 @@
+package event
+
+import (
+	"context"
+	"tickets/entities"
+)
+
+func (h Handler) DeliverTicketBookingConfirmedWebhooks(ctx context.Context, event *entities.TicketBookingConfirmed) error {
+	return h.webhooks.Deliver(ctx, "TicketBookingConfirmed", event.Header.ID, event)
+}
+
+func (h Handler) DeliverTicketBookingCanceledWebhooks(ctx context.Context, event *entities.TicketBookingCanceled) error {
+	return h.webhooks.Deliver(ctx, "TicketBookingCanceled", event.Header.ID, event)
+}
+
+func (h Handler) DeliverTicketRefundedWebhooks(ctx context.Context, event *entities.TicketRefunded) error {
+	return h.webhooks.Deliver(ctx, "TicketRefunded", event.Header.ID, event)
+}
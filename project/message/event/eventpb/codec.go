@@ -0,0 +1,351 @@
+// Package eventpb encodes events with the protobuf schema from events.proto.
+//
+// There is no generated code: events are encoded straight from the entities, so field numbers
+// below must match events.proto. Unknown fields are skipped, as required by protobuf.
+package eventpb
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"tickets/entities"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type codec struct {
+	marshal   func(v any) []byte
+	unmarshal func(data []byte, v any) error
+}
+
+var codecs = map[reflect.Type]codec{}
+
+func register[T any](marshal func(e *T) []byte, unmarshal func(f field, e *T) error) {
+	codecs[reflect.TypeOf((*T)(nil)).Elem()] = codec{
+		marshal: func(v any) []byte {
+			return marshal(v.(*T))
+		},
+		unmarshal: func(data []byte, v any) error {
+			return decode(data, func(f field) error {
+				return unmarshal(f, v.(*T))
+			})
+		},
+	}
+}
+
+func init() {
+	register(marshalTicketBookingConfirmed, unmarshalTicketBookingConfirmed)
+	register(marshalTicketBookingCanceled, unmarshalTicketBookingCanceled)
+	register(marshalTicketRefunded, unmarshalTicketRefunded)
+	register(marshalBookingMade, unmarshalBookingMade)
+}
+
+// Events returns zero values of all events with a protobuf schema.
+func Events() []any {
+	var events []any
+	for t := range codecs {
+		events = append(events, reflect.New(t).Elem().Interface())
+	}
+
+	return events
+}
+
+func Marshal(v any) ([]byte, error) {
+	c, ok := codecs[structType(v)]
+	if !ok {
+		return nil, fmt.Errorf("no protobuf schema for %T", v)
+	}
+
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer {
+		ptr := reflect.New(value.Type())
+		ptr.Elem().Set(value)
+		value = ptr
+	}
+
+	return c.marshal(value.Interface()), nil
+}
+
+// Unmarshal decodes data into v, which must be a pointer to an event.
+func Unmarshal(data []byte, v any) error {
+	if reflect.ValueOf(v).Kind() != reflect.Pointer {
+		return fmt.Errorf("cannot unmarshal into non-pointer %T", v)
+	}
+
+	c, ok := codecs[structType(v)]
+	if !ok {
+		return fmt.Errorf("no protobuf schema for %T", v)
+	}
+
+	return c.unmarshal(data, v)
+}
+
+func structType(v any) reflect.Type {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+
+	return t
+}
+
+func marshalTicketBookingConfirmed(e *entities.TicketBookingConfirmed) []byte {
+	var b []byte
+	b = appendMessage(b, 1, marshalHeader(e.Header))
+	b = appendString(b, 2, e.TicketID)
+	b = appendString(b, 3, e.CustomerEmail)
+	b = appendMessage(b, 4, marshalMoney(e.Price))
+	b = appendString(b, 5, e.BookingID)
+	return b
+}
+
+func unmarshalTicketBookingConfirmed(f field, e *entities.TicketBookingConfirmed) error {
+	switch f.num {
+	case 1:
+		return f.message(func(f field) error { return unmarshalHeader(f, &e.Header) })
+	case 2:
+		e.TicketID = f.string()
+	case 3:
+		e.CustomerEmail = f.string()
+	case 4:
+		return f.message(func(f field) error { return unmarshalMoney(f, &e.Price) })
+	case 5:
+		e.BookingID = f.string()
+	}
+	return nil
+}
+
+func marshalTicketBookingCanceled(e *entities.TicketBookingCanceled) []byte {
+	var b []byte
+	b = appendMessage(b, 1, marshalHeader(e.Header))
+	b = appendString(b, 2, e.TicketID)
+	b = appendString(b, 3, e.CustomerEmail)
+	b = appendMessage(b, 4, marshalMoney(e.Price))
+	return b
+}
+
+func unmarshalTicketBookingCanceled(f field, e *entities.TicketBookingCanceled) error {
+	switch f.num {
+	case 1:
+		return f.message(func(f field) error { return unmarshalHeader(f, &e.Header) })
+	case 2:
+		e.TicketID = f.string()
+	case 3:
+		e.CustomerEmail = f.string()
+	case 4:
+		return f.message(func(f field) error { return unmarshalMoney(f, &e.Price) })
+	}
+	return nil
+}
+
+func marshalTicketRefunded(e *entities.TicketRefunded) []byte {
+	var b []byte
+	b = appendMessage(b, 1, marshalHeader(e.Header))
+	b = appendString(b, 2, e.TicketID)
+	return b
+}
+
+func unmarshalTicketRefunded(f field, e *entities.TicketRefunded) error {
+	switch f.num {
+	case 1:
+		return f.message(func(f field) error { return unmarshalHeader(f, &e.Header) })
+	case 2:
+		e.TicketID = f.string()
+	}
+	return nil
+}
+
+func marshalBookingMade(e *entities.BookingMade) []byte {
+	var b []byte
+	b = appendMessage(b, 1, marshalHeader(e.Header))
+	b = appendInt64(b, 2, int64(e.NumberOfTickets))
+	b = appendString(b, 3, uuidString(e.BookingID))
+	b = appendString(b, 4, e.CustomerEmail)
+	b = appendString(b, 5, uuidString(e.ShowId))
+	b = appendMessage(b, 6, marshalMoney(e.TicketPrice))
+	return b
+}
+
+func unmarshalBookingMade(f field, e *entities.BookingMade) error {
+	var err error
+
+	switch f.num {
+	case 1:
+		return f.message(func(f field) error { return unmarshalHeader(f, &e.Header) })
+	case 2:
+		e.NumberOfTickets = int(f.int64())
+	case 3:
+		e.BookingID, err = parseUUID(f.string())
+	case 4:
+		e.CustomerEmail = f.string()
+	case 5:
+		e.ShowId, err = parseUUID(f.string())
+	case 6:
+		return f.message(func(f field) error { return unmarshalMoney(f, &e.TicketPrice) })
+	}
+	return err
+}
+
+func marshalHeader(h entities.EventHeader) []byte {
+	var b []byte
+	b = appendString(b, 1, h.ID)
+	if !h.PublishedAt.IsZero() {
+		b = appendMessage(b, 2, marshalTimestamp(h.PublishedAt))
+	}
+	b = appendString(b, 3, h.TenantID)
+	return b
+}
+
+func unmarshalHeader(f field, h *entities.EventHeader) error {
+	switch f.num {
+	case 1:
+		h.ID = f.string()
+	case 2:
+		var seconds, nanos int64
+		err := f.message(func(f field) error {
+			switch f.num {
+			case 1:
+				seconds = f.int64()
+			case 2:
+				nanos = f.int64()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		h.PublishedAt = time.Unix(seconds, nanos).UTC()
+	case 3:
+		h.TenantID = f.string()
+	}
+	return nil
+}
+
+// marshalTimestamp encodes google.protobuf.Timestamp.
+func marshalTimestamp(t time.Time) []byte {
+	var b []byte
+	b = appendInt64(b, 1, t.Unix())
+	b = appendInt64(b, 2, int64(t.Nanosecond()))
+	return b
+}
+
+func marshalMoney(m entities.Money) []byte {
+	var b []byte
+	b = appendString(b, 1, m.Amount)
+	b = appendString(b, 2, m.Currency)
+	return b
+}
+
+func unmarshalMoney(f field, m *entities.Money) error {
+	switch f.num {
+	case 1:
+		m.Amount = f.string()
+	case 2:
+		m.Currency = f.string()
+	}
+	return nil
+}
+
+func uuidString(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
+	}
+
+	return id.String()
+}
+
+func parseUUID(s string) (uuid.UUID, error) {
+	if s == "" {
+		return uuid.Nil, nil
+	}
+
+	return uuid.Parse(s)
+}
+
+// Scalar fields with zero values are omitted, as in proto3.
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendInt64(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func appendMessage(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+type field struct {
+	num    protowire.Number
+	typ    protowire.Type
+	varint uint64
+	bytes  []byte
+}
+
+func (f field) string() string {
+	return string(f.bytes)
+}
+
+func (f field) int64() int64 {
+	return int64(f.varint)
+}
+
+func (f field) message(fn func(field) error) error {
+	if f.typ != protowire.BytesType {
+		return fmt.Errorf("field %d: expected embedded message", f.num)
+	}
+
+	return decode(f.bytes, fn)
+}
+
+var errMalformed = errors.New("malformed protobuf")
+
+func decode(b []byte, fn func(field) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("%w: %w", errMalformed, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		f := field{num: num, typ: typ}
+
+		switch typ {
+		case protowire.VarintType:
+			f.varint, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return fmt.Errorf("%w: field %d: %w", errMalformed, num, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		if typ != protowire.VarintType && typ != protowire.BytesType {
+			// no fields of other wire types in the schema
+			continue
+		}
+
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
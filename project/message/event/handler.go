@@ -2,17 +2,28 @@ package event
 
 import (
 	"context"
+	"tickets/config"
 	"tickets/entities"
+	"tickets/sheets"
 )
 
 type Handler struct {
 	spreadsheetsService SpreadsheetsAPI
 	receiptsService     ReceiptsService
+	webhooks            WebhooksDeliverer
+	sheetLayouts        *sheets.Layouts
+
+	sheets  config.Spreadsheets
+	tenants config.Tenants
 }
 
 func NewHandler(
 	spreadsheetsService SpreadsheetsAPI,
 	receiptsService ReceiptsService,
+	webhooks WebhooksDeliverer,
+	sheetLayouts *sheets.Layouts,
+	sheets config.Spreadsheets,
+	tenants config.Tenants,
 ) Handler {
 	if spreadsheetsService == nil {
 		panic("missing spreadsheetsService")
@@ -20,15 +31,30 @@ func NewHandler(
 	if receiptsService == nil {
 		panic("missing receiptsService")
 	}
+	if webhooks == nil {
+		panic("missing webhooks")
+	}
+	if sheetLayouts == nil {
+		panic("missing sheetLayouts")
+	}
 
 	return Handler{
 		spreadsheetsService: spreadsheetsService,
 		receiptsService:     receiptsService,
+		webhooks:            webhooks,
+		sheetLayouts:        sheetLayouts,
+
+		sheets:  sheets,
+		tenants: tenants,
 	}
 }
 
 type SpreadsheetsAPI interface {
-	AppendRow(ctx context.Context, sheetName string, row []string) error
+	AppendRows(ctx context.Context, sheetName string, rows [][]string) error
+}
+
+type WebhooksDeliverer interface {
+	Deliver(ctx context.Context, eventType string, eventID string, event any) error
 }
 
 type ReceiptsService interface {
@@ -0,0 +1,147 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"tickets/entities"
+	"tickets/message/event/eventpb"
+	"tickets/tenant"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+const (
+	ContentTypeMetadataKey = "content_type"
+
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/protobuf"
+)
+
+// Marshaler encodes events as JSON, or as protobuf for the chosen event names, optionally as CloudEvents.
+// It decodes by the message's content type, so consumers accept all formats while events are migrated.
+type Marshaler struct {
+	names          cqrs.JSONMarshaler
+	protobufEvents map[string]struct{}
+
+	cloudEventsMode string
+	source          string
+}
+
+// NewMarshaler returns a marshaler encoding protobufEvents with protobuf, and all other events with JSON.
+// With cloudEventsMode set to CloudEventsBinary or CloudEventsStructured, events are published as CloudEvents
+// from source.
+func NewMarshaler(protobufEvents []string, cloudEventsMode string, source string) (Marshaler, error) {
+	m := Marshaler{
+		names: cqrs.JSONMarshaler{
+			GenerateName: cqrs.StructName,
+		},
+		protobufEvents:  map[string]struct{}{},
+		cloudEventsMode: cloudEventsMode,
+		source:          source,
+	}
+
+	switch cloudEventsMode {
+	case "", CloudEventsBinary, CloudEventsStructured:
+	default:
+		return Marshaler{}, fmt.Errorf("unknown CloudEvents mode %s", cloudEventsMode)
+	}
+
+	supported := map[string]struct{}{}
+	for _, e := range eventpb.Events() {
+		supported[m.Name(e)] = struct{}{}
+	}
+
+	for _, name := range protobufEvents {
+		if _, ok := supported[name]; !ok {
+			return Marshaler{}, fmt.Errorf("event %s has no protobuf schema", name)
+		}
+		m.protobufEvents[name] = struct{}{}
+	}
+
+	return m, nil
+}
+
+func (m Marshaler) Marshal(v any) (*message.Message, error) {
+	name := m.Name(v)
+	contentType := ContentTypeJSON
+
+	var payload []byte
+	var err error
+
+	if _, ok := m.protobufEvents[name]; ok {
+		contentType = ContentTypeProtobuf
+		payload, err = eventpb.Marshal(v)
+	} else {
+		payload, err = json.Marshal(v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+
+	msg := message.NewMessage(watermill.NewUUID(), payload)
+	msg.Metadata.Set("name", name)
+	msg.Metadata.Set(ContentTypeMetadataKey, contentType)
+	if tenantID := eventHeader(v).TenantID; tenantID != "" {
+		msg.Metadata.Set(tenant.MetadataKey, tenantID)
+	}
+
+	if m.cloudEventsMode != "" {
+		if err := m.toCloudEvent(msg, name, v); err != nil {
+			return nil, err
+		}
+	}
+
+	return msg, nil
+}
+
+// Unmarshal decodes the message according to its content type. Messages without one are JSON,
+// as they were published before content types were set.
+func (m Marshaler) Unmarshal(msg *message.Message, v any) error {
+	contentType := msg.Metadata.Get(ContentTypeMetadataKey)
+
+	if contentType == ContentTypeCloudEventsJSON {
+		if err := unmarshalStructuredCloudEvent(msg.Payload, v); err != nil {
+			return err
+		}
+	} else {
+		if err := unmarshalPayload(contentType, msg.Payload, v); err != nil {
+			return err
+		}
+
+		fillBinaryCloudEventHeader(msg, v)
+	}
+
+	fillEventHeader(v, entities.EventHeader{TenantID: msg.Metadata.Get(tenant.MetadataKey)})
+
+	return nil
+}
+
+func unmarshalPayload(contentType string, payload []byte, v any) error {
+	// parameters, like charset, don't change decoding
+	mediaType, _, _ := strings.Cut(contentType, ";")
+
+	switch mediaType = strings.TrimSpace(mediaType); mediaType {
+	case ContentTypeProtobuf:
+		return eventpb.Unmarshal(payload, v)
+	case ContentTypeJSON, "":
+		return json.Unmarshal(payload, v)
+	default:
+		return fmt.Errorf("unsupported content type %s", contentType)
+	}
+}
+
+func (m Marshaler) Name(v any) string {
+	return m.names.Name(v)
+}
+
+// NameFromMessage returns the event name, falling back to the type of CloudEvents published by other services.
+func (m Marshaler) NameFromMessage(msg *message.Message) string {
+	if name := m.names.NameFromMessage(msg); name != "" {
+		return name
+	}
+
+	return cloudEventType(msg)
+}
@@ -0,0 +1,162 @@
+package event_test
+
+import (
+	"testing"
+	"tickets/entities"
+	"tickets/message/event"
+	"tickets/tenant"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshaler_protobuf_round_trip(t *testing.T) {
+	marshaler, err := event.NewMarshaler([]string{"TicketBookingConfirmed", "BookingMade"}, "", "")
+	require.NoError(t, err)
+
+	confirmed := entities.TicketBookingConfirmed{
+		Header:        entities.EventHeader{ID: uuid.NewString(), PublishedAt: time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC), TenantID: "reseller-a"},
+		TicketID:      uuid.NewString(),
+		CustomerEmail: "email@example.com",
+		Price:         entities.Money{Amount: "50.30", Currency: "GBP"},
+		BookingID:     uuid.NewString(),
+	}
+
+	msg, err := marshaler.Marshal(&confirmed)
+	require.NoError(t, err)
+	assert.Equal(t, event.ContentTypeProtobuf, msg.Metadata.Get(event.ContentTypeMetadataKey))
+	assert.Equal(t, "TicketBookingConfirmed", marshaler.NameFromMessage(msg))
+
+	var decodedConfirmed entities.TicketBookingConfirmed
+	require.NoError(t, event.DefaultMarshaler().Unmarshal(msg, &decodedConfirmed))
+	assert.Equal(t, confirmed, decodedConfirmed)
+
+	bookingMade := entities.BookingMade{
+		Header:          entities.NewEventHeader(tenant.DefaultID),
+		NumberOfTickets: 3,
+		BookingID:       uuid.New(),
+		CustomerEmail:   "email@example.com",
+		ShowId:          uuid.New(),
+		TicketPrice:     entities.Money{Amount: "25.00", Currency: "EUR"},
+	}
+
+	msg, err = marshaler.Marshal(bookingMade)
+	require.NoError(t, err)
+
+	var decodedBookingMade entities.BookingMade
+	require.NoError(t, marshaler.Unmarshal(msg, &decodedBookingMade))
+	assert.Equal(t, bookingMade, decodedBookingMade)
+}
+
+func TestMarshaler_json(t *testing.T) {
+	marshaler, err := event.NewMarshaler([]string{"TicketBookingConfirmed"}, "", "")
+	require.NoError(t, err)
+
+	refunded := entities.TicketRefunded{Header: entities.NewEventHeader(tenant.DefaultID), TicketID: uuid.NewString()}
+
+	msg, err := marshaler.Marshal(refunded)
+	require.NoError(t, err)
+	assert.Equal(t, event.ContentTypeJSON, msg.Metadata.Get(event.ContentTypeMetadataKey))
+
+	// messages published before content types were set
+	legacy := message.NewMessage(uuid.NewString(), msg.Payload)
+
+	var decoded entities.TicketRefunded
+	require.NoError(t, marshaler.Unmarshal(legacy, &decoded))
+	assert.Equal(t, refunded, decoded)
+}
+
+func TestMarshaler_tenant_metadata(t *testing.T) {
+	marshaler := event.DefaultMarshaler()
+
+	refunded := entities.TicketRefunded{Header: entities.NewEventHeader("reseller-a"), TicketID: uuid.NewString()}
+
+	msg, err := marshaler.Marshal(refunded)
+	require.NoError(t, err)
+	assert.Equal(t, "reseller-a", msg.Metadata.Get(tenant.MetadataKey))
+
+	// the header is filled from metadata when the payload doesn't have the tenant
+	payloadWithoutTenant := entities.TicketRefunded{Header: refunded.Header, TicketID: refunded.TicketID}
+	payloadWithoutTenant.Header.TenantID = ""
+	withoutTenant, err := marshaler.Marshal(payloadWithoutTenant)
+	require.NoError(t, err)
+	withoutTenant.Metadata.Set(tenant.MetadataKey, "reseller-a")
+
+	var decoded entities.TicketRefunded
+	require.NoError(t, marshaler.Unmarshal(withoutTenant, &decoded))
+	assert.Equal(t, refunded, decoded)
+}
+
+func TestNewMarshaler_unknown_event(t *testing.T) {
+	_, err := event.NewMarshaler([]string{"TicketPrinted"}, "", "")
+	assert.Error(t, err)
+}
+
+func TestMarshaler_cloud_events(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Mode           string
+		ProtobufEvents []string
+	}{
+		{Name: "binary", Mode: event.CloudEventsBinary},
+		{Name: "structured", Mode: event.CloudEventsStructured},
+		{Name: "structured_protobuf", Mode: event.CloudEventsStructured, ProtobufEvents: []string{"TicketRefunded"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			marshaler, err := event.NewMarshaler(tc.ProtobufEvents, tc.Mode, "tickets")
+			require.NoError(t, err)
+
+			refunded := entities.TicketRefunded{
+				Header:   entities.EventHeader{ID: uuid.NewString(), PublishedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+				TicketID: uuid.NewString(),
+			}
+
+			msg, err := marshaler.Marshal(refunded)
+			require.NoError(t, err)
+			assert.Equal(t, "TicketRefunded", marshaler.NameFromMessage(msg))
+
+			if tc.Mode == event.CloudEventsBinary {
+				assert.Equal(t, refunded.Header.ID, msg.Metadata.Get("ce_id"))
+				assert.Equal(t, "tickets", msg.Metadata.Get("ce_source"))
+				assert.Equal(t, "TicketRefunded", msg.Metadata.Get("ce_type"))
+				assert.Equal(t, "2024-05-01T12:00:00Z", msg.Metadata.Get("ce_time"))
+			} else {
+				assert.Equal(t, event.ContentTypeCloudEventsJSON, msg.Metadata.Get(event.ContentTypeMetadataKey))
+			}
+
+			var decoded entities.TicketRefunded
+			require.NoError(t, event.DefaultMarshaler().Unmarshal(msg, &decoded))
+			assert.Equal(t, refunded, decoded)
+		})
+	}
+}
+
+func TestMarshaler_cloud_event_from_another_service(t *testing.T) {
+	msg := message.NewMessage(uuid.NewString(), []byte(`{
+		"specversion": "1.0",
+		"id": "b7f6a7d6-7a4c-4a43-8b5e-9d5c3a5b1c11",
+		"source": "https://payments.example.com",
+		"type": "com.example.payments.TicketRefunded",
+		"time": "2024-05-01T12:00:00Z",
+		"data": {"ticket_id": "ticket-1"}
+	}`))
+	msg.Metadata.Set(event.ContentTypeMetadataKey, event.ContentTypeCloudEventsJSON)
+
+	marshaler := event.DefaultMarshaler()
+	assert.Equal(t, "TicketRefunded", marshaler.NameFromMessage(msg))
+
+	var decoded entities.TicketRefunded
+	require.NoError(t, marshaler.Unmarshal(msg, &decoded))
+	assert.Equal(t, entities.TicketRefunded{
+		Header: entities.EventHeader{
+			ID:          "b7f6a7d6-7a4c-4a43-8b5e-9d5c3a5b1c11",
+			PublishedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		},
+		TicketID: "ticket-1",
+	}, decoded)
+}
@@ -0,0 +1,68 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"tickets/entities"
+	"tickets/sheets"
+)
+
+type sheetRow struct {
+	sheet  string
+	header []string
+	// values returns the row in the order of the header row of the sheet
+	values func(layout sheets.Layout) []string
+}
+
+// appendRows appends rows of each sheet with one call, in order. It returns an error for each row
+// which wasn't appended, or nil when all of them were.
+func (h Handler) appendRows(ctx context.Context, rows []sheetRow) []error {
+	var sheetNames []string
+	// indexes of rows by sheet
+	indexes := map[string][]int{}
+	for i, row := range rows {
+		if _, ok := indexes[row.sheet]; !ok {
+			sheetNames = append(sheetNames, row.sheet)
+		}
+		indexes[row.sheet] = append(indexes[row.sheet], i)
+	}
+
+	var errs []error
+	for _, sheet := range sheetNames {
+		err := h.appendSheetRows(ctx, sheet, rows, indexes[sheet])
+		if err == nil {
+			continue
+		}
+
+		// rows appended before the failure must not be appended again
+		appended := 0
+		var appendErr *entities.AppendRowsError
+		if errors.As(err, &appendErr) {
+			appended = appendErr.Appended
+		}
+
+		if errs == nil {
+			errs = make([]error, len(rows))
+		}
+		for _, i := range indexes[sheet][appended:] {
+			errs[i] = fmt.Errorf("failed to append row to %s: %w", sheet, err)
+		}
+	}
+
+	return errs
+}
+
+func (h Handler) appendSheetRows(ctx context.Context, sheet string, rows []sheetRow, indexes []int) error {
+	layout, err := h.sheetLayouts.Ensure(ctx, sheet, rows[indexes[0]].header)
+	if err != nil {
+		return err
+	}
+
+	values := make([][]string, 0, len(indexes))
+	for _, i := range indexes {
+		values = append(values, rows[i].values(layout))
+	}
+
+	return h.spreadsheetsService.AppendRows(ctx, sheet, values)
+}
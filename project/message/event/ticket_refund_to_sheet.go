@@ -3,16 +3,34 @@ package event
 import (
 	"context"
 	"tickets/entities"
+	"tickets/sheets"
+	"tickets/tenant"
 
 	"github.com/ThreeDotsLabs/go-event-driven/common/log"
 )
 
-func (h Handler) TicketRefundToSheet(ctx context.Context, event *entities.TicketBookingCanceled) error {
-	log.FromContext(ctx).Info("Adding ticket refund to sheet")
+func (h Handler) TicketRefundToSheet(ctx context.Context, events []Batched[entities.TicketBookingCanceled]) []error {
+	log.FromContext(ctx).WithField("tickets", len(events)).Info("Adding ticket refunds to sheet")
 
-	return h.spreadsheetsService.AppendRow(
-		ctx,
-		"tickets-to-refund",
-		[]string{event.TicketID, event.CustomerEmail, event.Price.Amount, event.Price.Currency},
-	)
+	rows := make([]sheetRow, 0, len(events))
+	for _, e := range events {
+		sheet := h.tenants.Spreadsheets(tenant.FromContext(e.Ctx), h.sheets).TicketsToRefund
+
+		// the row is built once the layout of the sheet is known
+		event := e.Event
+		rows = append(rows, sheetRow{
+			sheet:  sheet,
+			header: sheets.TicketsToRefund.Header(),
+			values: func(layout sheets.Layout) []string {
+				return sheets.TicketsToRefund.Row(layout, sheets.TicketToRefund{
+					TicketID:      event.TicketID,
+					CustomerEmail: event.CustomerEmail,
+					Price:         event.Price,
+					CanceledAt:    event.Header.PublishedAt,
+				})
+			},
+		})
+	}
+
+	return h.appendRows(ctx, rows)
 }
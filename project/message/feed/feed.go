@@ -0,0 +1,79 @@
+// Package feed streams published messages to short-lived listeners, like dashboards, without consumer groups:
+// listeners don't affect consumers, and nothing is kept for them after they disconnect.
+package feed
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// ErrInvalidID is returned when the ID to resume after isn't valid for the feed.
+var ErrInvalidID = errors.New("invalid event ID")
+
+// ErrTooManySubscriptions is returned when the feed has as many subscriptions as it can serve.
+var ErrTooManySubscriptions = errors.New("too many event feed subscriptions")
+
+// Event is a message published to Topic.
+type Event struct {
+	// ID identifies the message position, so a listener can resume after it.
+	ID      string
+	Topic   string
+	Message *message.Message
+}
+
+// SubscriberFeed fans out messages from a subscriber that delivers every message to each subscription,
+// like GoChannel or Redis streams without a consumer group.
+//
+// It can't resume: event IDs are message UUIDs, and only messages published after subscribing are streamed.
+type SubscriberFeed struct {
+	subscriber message.Subscriber
+}
+
+func NewSubscriberFeed(subscriber message.Subscriber) *SubscriberFeed {
+	if subscriber == nil {
+		panic("missing subscriber")
+	}
+
+	return &SubscriberFeed{subscriber: subscriber}
+}
+
+// Subscribe streams events from topics until ctx is done. afterID is ignored.
+func (f *SubscriberFeed) Subscribe(ctx context.Context, topics []string, afterID string) (<-chan Event, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	events := make(chan Event)
+
+	done := make(chan struct{}, len(topics))
+	for _, topic := range topics {
+		messages, err := f.subscriber.Subscribe(ctx, topic)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+
+		go func(topic string, messages <-chan *message.Message) {
+			for msg := range messages {
+				select {
+				case events <- Event{ID: msg.UUID, Topic: topic, Message: msg}:
+					msg.Ack()
+				case <-ctx.Done():
+					msg.Nack()
+				}
+			}
+			done <- struct{}{}
+		}(topic, messages)
+	}
+
+	go func() {
+		defer cancel()
+
+		for range topics {
+			<-done
+		}
+		close(events)
+	}()
+
+	return events, nil
+}
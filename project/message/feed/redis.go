@@ -0,0 +1,229 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/ThreeDotsLabs/watermill-redisstream/pkg/redisstream"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisReadBlock = 2 * time.Second
+	redisReadCount = 100
+)
+
+// RedisFeed reads Redis streams directly, so events can be identified by their stream entry IDs
+// and resumed after any of them.
+//
+// Stream IDs start with the time they were added at, so a single ID is a position in all streams:
+// entries are streamed in ID order and resuming after an ID skips exactly the entries streamed before it.
+//
+// Each subscription holds a connection in a blocking read, so the feed has its own client with a connection
+// per subscription, and the connections of the service's client are left for its handlers.
+type RedisFeed struct {
+	client       *redis.Client
+	unmarshaller redisstream.Unmarshaller
+	// slots limit subscriptions to connections of client
+	slots chan struct{}
+}
+
+// NewRedisFeed returns a feed connecting to Redis like client does, with up to maxSubscriptions subscriptions.
+func NewRedisFeed(client *redis.Client, maxSubscriptions int) *RedisFeed {
+	if client == nil {
+		panic("missing redis client")
+	}
+	if maxSubscriptions < 1 {
+		panic("maxSubscriptions must be at least 1")
+	}
+
+	options := *client.Options()
+	options.PoolSize = maxSubscriptions
+	options.MinIdleConns = 0
+
+	return &RedisFeed{
+		client:       redis.NewClient(&options),
+		unmarshaller: redisstream.DefaultMarshallerUnmarshaller{},
+		slots:        make(chan struct{}, maxSubscriptions),
+	}
+}
+
+// Subscribe streams events from topics added after afterID until ctx is done.
+// With an empty afterID, only events added after subscribing are streamed.
+func (f *RedisFeed) Subscribe(ctx context.Context, topics []string, afterID string) (<-chan Event, error) {
+	select {
+	case f.slots <- struct{}{}:
+	default:
+		return nil, ErrTooManySubscriptions
+	}
+
+	events, err := f.subscribe(ctx, topics, afterID)
+	if err != nil {
+		<-f.slots
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Close closes connections of the feed, subscriptions end.
+func (f *RedisFeed) Close() error {
+	return f.client.Close()
+}
+
+func (f *RedisFeed) subscribe(ctx context.Context, topics []string, afterID string) (<-chan Event, error) {
+	positions := map[string]string{}
+
+	if afterID != "" {
+		if !validStreamID(afterID) {
+			return nil, fmt.Errorf("%w: %q is not a stream ID", ErrInvalidID, afterID)
+		}
+		for _, topic := range topics {
+			positions[topic] = afterID
+		}
+	} else {
+		// "$" can't be used in each read, it would skip entries added between reads
+		for _, topic := range topics {
+			last, err := f.client.XRevRangeN(ctx, topic, "+", "-", 1).Result()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read last entry of %s: %w", topic, err)
+			}
+
+			positions[topic] = "0-0"
+			if len(last) > 0 {
+				positions[topic] = last[0].ID
+			}
+		}
+	}
+
+	events := make(chan Event)
+
+	go func() {
+		defer func() { <-f.slots }()
+		defer close(events)
+
+		for ctx.Err() == nil {
+			err := f.read(ctx, topics, positions, events)
+			if errors.Is(err, redis.ErrClosed) {
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				log.FromContext(ctx).WithError(err).Error("Failed to read streams for feed")
+
+				select {
+				case <-ctx.Done():
+				case <-time.After(redisReadBlock):
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+type streamEntry struct {
+	topic string
+	entry redis.XMessage
+}
+
+func (f *RedisFeed) read(ctx context.Context, topics []string, positions map[string]string, events chan<- Event) error {
+	streams := make([]string, 0, len(topics)*2)
+	streams = append(streams, topics...)
+	for _, topic := range topics {
+		streams = append(streams, positions[topic])
+	}
+
+	result, err := f.client.XRead(ctx, &redis.XReadArgs{
+		Streams: streams,
+		Count:   redisReadCount,
+		Block:   redisReadBlock,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// A stream that returned a full batch may have more entries, with IDs lower than entries of other streams
+	// in this batch. Those entries are left for the next read, so that all entries are streamed in ID order.
+	cutoff := ""
+	for _, stream := range result {
+		if len(stream.Messages) < redisReadCount {
+			continue
+		}
+		if last := stream.Messages[len(stream.Messages)-1].ID; cutoff == "" || lessStreamID(last, cutoff) {
+			cutoff = last
+		}
+	}
+
+	var entries []streamEntry
+	for _, stream := range result {
+		for _, entry := range stream.Messages {
+			if cutoff != "" && lessStreamID(cutoff, entry.ID) {
+				break
+			}
+			entries = append(entries, streamEntry{topic: stream.Stream, entry: entry})
+		}
+	}
+
+	// entries added after this read get greater IDs, so sorting each batch is enough
+	sort.Slice(entries, func(i, j int) bool {
+		return lessStreamID(entries[i].entry.ID, entries[j].entry.ID)
+	})
+
+	for _, e := range entries {
+		positions[e.topic] = e.entry.ID
+
+		msg, err := f.unmarshaller.Unmarshal(e.entry.Values)
+		if err != nil {
+			log.FromContext(ctx).WithError(err).WithField("stream_id", e.entry.ID).Warn("Skipping invalid stream entry")
+			continue
+		}
+
+		select {
+		case events <- Event{ID: e.entry.ID, Topic: e.topic, Message: msg}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func validStreamID(id string) bool {
+	_, _, err := parseStreamID(id)
+	return err == nil
+}
+
+func lessStreamID(a, b string) bool {
+	aMs, aSeq, _ := parseStreamID(a)
+	bMs, bSeq, _ := parseStreamID(b)
+
+	return aMs < bMs || aMs == bMs && aSeq < bSeq
+}
+
+func parseStreamID(id string) (uint64, uint64, error) {
+	ms, seq, found := strings.Cut(id, "-")
+	if !found {
+		seq = "0"
+	}
+
+	msValue, err := strconv.ParseUint(ms, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	seqValue, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return msValue, seqValue, nil
+}
@@ -0,0 +1,44 @@
+package feed_test
+
+import (
+	"context"
+	"testing"
+	"tickets/message/feed"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisFeed_limits_subscriptions(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	redisFeed := feed.NewRedisFeed(client, 1)
+	t.Cleanup(func() {
+		_ = redisFeed.Close()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := redisFeed.Subscribe(ctx, []string{"TicketBookingConfirmed"}, "")
+	require.NoError(t, err)
+
+	_, err = redisFeed.Subscribe(context.Background(), []string{"TicketBookingConfirmed"}, "")
+	assert.ErrorIs(t, err, feed.ErrTooManySubscriptions)
+
+	cancel()
+	for range events {
+	}
+
+	require.EventuallyWithT(t, func(t *assert.CollectT) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		_, err := redisFeed.Subscribe(ctx, []string{"TicketBookingConfirmed"}, "")
+		assert.NoError(t, err, "subscription should be possible after the previous one ended")
+	}, 5*time.Second, 50*time.Millisecond)
+}
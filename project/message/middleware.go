@@ -1,7 +1,9 @@
 package message
 
 import (
-	"time"
+	"tickets/config"
+	"tickets/observability"
+	"tickets/tenant"
 
 	"github.com/ThreeDotsLabs/go-event-driven/common/log"
 	"github.com/ThreeDotsLabs/watermill"
@@ -9,16 +11,50 @@ import (
 	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
 	"github.com/lithammer/shortuuid/v3"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func useMiddlewares(router *message.Router, watermillLogger watermill.LoggerAdapter) {
+func useMiddlewares(router *message.Router, drainer *Drainer, retryConfig config.Retry, watermillLogger watermill.LoggerAdapter) {
 	router.AddMiddleware(middleware.Recoverer)
 
+	router.AddMiddleware(drainer.Middleware)
+
+	router.AddMiddleware(func(h message.HandlerFunc) message.HandlerFunc {
+		return func(msg *message.Message) ([]*message.Message, error) {
+			ctx := otel.GetTextMapPropagator().Extract(msg.Context(), propagation.MapCarrier(msg.Metadata))
+
+			ctx, span := observability.Tracer().Start(
+				ctx,
+				message.HandlerNameFromCtx(msg.Context()),
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(
+					attribute.String("messaging.message.id", msg.UUID),
+					attribute.String("messaging.destination.name", message.SubscribeTopicFromCtx(msg.Context())),
+				),
+			)
+			defer span.End()
+
+			msg.SetContext(ctx)
+
+			msgs, err := h(msg)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+
+			return msgs, err
+		}
+	})
+
 	router.AddMiddleware(middleware.Retry{
-		MaxRetries:      10,
-		InitialInterval: time.Millisecond * 100,
-		MaxInterval:     time.Second,
-		Multiplier:      2,
+		MaxRetries:      retryConfig.MaxRetries,
+		InitialInterval: retryConfig.InitialInterval,
+		MaxInterval:     retryConfig.MaxInterval,
+		Multiplier:      retryConfig.Multiplier,
 		Logger:          watermillLogger,
 	}.Middleware)
 
@@ -31,8 +67,18 @@ func useMiddlewares(router *message.Router, watermillLogger watermill.LoggerAdap
 				reqCorrelationID = shortuuid.New()
 			}
 
-			ctx = log.ToContext(ctx, logrus.WithFields(logrus.Fields{"correlation_id": reqCorrelationID}))
+			// messages published before tenants were introduced belong to the default tenant
+			tenantID := msg.Metadata.Get(tenant.MetadataKey)
+			if tenantID == "" {
+				tenantID = tenant.DefaultID
+			}
+
+			ctx = log.ToContext(ctx, logrus.WithFields(logrus.Fields{
+				"correlation_id": reqCorrelationID,
+				tenant.LogField:  tenantID,
+			}))
 			ctx = log.ContextWithCorrelationID(ctx, reqCorrelationID)
+			ctx = tenant.ContextWithID(ctx, tenantID)
 
 			msg.SetContext(ctx)
 
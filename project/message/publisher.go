@@ -0,0 +1,15 @@
+package message
+
+import (
+	"tickets/observability"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+func NewPublisher(pub message.Publisher) message.Publisher {
+	pub = log.CorrelationPublisherDecorator{Publisher: pub}
+	pub = observability.TracingPublisherDecorator{Publisher: pub}
+
+	return pub
+}
@@ -1,28 +1,15 @@
 package message
 
 import (
-	"github.com/ThreeDotsLabs/go-event-driven/common/log"
-	"github.com/ThreeDotsLabs/watermill"
-	"github.com/ThreeDotsLabs/watermill-redisstream/pkg/redisstream"
-	"github.com/ThreeDotsLabs/watermill/message"
+	"tickets/config"
+
 	"github.com/redis/go-redis/v9"
 )
 
-func NewRedisPublisher(rdb *redis.Client, watermillLogger watermill.LoggerAdapter) message.Publisher {
-	var pub message.Publisher
-	pub, err := redisstream.NewPublisher(redisstream.PublisherConfig{
-		Client: rdb,
-	}, watermillLogger)
-	if err != nil {
-		panic(err)
-	}
-	pub = log.CorrelationPublisherDecorator{pub}
-
-	return pub
-}
-
-func NewRedisClient(addr string) *redis.Client {
+func NewRedisClient(cfg config.Redis) *redis.Client {
 	return redis.NewClient(&redis.Options{
-		Addr: addr,
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
 	})
 }
@@ -1,40 +1,210 @@
 package message
 
 import (
+	"tickets/booking"
+	"tickets/config"
+	"tickets/entities"
+	"tickets/message/command"
 	"tickets/message/event"
+	"tickets/notifications"
+	"tickets/reconciliation"
+	"tickets/webhooks"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/ThreeDotsLabs/watermill/message"
 )
 
-func NewWatermillRouter(eventProcessorConfig cqrs.EventProcessorConfig, eventHandler event.Handler, watermillLogger watermill.LoggerAdapter) *message.Router {
-	router, err := message.NewRouter(message.RouterConfig{}, watermillLogger)
+// NewWatermillRouter adds event handlers consuming the shared topics with eventProcessorConfig, and for each tenant
+// in dedicatedTenantsConfigs, handlers consuming its dedicated topics. Handlers of a tenant are named with
+// ".<tenant ID>" suffix, so they have their own consumer groups.
+//
+// Handlers of the booking saga and its commands are added only when bookingSaga is not nil,
+// and handlers emailing customers only when customerNotifications is not nil.
+// Webhook deliveries are commands, handled with webhooksDeliverer.
+func NewWatermillRouter(
+	eventProcessorConfig cqrs.EventProcessorConfig,
+	dedicatedTenantsConfigs map[string]cqrs.EventProcessorConfig,
+	eventHandler event.Handler,
+	bookingSaga *booking.Saga,
+	customerNotifications *notifications.Mailer,
+	ticketsRecorder *reconciliation.Recorder,
+	commandProcessorConfig cqrs.CommandProcessorConfig,
+	commandHandler command.Handler,
+	webhooksDeliverer *webhooks.Deliverer,
+	drainer *Drainer,
+	retryConfig config.Retry,
+	spreadsheetsBatchConfig config.Batch,
+	closeTimeout time.Duration,
+	watermillLogger watermill.LoggerAdapter,
+) *message.Router {
+	router, err := message.NewRouter(message.RouterConfig{
+		CloseTimeout: closeTimeout,
+	}, watermillLogger)
 	if err != nil {
 		panic(err)
 	}
 
-	useMiddlewares(router, watermillLogger)
+	useMiddlewares(router, drainer, retryConfig, watermillLogger)
 
-	eventProcessor, err := cqrs.NewEventProcessorWithConfig(router, eventProcessorConfig)
+	addEventHandlers(router, eventProcessorConfig, eventHandler, bookingSaga, customerNotifications, ticketsRecorder, spreadsheetsBatchConfig, "")
+	for tenantID, processorConfig := range dedicatedTenantsConfigs {
+		addEventHandlers(router, processorConfig, eventHandler, bookingSaga, customerNotifications, ticketsRecorder, spreadsheetsBatchConfig, "."+tenantID)
+	}
+
+	addCommandHandlers(router, commandProcessorConfig, commandHandler, webhooksDeliverer, bookingSaga != nil)
+
+	return router
+}
+
+func addEventHandlers(
+	router *message.Router,
+	processorConfig cqrs.EventProcessorConfig,
+	eventHandler event.Handler,
+	bookingSaga *booking.Saga,
+	customerNotifications *notifications.Mailer,
+	ticketsRecorder *reconciliation.Recorder,
+	spreadsheetsBatchConfig config.Batch,
+	nameSuffix string,
+) {
+	eventProcessor, err := cqrs.NewEventProcessorWithConfig(router, processorConfig)
 	if err != nil {
 		panic(err)
 	}
 
+	addBatchEventHandler(
+		router,
+		processorConfig,
+		"AppendToTracker"+nameSuffix,
+		&entities.TicketBookingConfirmed{},
+		event.BatchHandler(processorConfig.Marshaler, eventHandler.AppendToTracker),
+		spreadsheetsBatchConfig,
+	)
+	addBatchEventHandler(
+		router,
+		processorConfig,
+		"TicketRefundToSheet"+nameSuffix,
+		&entities.TicketBookingCanceled{},
+		event.BatchHandler(processorConfig.Marshaler, eventHandler.TicketRefundToSheet),
+		spreadsheetsBatchConfig,
+	)
+
 	eventProcessor.AddHandlers(
 		cqrs.NewEventHandler(
-			"AppendToTracker",
-			eventHandler.AppendToTracker,
+			"IssueReceipt"+nameSuffix,
+			eventHandler.IssueReceipt,
+		),
+		cqrs.NewEventHandler(
+			"DeliverTicketBookingConfirmedWebhooks"+nameSuffix,
+			eventHandler.DeliverTicketBookingConfirmedWebhooks,
 		),
 		cqrs.NewEventHandler(
-			"TicketRefundToSheet",
-			eventHandler.TicketRefundToSheet,
+			"DeliverTicketBookingCanceledWebhooks"+nameSuffix,
+			eventHandler.DeliverTicketBookingCanceledWebhooks,
 		),
 		cqrs.NewEventHandler(
-			"IssueReceipt",
-			eventHandler.IssueReceipt,
+			"DeliverTicketRefundedWebhooks"+nameSuffix,
+			eventHandler.DeliverTicketRefundedWebhooks,
+		),
+		cqrs.NewEventHandler(
+			"RecordTicketBookingConfirmed"+nameSuffix,
+			ticketsRecorder.OnTicketBookingConfirmed,
+		),
+		cqrs.NewEventHandler(
+			"RecordTicketBookingCanceled"+nameSuffix,
+			ticketsRecorder.OnTicketBookingCanceled,
 		),
 	)
 
-	return router
+	if customerNotifications != nil {
+		eventProcessor.AddHandlers(
+			cqrs.NewEventHandler(
+				"NotifyTicketBookingConfirmed"+nameSuffix,
+				customerNotifications.NotifyTicketBookingConfirmed,
+			),
+			cqrs.NewEventHandler(
+				"NotifyTicketBookingCanceled"+nameSuffix,
+				customerNotifications.NotifyTicketBookingCanceled,
+			),
+		)
+	}
+
+	if bookingSaga == nil {
+		return
+	}
+
+	eventProcessor.AddHandlers(
+		cqrs.NewEventHandler(
+			"BookingSagaOnBookingMade"+nameSuffix,
+			bookingSaga.OnBookingMade,
+		),
+		cqrs.NewEventHandler(
+			"BookingSagaOnTicketsReserved"+nameSuffix,
+			bookingSaga.OnTicketsReserved,
+		),
+		cqrs.NewEventHandler(
+			"BookingSagaOnPaymentCaptured"+nameSuffix,
+			bookingSaga.OnPaymentCaptured,
+		),
+		cqrs.NewEventHandler(
+			"BookingSagaOnReceiptsIssued"+nameSuffix,
+			bookingSaga.OnReceiptsIssued,
+		),
+		cqrs.NewEventHandler(
+			"BookingSagaOnBookingStepFailed"+nameSuffix,
+			bookingSaga.OnBookingStepFailed,
+		),
+	)
+}
+
+func addCommandHandlers(
+	router *message.Router,
+	processorConfig cqrs.CommandProcessorConfig,
+	commandHandler command.Handler,
+	webhooksDeliverer *webhooks.Deliverer,
+	bookingSagaEnabled bool,
+) {
+	commandProcessor, err := cqrs.NewCommandProcessorWithConfig(router, processorConfig)
+	if err != nil {
+		panic(err)
+	}
+
+	commandProcessor.AddHandlers(
+		cqrs.NewCommandHandler(
+			"DeliverWebhook",
+			webhooksDeliverer.DeliverAttempt,
+		),
+	)
+
+	if !bookingSagaEnabled {
+		return
+	}
+
+	commandProcessor.AddHandlers(
+		cqrs.NewCommandHandler(
+			"ReserveTickets",
+			commandHandler.ReserveTickets,
+		),
+		cqrs.NewCommandHandler(
+			"CapturePayment",
+			commandHandler.CapturePayment,
+		),
+		cqrs.NewCommandHandler(
+			"IssueReceipts",
+			commandHandler.IssueReceipts,
+		),
+		cqrs.NewCommandHandler(
+			"ReleaseTickets",
+			commandHandler.ReleaseTickets,
+		),
+		cqrs.NewCommandHandler(
+			"RefundPayment",
+			commandHandler.RefundPayment,
+		),
+		cqrs.NewCommandHandler(
+			"VoidReceipts",
+			commandHandler.VoidReceipts,
+		),
+	)
 }
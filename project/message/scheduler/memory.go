@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps scheduled messages in memory, for running without Redis and PostgreSQL.
+// Messages are lost on restart.
+type MemoryStore struct {
+	lock     sync.Mutex
+	messages map[string]Message
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{messages: map[string]Message{}}
+}
+
+func (s *MemoryStore) Schedule(ctx context.Context, msg Message) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.messages[msg.Key] = copyMessage(msg)
+
+	return nil
+}
+
+func (s *MemoryStore) Cancel(ctx context.Context, key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.messages[key]; !ok {
+		return ErrMessageNotFound
+	}
+	delete(s.messages, key)
+
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, limit int) ([]Message, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.sorted(func(msg Message) bool { return true }, limit), nil
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, now time.Time, claimedUntil time.Time, limit int) ([]Message, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	due := s.sorted(func(msg Message) bool { return !msg.DeliverAt.After(now) }, limit)
+	for i := range due {
+		due[i].DeliverAt = claimedUntil
+		s.messages[due[i].Key] = copyMessage(due[i])
+	}
+
+	return due, nil
+}
+
+func (s *MemoryStore) Ack(ctx context.Context, msg Message) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if stored, ok := s.messages[msg.Key]; ok && stored.DeliverAt.Equal(msg.DeliverAt) {
+		delete(s.messages, msg.Key)
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) sorted(filter func(msg Message) bool, limit int) []Message {
+	var messages []Message
+	for _, msg := range s.messages {
+		if filter(msg) {
+			messages = append(messages, copyMessage(msg))
+		}
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].DeliverAt.Before(messages[j].DeliverAt)
+	})
+	if len(messages) > limit {
+		messages = messages[:limit]
+	}
+
+	return messages
+}
+
+func copyMessage(msg Message) Message {
+	metadata := make(map[string]string, len(msg.Metadata))
+	for k, v := range msg.Metadata {
+		metadata[k] = v
+	}
+	msg.Metadata = metadata
+	msg.Payload = append([]byte(nil), msg.Payload...)
+
+	return msg
+}
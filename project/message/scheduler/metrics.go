@@ -0,0 +1,27 @@
+package scheduler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	scheduledMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "tickets",
+		Subsystem: "scheduler",
+		Name:      "scheduled_messages_total",
+		Help:      "Number of messages scheduled for later delivery.",
+	}, []string{"topic"})
+	releasedMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "tickets",
+		Subsystem: "scheduler",
+		Name:      "released_messages_total",
+		Help:      "Number of scheduled messages published when they were due.",
+	}, []string{"topic"})
+	releaseErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "tickets",
+		Subsystem: "scheduler",
+		Name:      "release_errors_total",
+		Help:      "Number of failures while releasing scheduled messages.",
+	})
+)
@@ -0,0 +1,164 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresStore keeps scheduled messages in the scheduled_messages table created by db/schema.sql.
+type PostgresStore struct {
+	db *sqlx.DB
+}
+
+func NewPostgresStore(db *sqlx.DB) *PostgresStore {
+	if db == nil {
+		panic("missing db")
+	}
+
+	return &PostgresStore{db: db}
+}
+
+type messageRow struct {
+	Key       string    `db:"key"`
+	Topic     string    `db:"topic"`
+	UUID      string    `db:"uuid"`
+	Metadata  []byte    `db:"metadata"`
+	Payload   []byte    `db:"payload"`
+	DeliverAt time.Time `db:"deliver_at"`
+}
+
+func (r messageRow) message() (Message, error) {
+	var metadata map[string]string
+	if err := json.Unmarshal(r.Metadata, &metadata); err != nil {
+		return Message{}, fmt.Errorf("failed to unmarshal metadata of message %s: %w", r.Key, err)
+	}
+
+	return Message{
+		Key:       r.Key,
+		Topic:     r.Topic,
+		UUID:      r.UUID,
+		Metadata:  metadata,
+		Payload:   r.Payload,
+		DeliverAt: r.DeliverAt.UTC(),
+	}, nil
+}
+
+func messagesFromRows(rows []messageRow) ([]Message, error) {
+	messages := make([]Message, 0, len(rows))
+	for _, row := range rows {
+		msg, err := row.message()
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+const messageColumns = `key, topic, uuid, metadata, payload, deliver_at`
+
+func (s *PostgresStore) Schedule(ctx context.Context, msg Message) error {
+	metadata, err := json.Marshal(msg.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		`INSERT INTO scheduled_messages (`+messageColumns+`) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (key) DO UPDATE SET
+			topic = EXCLUDED.topic,
+			uuid = EXCLUDED.uuid,
+			metadata = EXCLUDED.metadata,
+			payload = EXCLUDED.payload,
+			deliver_at = EXCLUDED.deliver_at`,
+		msg.Key,
+		msg.Topic,
+		msg.UUID,
+		metadata,
+		msg.Payload,
+		msg.DeliverAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store message: %w", err)
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) Cancel(ctx context.Context, key string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM scheduled_messages WHERE key = $1`, key)
+	if err != nil {
+		return fmt.Errorf("failed to remove message: %w", err)
+	}
+
+	removed, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to remove message: %w", err)
+	}
+	if removed == 0 {
+		return ErrMessageNotFound
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) List(ctx context.Context, limit int) ([]Message, error) {
+	var rows []messageRow
+	err := s.db.SelectContext(
+		ctx,
+		&rows,
+		`SELECT `+messageColumns+` FROM scheduled_messages ORDER BY deliver_at LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	return messagesFromRows(rows)
+}
+
+func (s *PostgresStore) Claim(ctx context.Context, now time.Time, claimedUntil time.Time, limit int) ([]Message, error) {
+	// SKIP LOCKED lets replicas claim different messages at the same time
+	var rows []messageRow
+	err := s.db.SelectContext(
+		ctx,
+		&rows,
+		`UPDATE scheduled_messages SET deliver_at = $2
+		WHERE key IN (
+			SELECT key FROM scheduled_messages
+			WHERE deliver_at <= $1
+			ORDER BY deliver_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+messageColumns,
+		now,
+		claimedUntil,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due messages: %w", err)
+	}
+
+	return messagesFromRows(rows)
+}
+
+func (s *PostgresStore) Ack(ctx context.Context, msg Message) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM scheduled_messages WHERE key = $1 AND deliver_at = $2`,
+		msg.Key,
+		msg.DeliverAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove message: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// Metadata of messages published with Publisher. They are removed before the message is published or scheduled.
+const (
+	// DeliverAtMetadataKey is when the message is due, in RFC 3339 format.
+	DeliverAtMetadataKey = "deliver_at"
+	// DelayMetadataKey is how long after publishing the message is due, in time.ParseDuration format.
+	DelayMetadataKey = "delay"
+	// KeyMetadataKey is the key the scheduled message can be canceled with.
+	KeyMetadataKey = "schedule_key"
+)
+
+type ctxKey int
+
+const (
+	deliverAtCtxKey ctxKey = iota
+	delayCtxKey
+	keyCtxKey
+)
+
+// DeliverAt makes messages published with ctx due at t, for example with the command or event bus.
+func DeliverAt(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, deliverAtCtxKey, t)
+}
+
+// DeliverAfter makes messages published with ctx due delay after they are published.
+func DeliverAfter(ctx context.Context, delay time.Duration) context.Context {
+	return context.WithValue(ctx, delayCtxKey, delay)
+}
+
+// WithKey sets the key a message published with ctx can be canceled with, for example "show-reminder-<show ID>".
+// A message scheduled with the key of another one replaces it.
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyCtxKey, key)
+}
+
+// Publisher decorates the transport publisher: messages due later are kept in the store, and published
+// by Releaser when they are due. Other messages are published right away.
+type Publisher struct {
+	pub   message.Publisher
+	store Store
+	now   func() time.Time
+}
+
+func NewPublisher(pub message.Publisher, store Store) *Publisher {
+	if pub == nil {
+		panic("missing publisher")
+	}
+	if store == nil {
+		panic("missing store")
+	}
+
+	return &Publisher{
+		pub:   pub,
+		store: store,
+		now:   time.Now,
+	}
+}
+
+func (p *Publisher) Publish(topic string, messages ...*message.Message) error {
+	now := p.now()
+
+	var immediate []*message.Message
+	for _, msg := range messages {
+		deliverAt, err := deliveryTime(msg, now)
+		if err != nil {
+			return err
+		}
+		key := scheduleKey(msg)
+
+		delete(msg.Metadata, DeliverAtMetadataKey)
+		delete(msg.Metadata, DelayMetadataKey)
+		delete(msg.Metadata, KeyMetadataKey)
+
+		if !deliverAt.After(now) {
+			immediate = append(immediate, msg)
+			continue
+		}
+
+		err = p.store.Schedule(msg.Context(), Message{
+			Key:       key,
+			Topic:     topic,
+			UUID:      msg.UUID,
+			Metadata:  msg.Metadata,
+			Payload:   msg.Payload,
+			DeliverAt: storedTime(deliverAt),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to schedule message %s: %w", msg.UUID, err)
+		}
+		scheduledMessages.WithLabelValues(topic).Inc()
+	}
+
+	if len(immediate) == 0 {
+		return nil
+	}
+
+	return p.pub.Publish(topic, immediate...)
+}
+
+func (p *Publisher) Close() error {
+	return p.pub.Close()
+}
+
+// deliveryTime is zero for messages which aren't delayed. Metadata takes precedence over the context.
+func deliveryTime(msg *message.Message, now time.Time) (time.Time, error) {
+	if deliverAt := msg.Metadata.Get(DeliverAtMetadataKey); deliverAt != "" {
+		t, err := time.Parse(time.RFC3339Nano, deliverAt)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s of message %s: %w", DeliverAtMetadataKey, msg.UUID, err)
+		}
+		return t, nil
+	}
+	if delay := msg.Metadata.Get(DelayMetadataKey); delay != "" {
+		d, err := time.ParseDuration(delay)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s of message %s: %w", DelayMetadataKey, msg.UUID, err)
+		}
+		return now.Add(d), nil
+	}
+
+	ctx := msg.Context()
+	if t, ok := ctx.Value(deliverAtCtxKey).(time.Time); ok {
+		return t, nil
+	}
+	if d, ok := ctx.Value(delayCtxKey).(time.Duration); ok {
+		return now.Add(d), nil
+	}
+
+	return time.Time{}, nil
+}
+
+func scheduleKey(msg *message.Message) string {
+	if key := msg.Metadata.Get(KeyMetadataKey); key != "" {
+		return key
+	}
+	if key, ok := msg.Context().Value(keyCtxKey).(string); ok && key != "" {
+		return key
+	}
+
+	return msg.UUID
+}
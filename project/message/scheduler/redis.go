@@ -0,0 +1,209 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// redisDueKey is a sorted set of message keys, scored by when they are due in Unix milliseconds.
+	redisDueKey = "tickets:scheduler:due"
+	// redisMessagesKey is a hash of messages by their keys.
+	redisMessagesKey = "tickets:scheduler:messages"
+)
+
+// claimScript postpones due messages and returns their keys and messages, interleaved.
+// Keys without a message are leftovers of a cancellation racing with Claim.
+var claimScript = redis.NewScript(`
+local keys = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+local claimed = {}
+for _, key in ipairs(keys) do
+	local msg = redis.call('HGET', KEYS[2], key)
+	if msg then
+		redis.call('ZADD', KEYS[1], ARGV[2], key)
+		table.insert(claimed, key)
+		table.insert(claimed, msg)
+	else
+		redis.call('ZREM', KEYS[1], key)
+	end
+end
+return claimed
+`)
+
+// ackScript removes the message, unless it's due at another time than when it was claimed.
+var ackScript = redis.NewScript(`
+if tonumber(redis.call('ZSCORE', KEYS[1], ARGV[1])) == tonumber(ARGV[2]) then
+	redis.call('ZREM', KEYS[1], ARGV[1])
+	redis.call('HDEL', KEYS[2], ARGV[1])
+end
+return 0
+`)
+
+// RedisStore keeps scheduled messages in Redis, so they are shared by all service instances.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	if client == nil {
+		panic("missing redis client")
+	}
+
+	return &RedisStore{client: client}
+}
+
+type redisMessage struct {
+	Topic    string            `json:"topic"`
+	UUID     string            `json:"uuid"`
+	Metadata map[string]string `json:"metadata"`
+	Payload  []byte            `json:"payload"`
+}
+
+func (s *RedisStore) Schedule(ctx context.Context, msg Message) error {
+	stored, err := json.Marshal(redisMessage{
+		Topic:    msg.Topic,
+		UUID:     msg.UUID,
+		Metadata: msg.Metadata,
+		Payload:  msg.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, redisMessagesKey, msg.Key, stored)
+		pipe.ZAdd(ctx, redisDueKey, redis.Z{Score: redisScore(msg.DeliverAt), Member: msg.Key})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store message: %w", err)
+	}
+
+	return nil
+}
+
+func (s *RedisStore) Cancel(ctx context.Context, key string) error {
+	var removed *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		removed = pipe.ZRem(ctx, redisDueKey, key)
+		pipe.HDel(ctx, redisMessagesKey, key)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove message: %w", err)
+	}
+
+	if removed.Val() == 0 {
+		return ErrMessageNotFound
+	}
+
+	return nil
+}
+
+func (s *RedisStore) List(ctx context.Context, limit int) ([]Message, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	due, err := s.client.ZRangeWithScores(ctx, redisDueKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due messages: %w", err)
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(due))
+	for _, z := range due {
+		keys = append(keys, z.Member.(string))
+	}
+
+	stored, err := s.client.HMGet(ctx, redisMessagesKey, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	messages := make([]Message, 0, len(due))
+	for i, z := range due {
+		raw, ok := stored[i].(string)
+		if !ok {
+			// canceled meanwhile
+			continue
+		}
+
+		msg, err := unmarshalRedisMessage(keys[i], raw, z.Score)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+func (s *RedisStore) Claim(ctx context.Context, now time.Time, claimedUntil time.Time, limit int) ([]Message, error) {
+	claimedScore := redisScore(claimedUntil)
+
+	result, err := claimScript.Run(
+		ctx,
+		s.client,
+		[]string{redisDueKey, redisMessagesKey},
+		redisScore(now),
+		claimedScore,
+		limit,
+	).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due messages: %w", err)
+	}
+
+	messages := make([]Message, 0, len(result)/2)
+	for i := 0; i+1 < len(result); i += 2 {
+		msg, err := unmarshalRedisMessage(result[i], result[i+1], claimedScore)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+func (s *RedisStore) Ack(ctx context.Context, msg Message) error {
+	err := ackScript.Run(
+		ctx,
+		s.client,
+		[]string{redisDueKey, redisMessagesKey},
+		msg.Key,
+		strconv.FormatFloat(redisScore(msg.DeliverAt), 'f', -1, 64),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to remove message: %w", err)
+	}
+
+	return nil
+}
+
+func unmarshalRedisMessage(key string, raw string, score float64) (Message, error) {
+	var stored redisMessage
+	if err := json.Unmarshal([]byte(raw), &stored); err != nil {
+		return Message{}, fmt.Errorf("failed to unmarshal message %s: %w", key, err)
+	}
+
+	return Message{
+		Key:       key,
+		Topic:     stored.Topic,
+		UUID:      stored.UUID,
+		Metadata:  stored.Metadata,
+		Payload:   stored.Payload,
+		DeliverAt: time.UnixMilli(int64(score)).UTC(),
+	}, nil
+}
+
+func redisScore(t time.Time) float64 {
+	return float64(t.UnixMilli())
+}
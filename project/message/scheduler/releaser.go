@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"tickets/config"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/sirupsen/logrus"
+)
+
+// Releaser publishes scheduled messages when they are due.
+//
+// Due messages are claimed before they are published, so replicas don't publish the same messages.
+// A message is acknowledged after it's published: if the releaser stops in between, the claim expires
+// and the message is published again.
+type Releaser struct {
+	store  Store
+	pub    message.Publisher
+	config config.Scheduler
+	now    func() time.Time
+}
+
+// NewReleaser publishes to pub, which should be the transport publisher, not the decorating Publisher.
+func NewReleaser(store Store, pub message.Publisher, cfg config.Scheduler) *Releaser {
+	if store == nil {
+		panic("missing store")
+	}
+	if pub == nil {
+		panic("missing publisher")
+	}
+
+	return &Releaser{
+		store:  store,
+		pub:    pub,
+		config: cfg,
+		now:    time.Now,
+	}
+}
+
+// Run releases due messages every check interval, until ctx is done.
+func (r *Releaser) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		// a full batch means there may be more due messages
+		for {
+			released, err := r.ReleaseDue(ctx)
+			if err != nil {
+				log.FromContext(ctx).WithError(err).Error("Failed to release scheduled messages")
+			}
+			if err != nil || released < r.config.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+}
+
+// ReleaseDue publishes a batch of due messages, it returns how many were claimed.
+func (r *Releaser) ReleaseDue(ctx context.Context) (int, error) {
+	now := r.now()
+
+	claimed, err := r.store.Claim(ctx, storedTime(now), storedTime(now.Add(r.config.ClaimTimeout)), r.config.BatchSize)
+	if err != nil {
+		releaseErrors.Inc()
+		return 0, err
+	}
+
+	var failed int
+	for _, scheduled := range claimed {
+		if err := r.release(ctx, scheduled); err != nil {
+			releaseErrors.Inc()
+			failed++
+
+			log.FromContext(ctx).WithError(err).WithFields(logrus.Fields{
+				"key":   scheduled.Key,
+				"topic": scheduled.Topic,
+			}).Error("Failed to release scheduled message, it will be retried")
+		}
+	}
+
+	if failed > 0 {
+		return len(claimed), fmt.Errorf("failed to release %d of %d scheduled messages", failed, len(claimed))
+	}
+
+	return len(claimed), nil
+}
+
+func (r *Releaser) release(ctx context.Context, scheduled Message) error {
+	msg := message.NewMessage(scheduled.UUID, scheduled.Payload)
+	for k, v := range scheduled.Metadata {
+		msg.Metadata.Set(k, v)
+	}
+	msg.SetContext(ctx)
+
+	if err := r.pub.Publish(scheduled.Topic, msg); err != nil {
+		return fmt.Errorf("failed to publish: %w", err)
+	}
+	releasedMessages.WithLabelValues(scheduled.Topic).Inc()
+
+	if err := r.store.Ack(ctx, scheduled); err != nil {
+		return fmt.Errorf("failed to acknowledge published message: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"tickets/config"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	pubSub := gochannel.NewGoChannel(gochannel.Config{Persistent: true}, watermill.NopLogger{})
+	store := NewMemoryStore()
+
+	publisher := NewPublisher(pubSub, store)
+	publisher.now = func() time.Time { return now }
+
+	releaser := NewReleaser(store, pubSub, config.Scheduler{ClaimTimeout: time.Minute, BatchSize: 10})
+
+	delayed := message.NewMessage(watermill.NewUUID(), []byte("delayed"))
+	delayed.SetContext(DeliverAfter(ctx, time.Hour))
+
+	canceled := message.NewMessage(watermill.NewUUID(), []byte("canceled"))
+	canceled.SetContext(WithKey(DeliverAfter(ctx, time.Hour), "reminder"))
+
+	immediate := message.NewMessage(watermill.NewUUID(), []byte("immediate"))
+	immediate.Metadata.Set(DelayMetadataKey, "0s")
+
+	require.NoError(t, publisher.Publish("topic", delayed, canceled, immediate))
+
+	messages, err := pubSub.Subscribe(ctx, "topic")
+	require.NoError(t, err)
+
+	assert.Equal(t, immediate.UUID, receive(t, messages).UUID)
+
+	scheduled, err := store.List(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, scheduled, 2)
+	assert.Equal(t, now.Add(time.Hour), scheduled[0].DeliverAt)
+
+	require.NoError(t, store.Cancel(ctx, "reminder"))
+	assert.ErrorIs(t, store.Cancel(ctx, "reminder"), ErrMessageNotFound)
+
+	releaser.now = func() time.Time { return now.Add(time.Minute) }
+	released, err := releaser.ReleaseDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, released, "message released before it's due")
+
+	releaser.now = func() time.Time { return now.Add(time.Hour) }
+	released, err = releaser.ReleaseDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+
+	msg := receive(t, messages)
+	assert.Equal(t, delayed.UUID, msg.UUID)
+	assert.Equal(t, "delayed", string(msg.Payload))
+	assert.Empty(t, msg.Metadata.Get(DelayMetadataKey))
+
+	scheduled, err = store.List(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, scheduled)
+}
+
+func TestMemoryStore_Ack_keeps_rescheduled_message(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	store := NewMemoryStore()
+	require.NoError(t, store.Schedule(ctx, Message{Key: "key", Topic: "topic", DeliverAt: now}))
+
+	claimed, err := store.Claim(ctx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	// a claimed message isn't claimed again until the claim expires
+	again, err := store.Claim(ctx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+
+	require.NoError(t, store.Schedule(ctx, Message{Key: "key", Topic: "topic", DeliverAt: now.Add(time.Hour)}))
+	require.NoError(t, store.Ack(ctx, claimed[0]))
+
+	scheduled, err := store.List(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, scheduled, 1)
+	assert.Equal(t, now.Add(time.Hour), scheduled[0].DeliverAt)
+}
+
+func receive(t *testing.T, messages <-chan *message.Message) *message.Message {
+	t.Helper()
+
+	select {
+	case msg := <-messages:
+		msg.Ack()
+		return msg
+	case <-time.After(time.Second):
+		require.FailNow(t, "message not received")
+		return nil
+	}
+}
@@ -0,0 +1,47 @@
+// Package scheduler delays delivery of messages, which the transports can't do themselves.
+//
+// Publisher keeps messages due later in a Store, instead of publishing them, and Releaser publishes them
+// to their topics once they are due. A message is removed from the Store only after it's published,
+// so delivery is at-least-once.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrMessageNotFound = errors.New("scheduled message not found")
+
+// Message waiting in a Store for its delivery.
+type Message struct {
+	// Key identifies the message in the Store, it's the message UUID unless set with WithKey.
+	Key   string
+	Topic string
+
+	UUID     string
+	Metadata map[string]string
+	Payload  []byte
+
+	// DeliverAt is when the message is due. While the message is claimed, it's when the claim expires.
+	DeliverAt time.Time
+}
+
+type Store interface {
+	// Schedule stores the message, replacing a message with the same key.
+	Schedule(ctx context.Context, msg Message) error
+	// Cancel removes the message, it returns ErrMessageNotFound if there is no such message.
+	Cancel(ctx context.Context, key string) error
+	// List returns up to limit of messages, the earliest due first.
+	List(ctx context.Context, limit int) ([]Message, error)
+	// Claim returns up to limit of messages due at now, and postpones them until claimedUntil,
+	// so they are claimed again if they aren't acknowledged by then.
+	Claim(ctx context.Context, now time.Time, claimedUntil time.Time, limit int) ([]Message, error)
+	// Ack removes the claimed message, unless it was scheduled again since it was claimed.
+	Ack(ctx context.Context, msg Message) error
+}
+
+// storedTime is the precision of times kept by all stores, so claimed messages can be compared with stored ones.
+func storedTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Millisecond)
+}
@@ -0,0 +1,199 @@
+// Package streams inspects and maintains the Redis streams used as topics.
+package streams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type StreamReport struct {
+	Topic           string        `json:"topic"`
+	Length          int64         `json:"length"`
+	LastGeneratedID string        `json:"last_generated_id"`
+	Groups          []GroupReport `json:"groups"`
+}
+
+type GroupReport struct {
+	Name            string `json:"name"`
+	LastDeliveredID string `json:"last_delivered_id"`
+	// Lag is the number of entries not yet delivered to the group, reported by Redis 7+ only.
+	Lag     int64 `json:"lag"`
+	Pending int64 `json:"pending"`
+	// OldestPendingAgeMs is the time since the oldest pending message was published.
+	OldestPendingAgeMs int64 `json:"oldest_pending_age_ms"`
+	// OldestPendingIdleMs is the time since the oldest pending message was last delivered to a consumer.
+	OldestPendingIdleMs int64            `json:"oldest_pending_idle_ms"`
+	ConsumersPending    map[string]int64 `json:"consumers_pending"`
+}
+
+// Inspector reports on streams consumed by the service's consumer groups.
+type Inspector struct {
+	client              *redis.Client
+	consumerGroupPrefix string
+}
+
+func NewInspector(client *redis.Client, consumerGroupPrefix string) *Inspector {
+	if client == nil {
+		panic("missing redis client")
+	}
+
+	return &Inspector{
+		client:              client,
+		consumerGroupPrefix: consumerGroupPrefix,
+	}
+}
+
+// Inspect reports all streams that have at least one of the service's consumer groups.
+func (i *Inspector) Inspect(ctx context.Context) ([]StreamReport, error) {
+	topics, err := scanStreams(ctx, i.client)
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []StreamReport
+	for _, topic := range topics {
+		report, ok, err := i.inspectStream(ctx, topic)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			reports = append(reports, report)
+		}
+	}
+
+	return reports, nil
+}
+
+// scanStreams returns names of all streams, sorted.
+func scanStreams(ctx context.Context, client *redis.Client) ([]string, error) {
+	var topics []string
+
+	var cursor uint64
+	for {
+		keys, next, err := client.ScanType(ctx, cursor, "", 100, "stream").Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan streams: %w", err)
+		}
+
+		topics = append(topics, keys...)
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	sort.Strings(topics)
+
+	return topics, nil
+}
+
+func (i *Inspector) inspectStream(ctx context.Context, topic string) (StreamReport, bool, error) {
+	groups, err := i.client.XInfoGroups(ctx, topic).Result()
+	if err != nil {
+		return StreamReport{}, false, fmt.Errorf("failed to get groups of %s: %w", topic, err)
+	}
+
+	if !hasGroupWithPrefix(groups, i.consumerGroupPrefix) {
+		return StreamReport{}, false, nil
+	}
+
+	report := StreamReport{Topic: topic}
+
+	for _, group := range groups {
+		if !strings.HasPrefix(group.Name, i.consumerGroupPrefix) {
+			continue
+		}
+
+		groupReport, err := i.inspectGroup(ctx, topic, group)
+		if err != nil {
+			return StreamReport{}, false, err
+		}
+
+		report.Groups = append(report.Groups, groupReport)
+	}
+
+	info, err := i.client.XInfoStream(ctx, topic).Result()
+	if err != nil {
+		return StreamReport{}, false, fmt.Errorf("failed to get info of %s: %w", topic, err)
+	}
+
+	report.Length = info.Length
+	report.LastGeneratedID = info.LastGeneratedID
+
+	return report, true, nil
+}
+
+// hasGroupWithPrefix reports whether the stream is consumed by the service, that is by one of its consumer groups.
+func hasGroupWithPrefix(groups []redis.XInfoGroup, consumerGroupPrefix string) bool {
+	for _, group := range groups {
+		if strings.HasPrefix(group.Name, consumerGroupPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (i *Inspector) inspectGroup(ctx context.Context, topic string, group redis.XInfoGroup) (GroupReport, error) {
+	report := GroupReport{
+		Name:             group.Name,
+		LastDeliveredID:  group.LastDeliveredID,
+		Lag:              group.Lag,
+		Pending:          group.Pending,
+		ConsumersPending: map[string]int64{},
+	}
+
+	if group.Pending == 0 {
+		return report, nil
+	}
+
+	pending, err := i.client.XPending(ctx, topic, group.Name).Result()
+	if err != nil {
+		return GroupReport{}, fmt.Errorf("failed to get pending of %s/%s: %w", topic, group.Name, err)
+	}
+
+	report.ConsumersPending = pending.Consumers
+
+	if publishedAt, err := idTime(pending.Lower); err == nil {
+		report.OldestPendingAgeMs = time.Since(publishedAt).Milliseconds()
+	}
+
+	oldest, err := i.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: topic,
+		Group:  group.Name,
+		Start:  "-",
+		End:    "+",
+		Count:  1,
+	}).Result()
+	if err != nil {
+		return GroupReport{}, fmt.Errorf("failed to get oldest pending of %s/%s: %w", topic, group.Name, err)
+	}
+	if len(oldest) > 0 {
+		report.OldestPendingIdleMs = oldest[0].Idle.Milliseconds()
+	}
+
+	return report, nil
+}
+
+// idTime returns the time encoded in a stream entry ID (<milliseconds>-<sequence>).
+func idTime(id string) (time.Time, error) {
+	ms, _, found := strings.Cut(id, "-")
+	if !found {
+		return time.Time{}, errors.New("invalid stream ID: " + id)
+	}
+
+	millis, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid stream ID %s: %w", id, err)
+	}
+
+	return time.UnixMilli(millis), nil
+}
@@ -0,0 +1,33 @@
+package streams
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	trimRuns = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "tickets",
+		Subsystem: "streams",
+		Name:      "trim_runs_total",
+		Help:      "Number of stream trimming runs.",
+	})
+	trimErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "tickets",
+		Subsystem: "streams",
+		Name:      "trim_errors_total",
+		Help:      "Number of failures while trimming streams.",
+	})
+	trimmedEntries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "tickets",
+		Subsystem: "streams",
+		Name:      "trimmed_entries_total",
+		Help:      "Number of entries removed from streams by retention policies.",
+	}, []string{"topic"})
+	streamLength = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "tickets",
+		Subsystem: "streams",
+		Name:      "length",
+		Help:      "Number of entries in a stream after the last trimming run.",
+	}, []string{"topic"})
+)
@@ -0,0 +1,257 @@
+package streams
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"tickets/config"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// Trimmer applies retention policies to the streams the Inspector reports: those consumed by at least one
+// of the service's consumer groups. Streams of other services are left alone.
+//
+// Entries are never trimmed past the slowest consumer group: neither past its last delivered ID,
+// nor past its oldest pending (delivered but not acknowledged) entry, so they can still be redelivered.
+type Trimmer struct {
+	client              *redis.Client
+	consumerGroupPrefix string
+	retention           config.Retention
+	now                 func() time.Time
+}
+
+func NewTrimmer(client *redis.Client, consumerGroupPrefix string, retention config.Retention) *Trimmer {
+	if client == nil {
+		panic("missing redis client")
+	}
+
+	return &Trimmer{
+		client:              client,
+		consumerGroupPrefix: consumerGroupPrefix,
+		retention:           retention,
+		now:                 time.Now,
+	}
+}
+
+// Run trims streams every retention interval until ctx is done.
+func (t *Trimmer) Run(ctx context.Context) error {
+	if t.retention.Interval == 0 {
+		return nil
+	}
+
+	logger := log.FromContext(ctx)
+
+	ticker := time.NewTicker(t.retention.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.TrimOnce(ctx); err != nil {
+			logger.WithError(err).Error("Failed to trim streams")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// TrimOnce trims the service's streams according to their retention policies.
+func (t *Trimmer) TrimOnce(ctx context.Context) error {
+	trimRuns.Inc()
+
+	topics, err := scanStreams(ctx, t.client)
+	if err != nil {
+		trimErrors.Inc()
+		return err
+	}
+
+	var failed int
+	for _, topic := range topics {
+		if err := t.trim(ctx, topic); err != nil {
+			trimErrors.Inc()
+			failed++
+
+			log.FromContext(ctx).WithError(err).WithField("topic", topic).Error("Failed to trim stream")
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to trim %d of %d streams", failed, len(topics))
+	}
+
+	return nil
+}
+
+func (t *Trimmer) trim(ctx context.Context, topic string) error {
+	groups, err := t.client.XInfoGroups(ctx, topic).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get groups: %w", err)
+	}
+	if !hasGroupWithPrefix(groups, t.consumerGroupPrefix) {
+		return nil
+	}
+
+	policy := t.retention.Policy(topic)
+
+	length, err := t.client.XLen(ctx, topic).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get length: %w", err)
+	}
+
+	policyMinID, err := t.policyMinID(ctx, topic, policy, length)
+	if err != nil {
+		return err
+	}
+
+	minID := policyMinID
+	if minID != "" {
+		floor, err := t.consumedFloor(ctx, topic, groups)
+		if err != nil {
+			return err
+		}
+		if compareIDs(floor, minID) < 0 {
+			minID = floor
+		}
+	}
+
+	var trimmed int64
+	if minID != "" && minID != "0-0" {
+		// approximate trimming removes only whole macro nodes, which is much cheaper
+		trimmed, err = t.client.XTrimMinIDApprox(ctx, topic, minID, 0).Result()
+		if err != nil {
+			return fmt.Errorf("failed to trim to %s: %w", minID, err)
+		}
+	}
+
+	trimmedEntries.WithLabelValues(topic).Add(float64(trimmed))
+	streamLength.WithLabelValues(topic).Set(float64(length - trimmed))
+
+	if trimmed > 0 {
+		log.FromContext(ctx).WithFields(logrus.Fields{
+			"topic":      topic,
+			"trimmed":    trimmed,
+			"min_id":     minID,
+			"limited_by": limitedBy(minID, policyMinID),
+		}).Info("Trimmed stream")
+	}
+
+	return nil
+}
+
+// policyMinID returns the lowest ID to keep according to the policy, or "" if nothing should be trimmed.
+func (t *Trimmer) policyMinID(ctx context.Context, topic string, policy config.RetentionPolicy, length int64) (string, error) {
+	var minID string
+
+	if policy.MaxLen > 0 && length > policy.MaxLen {
+		newest, err := t.client.XRevRangeN(ctx, topic, "+", "-", policy.MaxLen).Result()
+		if err != nil {
+			return "", fmt.Errorf("failed to read newest entries: %w", err)
+		}
+		if len(newest) > 0 {
+			minID = newest[len(newest)-1].ID
+		}
+	}
+
+	if policy.MaxAge > 0 {
+		byAge := fmt.Sprintf("%d-0", t.now().Add(-policy.MaxAge).UnixMilli())
+		if minID == "" || compareIDs(byAge, minID) > 0 {
+			minID = byAge
+		}
+	}
+
+	return minID, nil
+}
+
+// consumedFloor returns the lowest ID that some consumer group, of any service, may still need.
+func (t *Trimmer) consumedFloor(ctx context.Context, topic string, groups []redis.XInfoGroup) (string, error) {
+	floor := "+"
+	for _, group := range groups {
+		// entries after the last delivered ID were not read by the group yet
+		groupFloor := nextID(group.LastDeliveredID)
+
+		if group.Pending > 0 {
+			pending, err := t.client.XPending(ctx, topic, group.Name).Result()
+			if err != nil {
+				return "", fmt.Errorf("failed to get pending of %s: %w", group.Name, err)
+			}
+			if compareIDs(pending.Lower, groupFloor) < 0 {
+				groupFloor = pending.Lower
+			}
+		}
+
+		if floor == "+" || compareIDs(groupFloor, floor) < 0 {
+			floor = groupFloor
+		}
+	}
+
+	return floor, nil
+}
+
+func limitedBy(minID, policyMinID string) string {
+	if minID == policyMinID {
+		return "policy"
+	}
+
+	return "consumer_group"
+}
+
+// nextID returns the smallest ID greater than id.
+func nextID(id string) string {
+	ms, seq, err := parseID(id)
+	if err != nil {
+		return id
+	}
+
+	return fmt.Sprintf("%d-%d", ms, seq+1)
+}
+
+// compareIDs compares stream IDs, "+" is greater than any ID.
+func compareIDs(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "+" {
+		return 1
+	}
+	if b == "+" {
+		return -1
+	}
+
+	aMs, aSeq, _ := parseID(a)
+	bMs, bSeq, _ := parseID(b)
+
+	switch {
+	case aMs < bMs || aMs == bMs && aSeq < bSeq:
+		return -1
+	case aMs == bMs && aSeq == bSeq:
+		return 0
+	default:
+		return 1
+	}
+}
+
+func parseID(id string) (uint64, uint64, error) {
+	ms, seq, found := strings.Cut(id, "-")
+	if !found {
+		seq = "0"
+	}
+
+	msValue, err := strconv.ParseUint(ms, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid stream ID %s: %w", id, err)
+	}
+
+	seqValue, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid stream ID %s: %w", id, err)
+	}
+
+	return msValue, seqValue, nil
+}
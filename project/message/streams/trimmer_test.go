@@ -0,0 +1,113 @@
+package streams
+
+import (
+	"context"
+	"testing"
+	"tickets/config"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareIDs(t *testing.T) {
+	testCases := []struct {
+		A, B     string
+		Expected int
+	}{
+		{A: "1-0", B: "1-0", Expected: 0},
+		{A: "1-1", B: "1-0", Expected: 1},
+		{A: "1-9", B: "1-10", Expected: -1},
+		{A: "9-0", B: "10-0", Expected: -1},
+		{A: "10", B: "10-0", Expected: 0},
+		{A: "+", B: "10-0", Expected: 1},
+		{A: "10-0", B: "+", Expected: -1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.A+" vs "+tc.B, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, compareIDs(tc.A, tc.B))
+		})
+	}
+}
+
+func TestNextID(t *testing.T) {
+	assert.Equal(t, "0-1", nextID("0-0"))
+	assert.Equal(t, "1700000000000-4", nextID("1700000000000-3"))
+}
+
+func TestTrimmer_keeps_entries_needed_by_groups(t *testing.T) {
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+
+	addEntries := func(topic string, count int) []string {
+		ids := make([]string, 0, count)
+		for i := 0; i < count; i++ {
+			id, err := client.XAdd(ctx, &redis.XAddArgs{Stream: topic, Values: map[string]any{"n": i}}).Result()
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+		return ids
+	}
+	firstID := func(topic string) string {
+		entries, err := client.XRangeN(ctx, topic, "-", "+", 1).Result()
+		require.NoError(t, err)
+		require.NotEmpty(t, entries)
+		return entries[0].ID
+	}
+
+	// the slow group has read 4 entries and acknowledged all but the 3rd one
+	pendingIDs := addEntries("TicketBookingConfirmed", 10)
+	require.NoError(t, client.XGroupCreate(ctx, "TicketBookingConfirmed", "svc-tickets.slow", "0").Err())
+	require.NoError(t, client.XGroupCreate(ctx, "TicketBookingConfirmed", "svc-tickets.fast", "$").Err())
+	_, err := client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    "svc-tickets.slow",
+		Consumer: "consumer",
+		Streams:  []string{"TicketBookingConfirmed", ">"},
+		Count:    4,
+	}).Result()
+	require.NoError(t, err)
+	require.NoError(t, client.XAck(ctx, "TicketBookingConfirmed", "svc-tickets.slow", pendingIDs[0], pendingIDs[1], pendingIDs[3]).Err())
+
+	// the group has read 6 entries and acknowledged all of them
+	undeliveredIDs := addEntries("TicketPrinted", 10)
+	require.NoError(t, client.XGroupCreate(ctx, "TicketPrinted", "svc-tickets.printer", "0").Err())
+	_, err = client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    "svc-tickets.printer",
+		Consumer: "consumer",
+		Streams:  []string{"TicketPrinted", ">"},
+		Count:    6,
+	}).Result()
+	require.NoError(t, err)
+	require.NoError(t, client.XAck(ctx, "TicketPrinted", "svc-tickets.printer", undeliveredIDs[:6]...).Err())
+
+	// streams without the service's groups belong to other services
+	otherIDs := addEntries("OtherServiceEvent", 10)
+	require.NoError(t, client.XGroupCreate(ctx, "OtherServiceEvent", "svc-other.handler", "$").Err())
+
+	trimmer := NewTrimmer(client, "svc-tickets.", config.Retention{
+		Interval: time.Minute,
+		Default:  config.RetentionPolicy{MaxLen: 1},
+	})
+	require.NoError(t, trimmer.TrimOnce(ctx))
+
+	assert.Equal(t, pendingIDs[2], firstID("TicketBookingConfirmed"), "pending entries are kept")
+	assert.Equal(t, undeliveredIDs[6], firstID("TicketPrinted"), "undelivered entries are kept")
+	assert.Equal(t, otherIDs[0], firstID("OtherServiceEvent"), "streams of other services are not trimmed")
+
+	// once the slow group acknowledges everything, the policy applies
+	require.NoError(t, client.XAck(ctx, "TicketBookingConfirmed", "svc-tickets.slow", pendingIDs[2]).Err())
+	_, err = client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    "svc-tickets.slow",
+		Consumer: "consumer",
+		Streams:  []string{"TicketBookingConfirmed", ">"},
+	}).Result()
+	require.NoError(t, err)
+	require.NoError(t, client.XAck(ctx, "TicketBookingConfirmed", "svc-tickets.slow", pendingIDs[4:]...).Err())
+
+	require.NoError(t, trimmer.TrimOnce(ctx))
+
+	assert.Equal(t, pendingIDs[9], firstID("TicketBookingConfirmed"))
+}
@@ -0,0 +1,39 @@
+package transport
+
+import (
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+)
+
+// GoChannelTransport is an in-process Pub/Sub: messages are lost on restart and not shared between replicas.
+//
+// GoChannel delivers every message to each subscriber of a topic,
+// so a subscriber per consumer group gives the same semantics as the other transports within one process.
+type GoChannelTransport struct {
+	pubSub *gochannel.GoChannel
+}
+
+func NewGoChannel(cfg gochannel.Config, watermillLogger watermill.LoggerAdapter) *GoChannelTransport {
+	return &GoChannelTransport{
+		pubSub: gochannel.NewGoChannel(cfg, watermillLogger),
+	}
+}
+
+// Publisher closes the whole Pub/Sub on Close, so it should be closed after all subscribers.
+func (t *GoChannelTransport) Publisher() message.Publisher {
+	return t.pubSub
+}
+
+func (t *GoChannelTransport) Subscriber(consumerGroup string) (message.Subscriber, error) {
+	return sharedSubscriber{Subscriber: t.pubSub}, nil
+}
+
+// sharedSubscriber stops the router from closing the Pub/Sub shared by all handlers when one of them stops.
+type sharedSubscriber struct {
+	message.Subscriber
+}
+
+func (s sharedSubscriber) Close() error {
+	return nil
+}
@@ -0,0 +1,86 @@
+package transport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/watermill"
+	watermillSQL "github.com/ThreeDotsLabs/watermill-sql/v3/pkg/sql"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	postgresSchema = watermillSQL.DefaultPostgreSQLSchema{
+		GenerateMessagesTableName: func(topic string) string {
+			return postgresTableName("watermill_", topic)
+		},
+	}
+	postgresOffsetsAdapter = watermillSQL.DefaultPostgreSQLOffsetsAdapter{
+		GenerateMessagesOffsetsTableName: func(topic string) string {
+			return postgresTableName("watermill_offsets_", topic)
+		},
+	}
+)
+
+// maxPostgresIdentifierLength is the length Postgres truncates longer identifiers to.
+const maxPostgresIdentifierLength = 63
+
+// postgresTableName returns the quoted name of the table of topic. Names which would be truncated by Postgres,
+// such as of topics dedicated to tenants with long IDs, end with a hash of the topic instead, so they stay unique.
+func postgresTableName(prefix string, topic string) string {
+	name := prefix + topic
+	if len(name) > maxPostgresIdentifierLength {
+		hash := sha256.Sum256([]byte(topic))
+		suffix := "_" + hex.EncodeToString(hash[:8])
+		name = name[:maxPostgresIdentifierLength-len(suffix)] + suffix
+	}
+
+	return fmt.Sprintf(`"%s"`, name)
+}
+
+// PostgresTransport stores messages in per-topic tables of the service database.
+// Offsets are tracked per consumer group, so each handler consumes all messages independently.
+type PostgresTransport struct {
+	db        *sqlx.DB
+	publisher message.Publisher
+	logger    watermill.LoggerAdapter
+}
+
+func NewPostgres(db *sqlx.DB, watermillLogger watermill.LoggerAdapter) (*PostgresTransport, error) {
+	pub, err := watermillSQL.NewPublisher(
+		db,
+		watermillSQL.PublisherConfig{
+			SchemaAdapter:        postgresSchema,
+			AutoInitializeSchema: true,
+		},
+		watermillLogger,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PostgresTransport{
+		db:        db,
+		publisher: pub,
+		logger:    watermillLogger,
+	}, nil
+}
+
+func (t *PostgresTransport) Publisher() message.Publisher {
+	return t.publisher
+}
+
+func (t *PostgresTransport) Subscriber(consumerGroup string) (message.Subscriber, error) {
+	return watermillSQL.NewSubscriber(
+		t.db,
+		watermillSQL.SubscriberConfig{
+			ConsumerGroup:    consumerGroup,
+			SchemaAdapter:    postgresSchema,
+			OffsetsAdapter:   postgresOffsetsAdapter,
+			InitializeSchema: true,
+		},
+		t.logger,
+	)
+}
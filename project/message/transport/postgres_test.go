@@ -0,0 +1,22 @@
+package transport
+
+import (
+	"strings"
+	"testing"
+	"tickets/tenant"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresTableName(t *testing.T) {
+	assert.Equal(t, `"watermill_TicketBookingConfirmed"`, postgresTableName("watermill_", "TicketBookingConfirmed"))
+	assert.Equal(t, `"watermill_offsets_TicketBookingConfirmed.acme"`, postgresTableName("watermill_offsets_", tenant.Topic("TicketBookingConfirmed", "acme")))
+
+	longTenantID := strings.Repeat("a", 62)
+	confirmed := postgresTableName("watermill_offsets_", tenant.Topic("TicketBookingConfirmed", longTenantID))
+	other := postgresTableName("watermill_offsets_", tenant.Topic("TicketBookingConfirmed", longTenantID+"b"))
+
+	assert.Len(t, strings.Trim(confirmed, `"`), maxPostgresIdentifierLength)
+	assert.True(t, strings.HasPrefix(confirmed, `"watermill_offsets_TicketBookingConfirmed.aaa`))
+	assert.NotEqual(t, confirmed, other)
+}
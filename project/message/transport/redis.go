@@ -0,0 +1,52 @@
+package transport
+
+import (
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill-redisstream/pkg/redisstream"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/redis/go-redis/v9"
+)
+
+type RedisTransport struct {
+	client    redis.UniversalClient
+	publisher message.Publisher
+	logger    watermill.LoggerAdapter
+}
+
+func NewRedis(redisClient *redis.Client, watermillLogger watermill.LoggerAdapter) (*RedisTransport, error) {
+	client := sharedRedisClient{Client: redisClient}
+
+	pub, err := redisstream.NewPublisher(redisstream.PublisherConfig{
+		Client: client,
+	}, watermillLogger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisTransport{
+		client:    client,
+		publisher: pub,
+		logger:    watermillLogger,
+	}, nil
+}
+
+func (t *RedisTransport) Publisher() message.Publisher {
+	return t.publisher
+}
+
+func (t *RedisTransport) Subscriber(consumerGroup string) (message.Subscriber, error) {
+	return redisstream.NewSubscriber(redisstream.SubscriberConfig{
+		Client:        t.client,
+		ConsumerGroup: consumerGroup,
+	}, t.logger)
+}
+
+// sharedRedisClient stops Watermill publishers and subscribers from closing the client on their Close:
+// the client is shared by the whole service, which closes it after they are all closed.
+type sharedRedisClient struct {
+	*redis.Client
+}
+
+func (c sharedRedisClient) Close() error {
+	return nil
+}
@@ -0,0 +1,59 @@
+package transport
+
+import (
+	"fmt"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	Redis     = "redis"
+	Postgres  = "postgres"
+	GoChannel = "gochannel"
+)
+
+// Transport provides the Pub/Sub the service publishes events to and consumes them from.
+type Transport interface {
+	Publisher() message.Publisher
+
+	// Subscriber creates a subscriber for the consumer group.
+	// Each consumer group receives every message published to a topic once.
+	Subscriber(consumerGroup string) (message.Subscriber, error)
+}
+
+// New creates the transport by its name.
+// redisClient and db are used only by the transports that need them and may be nil otherwise.
+func New(
+	name string,
+	redisClient *redis.Client,
+	db *sqlx.DB,
+	watermillLogger watermill.LoggerAdapter,
+) (Transport, error) {
+	switch name {
+	case Redis:
+		if redisClient == nil {
+			return nil, fmt.Errorf("%s transport requires Redis client", name)
+		}
+		return NewRedis(redisClient, watermillLogger)
+	case Postgres:
+		if db == nil {
+			return nil, fmt.Errorf("%s transport requires database", name)
+		}
+		return NewPostgres(db, watermillLogger)
+	case GoChannel:
+		return NewGoChannel(gochannel.Config{}, watermillLogger), nil
+	default:
+		return nil, fmt.Errorf("unknown transport %q", name)
+	}
+}
+
+// CompetingConsumers tells if subscribers of the same consumer group handle its messages concurrently,
+// each message being delivered to one of them.
+func CompetingConsumers(t Transport) bool {
+	_, ok := t.(*RedisTransport)
+	return ok
+}
@@ -0,0 +1,107 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"tickets/config"
+	"tickets/entities"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+)
+
+// sendingTTL is how long a notification is claimed while it's sent. If the instance sending it stops,
+// the notification can be sent again after that.
+const sendingTTL = time.Minute
+
+// Mailer emails customers when their tickets are confirmed or canceled.
+//
+// Each event is notified once: the notification is claimed in SentStore by the event ID before it's sent,
+// so redelivered events don't send it again. A notification which failed to send is released, and sent
+// again when the event is retried.
+type Mailer struct {
+	notifier Notifier
+	sent     SentStore
+	config   config.Notifications
+}
+
+func NewMailer(notifier Notifier, sent SentStore, cfg config.Notifications) *Mailer {
+	if notifier == nil {
+		panic("missing notifier")
+	}
+	if sent == nil {
+		panic("missing sent store")
+	}
+
+	return &Mailer{
+		notifier: notifier,
+		sent:     sent,
+		config:   cfg,
+	}
+}
+
+func (m *Mailer) NotifyTicketBookingConfirmed(ctx context.Context, event *entities.TicketBookingConfirmed) error {
+	return m.send(ctx, event.Header.ID, event.CustomerEmail, TemplateTicketBookingConfirmed, event)
+}
+
+func (m *Mailer) NotifyTicketBookingCanceled(ctx context.Context, event *entities.TicketBookingCanceled) error {
+	return m.send(ctx, event.Header.ID, event.CustomerEmail, TemplateTicketBookingCanceled, event)
+}
+
+func (m *Mailer) send(ctx context.Context, eventID string, to string, templateName string, data any) error {
+	logger := log.FromContext(ctx).WithField("template", templateName)
+
+	address, err := mail.ParseAddress(to)
+	if err != nil {
+		// retrying won't fix the address
+		logger.WithError(err).Warn("Not notifying customer with invalid email address")
+		return nil
+	}
+
+	email, err := Render(templateName, data)
+	if err != nil {
+		return err
+	}
+	email.To = address.Address
+
+	// events without an ID can't be deduplicated
+	notificationID := ""
+	if eventID != "" {
+		notificationID = templateName + ":" + eventID
+
+		claimed, err := m.sent.Claim(ctx, notificationID, sendingTTL)
+		if err != nil {
+			return fmt.Errorf("failed to claim notification: %w", err)
+		}
+		if !claimed {
+			logger.WithField("notification_id", notificationID).Info("Notification already sent, skipping")
+			return nil
+		}
+	}
+
+	if err := m.notifier.Send(ctx, email); err != nil {
+		err = fmt.Errorf("failed to send %s email: %w", templateName, err)
+
+		if notificationID != "" {
+			// the claim is released even when the handler was cancelled, so the retry can send the email
+			if releaseErr := m.sent.Release(context.WithoutCancel(ctx), notificationID); releaseErr != nil {
+				err = errors.Join(err, fmt.Errorf("failed to release notification: %w", releaseErr))
+			}
+		}
+
+		return err
+	}
+
+	if notificationID != "" {
+		if err := m.sent.MarkSent(context.WithoutCancel(ctx), notificationID, m.config.DeduplicationTTL); err != nil {
+			// the email is sent, failing would send it again
+			logger.WithError(err).Error("Failed to mark notification as sent")
+		}
+	}
+
+	logger.Info("Customer notified")
+
+	return nil
+}
@@ -0,0 +1,54 @@
+// Package notifications emails customers about their tickets.
+//
+// Emails are rendered from templates and sent with a Notifier: over SMTP, or to an outbox directory
+// for local development. Mailer sends each notification once, even when its event is redelivered.
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Email struct {
+	To      string
+	Subject string
+	// Body is plain text.
+	Body string
+}
+
+type Notifier interface {
+	Send(ctx context.Context, email Email) error
+}
+
+// formatMessage renders the email as an RFC 5322 message, with CRLF line endings.
+func formatMessage(from string, email Email, now time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	w := quotedprintable.NewWriter(&body)
+	if _, err := w.Write([]byte(strings.ReplaceAll(email.Body, "\n", "\r\n"))); err != nil {
+		return nil, fmt.Errorf("failed to encode body: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode body: %w", err)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", email.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Message-ID: <%s@tickets>\r\n", uuid.NewString())
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+
+	return msg.Bytes(), nil
+}
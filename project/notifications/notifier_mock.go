@@ -0,0 +1,48 @@
+package notifications
+
+import (
+	"context"
+	"sync"
+)
+
+// NotifierMock records emails instead of sending them.
+type NotifierMock struct {
+	lock sync.Mutex
+
+	// Err is returned by Send when set, and the email isn't recorded.
+	Err error
+
+	Emails []Email
+}
+
+func (n *NotifierMock) Send(ctx context.Context, email Email) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.Err != nil {
+		return n.Err
+	}
+
+	n.Emails = append(n.Emails, email)
+
+	return nil
+}
+
+// Sent returns a copy of sent emails, safe to use while the mock is being called.
+func (n *NotifierMock) Sent() []Email {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	sent := make([]Email, len(n.Emails))
+	copy(sent, n.Emails)
+
+	return sent
+}
+
+// SetErr makes Send fail with err, or succeed again when err is nil.
+func (n *NotifierMock) SetErr(err error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.Err = err
+}
@@ -0,0 +1,53 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxNotifier writes emails as .eml files to a directory instead of sending them, for local development.
+// The files can be opened with most email clients.
+type OutboxNotifier struct {
+	from string
+	dir  string
+}
+
+func NewOutboxNotifier(from string, dir string) *OutboxNotifier {
+	if from == "" {
+		panic("missing from")
+	}
+	if dir == "" {
+		panic("missing dir")
+	}
+
+	return &OutboxNotifier{
+		from: from,
+		dir:  dir,
+	}
+}
+
+func (n *OutboxNotifier) Send(ctx context.Context, email Email) error {
+	now := time.Now()
+
+	msg, err := formatMessage(n.from, email, now)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(n.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create outbox: %w", err)
+	}
+
+	// the timestamp sorts emails by when they were sent
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), uuid.NewString())
+	if err := os.WriteFile(filepath.Join(n.dir, name), msg, 0o644); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package notifications
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+	"tickets/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxNotifier(t *testing.T) {
+	dir := t.TempDir()
+	notifier := NewOutboxNotifier("tickets@example.com", dir)
+
+	email, err := Render(TemplateTicketBookingConfirmed, entities.TicketBookingConfirmed{
+		TicketID:      "ticket-1",
+		CustomerEmail: "customer@example.com",
+		Price:         entities.Money{Amount: "49.90", Currency: "EUR"},
+		BookingID:     "booking-1",
+	})
+	require.NoError(t, err)
+	email.To = "customer@example.com"
+
+	require.NoError(t, notifier.Send(context.Background(), email))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	f, err := os.Open(files[0])
+	require.NoError(t, err)
+	defer f.Close()
+
+	msg, err := mail.ReadMessage(f)
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "tickets@example.com", msg.Header.Get("From"))
+	assert.Equal(t, "customer@example.com", msg.Header.Get("To"))
+	assert.Equal(t, "Your ticket is confirmed", subject)
+
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "ticket-1")
+	assert.Contains(t, string(body), "Price: 49.90 EUR\r\nBooking: booking-1\r\n")
+}
@@ -0,0 +1,110 @@
+package notifications
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SentStore remembers notifications which were sent, or are being sent, by their IDs.
+type SentStore interface {
+	// Claim returns false if the notification was sent, or is being sent, already.
+	Claim(ctx context.Context, notificationID string, ttl time.Duration) (bool, error)
+	// MarkSent keeps the claimed notification from being sent again for ttl.
+	MarkSent(ctx context.Context, notificationID string, ttl time.Duration) error
+	// Release lets the claimed notification be sent again, after sending it failed.
+	Release(ctx context.Context, notificationID string) error
+}
+
+const redisSentKeyPrefix = "tickets:notifications:sent:"
+
+// RedisSentStore keeps sent notifications in Redis, so they are shared by all service instances.
+type RedisSentStore struct {
+	client *redis.Client
+}
+
+func NewRedisSentStore(client *redis.Client) *RedisSentStore {
+	if client == nil {
+		panic("missing redis client")
+	}
+
+	return &RedisSentStore{client: client}
+}
+
+func (s *RedisSentStore) Claim(ctx context.Context, notificationID string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, redisSentKeyPrefix+notificationID, "sending", ttl).Result()
+}
+
+func (s *RedisSentStore) MarkSent(ctx context.Context, notificationID string, ttl time.Duration) error {
+	return s.client.Set(ctx, redisSentKeyPrefix+notificationID, "sent", ttl).Err()
+}
+
+func (s *RedisSentStore) Release(ctx context.Context, notificationID string) error {
+	return s.client.Del(ctx, redisSentKeyPrefix+notificationID).Err()
+}
+
+// memorySweepInterval is how often MemorySentStore removes expired notifications. Notifications are
+// checked for expiry when claimed, so sweeping only bounds the memory expired notifications take.
+const memorySweepInterval = time.Minute
+
+// MemorySentStore keeps sent notifications in memory, for single instance deployments without Redis.
+type MemorySentStore struct {
+	lock      sync.Mutex
+	expires   map[string]time.Time
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemorySentStore() *MemorySentStore {
+	return &MemorySentStore{
+		expires: map[string]time.Time{},
+		now:     time.Now,
+	}
+}
+
+func (s *MemorySentStore) Claim(ctx context.Context, notificationID string, ttl time.Duration) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+
+	if !now.Before(s.nextSweep) {
+		s.sweep(now)
+		s.nextSweep = now.Add(memorySweepInterval)
+	}
+
+	if expires, ok := s.expires[notificationID]; ok && now.Before(expires) {
+		return false, nil
+	}
+	s.expires[notificationID] = now.Add(ttl)
+
+	return true, nil
+}
+
+func (s *MemorySentStore) MarkSent(ctx context.Context, notificationID string, ttl time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.expires[notificationID] = s.now().Add(ttl)
+
+	return nil
+}
+
+func (s *MemorySentStore) Release(ctx context.Context, notificationID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.expires, notificationID)
+
+	return nil
+}
+
+func (s *MemorySentStore) sweep(now time.Time) {
+	for id, expires := range s.expires {
+		if !now.Before(expires) {
+			delete(s.expires, id)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package notifications
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemorySentStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	store := NewMemorySentStore()
+	store.now = func() time.Time { return now }
+
+	claimed, err := store.Claim(ctx, "1", time.Second)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = store.Claim(ctx, "1", time.Second)
+	require.NoError(t, err)
+	assert.False(t, claimed, "notifications being sent can't be claimed")
+
+	require.NoError(t, store.MarkSent(ctx, "1", time.Second))
+
+	now = now.Add(2 * time.Second)
+	claimed, err = store.Claim(ctx, "2", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Len(t, store.expires, 2, "expired notifications are kept until the next sweep")
+
+	claimed, err = store.Claim(ctx, "1", time.Second)
+	require.NoError(t, err)
+	assert.True(t, claimed, "expired notifications can be claimed again")
+
+	now = now.Add(memorySweepInterval + time.Second)
+	_, err = store.Claim(ctx, "3", time.Hour)
+	require.NoError(t, err)
+	assert.Len(t, store.expires, 2)
+}
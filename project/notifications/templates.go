@@ -0,0 +1,50 @@
+package notifications
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Templates in templates/<name>.tmpl define "subject" and "body" of an email.
+const (
+	TemplateTicketBookingConfirmed = "ticket_booking_confirmed"
+	TemplateTicketBookingCanceled  = "ticket_booking_canceled"
+)
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+// templates are parsed separately, as all of them define the same names.
+var templates = map[string]*template.Template{
+	TemplateTicketBookingConfirmed: mustParseTemplate(TemplateTicketBookingConfirmed),
+	TemplateTicketBookingCanceled:  mustParseTemplate(TemplateTicketBookingCanceled),
+}
+
+func mustParseTemplate(name string) *template.Template {
+	return template.Must(template.New(name).Option("missingkey=error").ParseFS(templateFiles, "templates/"+name+".tmpl"))
+}
+
+// Render renders the email of the template with data, without its recipient.
+func Render(templateName string, data any) (Email, error) {
+	tmpl, ok := templates[templateName]
+	if !ok {
+		return Email{}, fmt.Errorf("unknown email template %q", templateName)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Email{}, fmt.Errorf("failed to render subject of %s: %w", templateName, err)
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return Email{}, fmt.Errorf("failed to render body of %s: %w", templateName, err)
+	}
+
+	return Email{
+		// a new line would end the header
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Body:    body.String(),
+	}, nil
+}
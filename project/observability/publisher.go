@@ -0,0 +1,47 @@
+package observability
+
+import (
+	"fmt"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type TracingPublisherDecorator struct {
+	message.Publisher
+}
+
+// Publish starts a span per message, as a child of the span in its context,
+// so each message carries the trace of the code which published it.
+func (p TracingPublisherDecorator) Publish(topic string, messages ...*message.Message) error {
+	spans := make([]trace.Span, 0, len(messages))
+	for _, msg := range messages {
+		ctx, span := Tracer().Start(
+			msg.Context(),
+			fmt.Sprintf("publish %s", topic),
+			trace.WithSpanKind(trace.SpanKindProducer),
+			trace.WithAttributes(
+				attribute.String("messaging.destination.name", topic),
+				attribute.String("messaging.message.id", msg.UUID),
+			),
+		)
+		otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(msg.Metadata))
+		spans = append(spans, span)
+	}
+
+	err := p.Publisher.Publish(topic, messages...)
+
+	for _, span := range spans {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+
+	return err
+}
@@ -0,0 +1,51 @@
+package observability_test
+
+import (
+	"context"
+	"testing"
+	"tickets/observability"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingPublisherDecorator_span_per_message(t *testing.T) {
+	tp, exporter := observability.ConfigureInMemoryTraceProvider()
+	defer tp.Shutdown(context.Background())
+
+	pubSub := gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{})
+	defer pubSub.Close()
+	publisher := observability.TracingPublisherDecorator{Publisher: pubSub}
+
+	var parents []trace.Span
+	var messages []*message.Message
+	for i := 0; i < 2; i++ {
+		ctx, parent := observability.Tracer().Start(context.Background(), "parent")
+		parents = append(parents, parent)
+
+		msg := message.NewMessage(watermill.NewUUID(), nil)
+		msg.SetContext(ctx)
+		messages = append(messages, msg)
+	}
+
+	require.NoError(t, publisher.Publish("topic", messages...))
+
+	for i, msg := range messages {
+		ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier(msg.Metadata))
+		injected := trace.SpanContextFromContext(ctx)
+
+		assert.Equal(t, parents[i].SpanContext().TraceID(), injected.TraceID(), "message %d should carry the trace of its context", i)
+	}
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	for i, span := range spans {
+		assert.Equal(t, parents[i].SpanContext().SpanID(), span.Parent.SpanID())
+	}
+}
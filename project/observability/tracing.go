@@ -0,0 +1,57 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const ServiceName = "tickets"
+
+func Tracer() trace.Tracer {
+	return otel.Tracer(ServiceName)
+}
+
+// ConfigureOTLPTraceProvider exports spans over OTLP/HTTP.
+// The endpoint is taken from the standard OTEL_EXPORTER_OTLP_* environment variables.
+func ConfigureOTLPTraceProvider(ctx context.Context) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	return configureTraceProvider(sdktrace.WithBatcher(exporter)), nil
+}
+
+// ConfigureInMemoryTraceProvider keeps finished spans in memory, so tests can assert on them.
+func ConfigureInMemoryTraceProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+
+	return configureTraceProvider(sdktrace.WithSyncer(exporter)), exporter
+}
+
+func configureTraceProvider(exporterOption sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	tp := sdktrace.NewTracerProvider(
+		exporterOption,
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(ServiceName),
+		)),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return tp
+}
@@ -0,0 +1,112 @@
+package reconciliation
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"tickets/entities"
+	"time"
+)
+
+// MemoryRepository keeps tickets in memory, for running without PostgreSQL.
+type MemoryRepository struct {
+	lock    sync.Mutex
+	tickets map[string]Ticket
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{tickets: map[string]Ticket{}}
+}
+
+func (r *MemoryRepository) SaveConfirmed(ctx context.Context, tenantID string, event entities.TicketBookingConfirmed) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	ticket := r.ticket(tenantID, event.TicketID, event.CustomerEmail, event.Price)
+	if ticket.Confirmed == nil {
+		header := withTenant(event.Header, tenantID)
+		ticket.Confirmed = &header
+	}
+	if ticket.BookingID == "" {
+		ticket.BookingID = event.BookingID
+	}
+	r.tickets[event.TicketID] = ticket
+
+	return nil
+}
+
+func (r *MemoryRepository) SaveCanceled(ctx context.Context, tenantID string, event entities.TicketBookingCanceled) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	ticket := r.ticket(tenantID, event.TicketID, event.CustomerEmail, event.Price)
+	if ticket.Canceled == nil {
+		header := withTenant(event.Header, tenantID)
+		ticket.Canceled = &header
+	}
+	r.tickets[event.TicketID] = ticket
+
+	return nil
+}
+
+func (r *MemoryRepository) ListChanged(ctx context.Context, from time.Time, to time.Time) ([]Ticket, error) {
+	return r.list(func(ticket Ticket) bool {
+		return ticket.ConfirmedBetween(from, to) || ticket.CanceledBetween(from, to)
+	}), nil
+}
+
+func (r *MemoryRepository) MarkRepublished(ctx context.Context, ticketID string, eventID string, at time.Time) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	ticket, ok := r.tickets[ticketID]
+	if !ok {
+		return nil
+	}
+
+	if ticket.Confirmed != nil && ticket.Confirmed.ID == eventID {
+		ticket.ConfirmedRepublishedAt = at
+	}
+	if ticket.Canceled != nil && ticket.Canceled.ID == eventID {
+		ticket.CanceledRepublishedAt = at
+	}
+	r.tickets[ticketID] = ticket
+
+	return nil
+}
+
+func (r *MemoryRepository) list(include func(ticket Ticket) bool) []Ticket {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var tickets []Ticket
+	for _, ticket := range r.tickets {
+		if include(ticket) {
+			tickets = append(tickets, ticket)
+		}
+	}
+
+	sort.Slice(tickets, func(i, j int) bool {
+		return tickets[i].TicketID < tickets[j].TicketID
+	})
+
+	return tickets
+}
+
+func (r *MemoryRepository) ticket(tenantID string, ticketID string, customerEmail string, price entities.Money) Ticket {
+	if ticket, ok := r.tickets[ticketID]; ok {
+		return ticket
+	}
+
+	return Ticket{
+		TicketID:      ticketID,
+		TenantID:      tenantID,
+		CustomerEmail: customerEmail,
+		Price:         price,
+	}
+}
+
+func withTenant(header entities.EventHeader, tenantID string) entities.EventHeader {
+	header.TenantID = tenantID
+	return header
+}
@@ -0,0 +1,39 @@
+package reconciliation
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	missingRows = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "tickets",
+		Subsystem: "reconciliation",
+		Name:      "missing_rows",
+		Help:      "Number of tickets without their row in the sheet, found by the last reconciliation.",
+	}, []string{"sheet"})
+	duplicateRows = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "tickets",
+		Subsystem: "reconciliation",
+		Name:      "duplicate_rows",
+		Help:      "Number of tickets with more than one row in the sheet, found by the last reconciliation.",
+	}, []string{"sheet"})
+	republishedEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "tickets",
+		Subsystem: "reconciliation",
+		Name:      "republished_events_total",
+		Help:      "Number of events published again to append missing rows.",
+	}, []string{"sheet"})
+	runs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "tickets",
+		Subsystem: "reconciliation",
+		Name:      "runs_total",
+		Help:      "Number of reconciliation runs.",
+	}, []string{"result"})
+	lastSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "tickets",
+		Subsystem: "reconciliation",
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful reconciliation.",
+	})
+)
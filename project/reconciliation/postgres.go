@@ -0,0 +1,166 @@
+package reconciliation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"tickets/entities"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresRepository keeps events of tickets in the tickets table created by db/schema.sql, next to tickets
+// reserved by the booking saga.
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	if db == nil {
+		panic("missing db")
+	}
+
+	return &PostgresRepository{db: db}
+}
+
+type ticketRow struct {
+	TicketID               string         `db:"ticket_id"`
+	TenantID               string         `db:"tenant_id"`
+	CustomerEmail          string         `db:"customer_email"`
+	PriceAmount            string         `db:"price_amount"`
+	PriceCurrency          string         `db:"price_currency"`
+	BookingID              sql.NullString `db:"booking_id"`
+	ConfirmedEventID       sql.NullString `db:"confirmed_event_id"`
+	ConfirmedAt            sql.NullTime   `db:"confirmed_at"`
+	ConfirmedRepublishedAt sql.NullTime   `db:"confirmed_republished_at"`
+	CanceledEventID        sql.NullString `db:"canceled_event_id"`
+	CanceledAt             sql.NullTime   `db:"canceled_at"`
+	CanceledRepublishedAt  sql.NullTime   `db:"canceled_republished_at"`
+}
+
+func (r ticketRow) ticket() Ticket {
+	ticket := Ticket{
+		TicketID:      r.TicketID,
+		TenantID:      r.TenantID,
+		CustomerEmail: r.CustomerEmail,
+		Price:         entities.Money{Amount: r.PriceAmount, Currency: r.PriceCurrency},
+		BookingID:     r.BookingID.String,
+	}
+
+	if r.ConfirmedEventID.Valid {
+		ticket.Confirmed = &entities.EventHeader{
+			ID:          r.ConfirmedEventID.String,
+			PublishedAt: r.ConfirmedAt.Time.UTC(),
+			TenantID:    r.TenantID,
+		}
+		ticket.ConfirmedRepublishedAt = r.ConfirmedRepublishedAt.Time.UTC()
+	}
+	if r.CanceledEventID.Valid {
+		ticket.Canceled = &entities.EventHeader{
+			ID:          r.CanceledEventID.String,
+			PublishedAt: r.CanceledAt.Time.UTC(),
+			TenantID:    r.TenantID,
+		}
+		ticket.CanceledRepublishedAt = r.CanceledRepublishedAt.Time.UTC()
+	}
+
+	return ticket
+}
+
+func (r *PostgresRepository) SaveConfirmed(ctx context.Context, tenantID string, event entities.TicketBookingConfirmed) error {
+	// the first confirmation is kept, so a redelivered event doesn't replace it
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO tickets
+			(ticket_id, tenant_id, customer_email, price_amount, price_currency, booking_id, confirmed_event_id, confirmed_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)
+		ON CONFLICT (ticket_id) DO UPDATE SET
+			confirmed_event_id = COALESCE(tickets.confirmed_event_id, EXCLUDED.confirmed_event_id),
+			confirmed_at = COALESCE(tickets.confirmed_at, EXCLUDED.confirmed_at),
+			booking_id = COALESCE(tickets.booking_id, EXCLUDED.booking_id)`,
+		event.TicketID,
+		tenantID,
+		event.CustomerEmail,
+		event.Price.Amount,
+		event.Price.Currency,
+		event.BookingID,
+		event.Header.ID,
+		event.Header.PublishedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save confirmed ticket %s: %w", event.TicketID, err)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) SaveCanceled(ctx context.Context, tenantID string, event entities.TicketBookingCanceled) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO tickets
+			(ticket_id, tenant_id, customer_email, price_amount, price_currency, canceled_event_id, canceled_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (ticket_id) DO UPDATE SET
+			canceled_event_id = COALESCE(tickets.canceled_event_id, EXCLUDED.canceled_event_id),
+			canceled_at = COALESCE(tickets.canceled_at, EXCLUDED.canceled_at)`,
+		event.TicketID,
+		tenantID,
+		event.CustomerEmail,
+		event.Price.Amount,
+		event.Price.Currency,
+		event.Header.ID,
+		event.Header.PublishedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save canceled ticket %s: %w", event.TicketID, err)
+	}
+
+	return nil
+}
+
+const ticketColumns = `ticket_id, tenant_id, customer_email, price_amount, price_currency, booking_id,
+	confirmed_event_id, confirmed_at, confirmed_republished_at, canceled_event_id, canceled_at, canceled_republished_at`
+
+func (r *PostgresRepository) ListChanged(ctx context.Context, from time.Time, to time.Time) ([]Ticket, error) {
+	return r.list(
+		ctx,
+		`SELECT `+ticketColumns+` FROM tickets
+		WHERE (confirmed_at >= $1 AND confirmed_at < $2) OR (canceled_at >= $1 AND canceled_at < $2)
+		ORDER BY ticket_id`,
+		from,
+		to,
+	)
+}
+
+func (r *PostgresRepository) MarkRepublished(ctx context.Context, ticketID string, eventID string, at time.Time) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE tickets SET
+			confirmed_republished_at = CASE WHEN confirmed_event_id = $2 THEN $3 ELSE confirmed_republished_at END,
+			canceled_republished_at = CASE WHEN canceled_event_id = $2 THEN $3 ELSE canceled_republished_at END
+		WHERE ticket_id = $1`,
+		ticketID,
+		eventID,
+		at,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark event %s of ticket %s republished: %w", eventID, ticketID, err)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) list(ctx context.Context, query string, args ...any) ([]Ticket, error) {
+	var rows []ticketRow
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list tickets: %w", err)
+	}
+
+	tickets := make([]Ticket, 0, len(rows))
+	for _, row := range rows {
+		tickets = append(tickets, row.ticket())
+	}
+
+	return tickets, nil
+}
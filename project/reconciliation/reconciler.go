@@ -0,0 +1,320 @@
+package reconciliation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"tickets/config"
+	"tickets/entities"
+	"tickets/sheets"
+	"tickets/tenant"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/sirupsen/logrus"
+)
+
+const ticketIDColumn = "Ticket ID"
+
+type SpreadsheetsAPI interface {
+	Rows(ctx context.Context, sheetName string) ([][]string, error)
+}
+
+type EventBus interface {
+	Publish(ctx context.Context, event any) error
+}
+
+// Report is the result of a reconciliation.
+type Report struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Sheets     []SheetReport
+}
+
+// SheetReport compares tickets with rows of a sheet of a tenant.
+type SheetReport struct {
+	TenantID string
+	Sheet    string
+	// Tickets is how many tickets checked should have their row in the sheet.
+	Tickets int
+	// Rows is how many rows the sheet has, without the header row.
+	Rows int
+	// Missing are IDs of tickets without their row.
+	Missing []string
+	// Duplicates are tickets with more than one row, including rows of tickets not known to the service.
+	Duplicates []Duplicate
+	// Republished is how many events of tickets with missing rows were published again.
+	Republished int
+}
+
+type Duplicate struct {
+	TicketID string
+	Rows     int
+}
+
+// Reconciler finds tickets confirmed or canceled by events whose rows are missing in, or duplicated by,
+// the tickets-to-print and tickets-to-refund sheets.
+//
+// Tickets confirmed or canceled within the grace period are not reported missing, their events may be
+// still handled, and neither are ones confirmed or canceled more than the window before it. With Republish,
+// the original events of tickets with missing rows are published again: all their handlers handle them again,
+// like redelivered events. The grace period applies again from the last time an event was republished by any
+// replica, so it's not republished on every run.
+type Reconciler struct {
+	repo         Repository
+	spreadsheets SpreadsheetsAPI
+	eventBus     EventBus
+	sheets       config.Spreadsheets
+	tenants      config.Tenants
+	config       config.Reconciliation
+	now          func() time.Time
+
+	lock       sync.Mutex
+	lastReport *Report
+}
+
+func NewReconciler(
+	repo Repository,
+	spreadsheets SpreadsheetsAPI,
+	eventBus EventBus,
+	sheetsConfig config.Spreadsheets,
+	tenants config.Tenants,
+	cfg config.Reconciliation,
+) *Reconciler {
+	if repo == nil {
+		panic("missing repo")
+	}
+	if spreadsheets == nil {
+		panic("missing spreadsheets")
+	}
+	if eventBus == nil {
+		panic("missing eventBus")
+	}
+
+	return &Reconciler{
+		repo:         repo,
+		spreadsheets: spreadsheets,
+		eventBus:     eventBus,
+		sheets:       sheetsConfig,
+		tenants:      tenants,
+		config:       cfg,
+		now:          time.Now,
+	}
+}
+
+// Run reconciles every interval, until ctx is done.
+func (r *Reconciler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := r.Reconcile(ctx); err != nil {
+				log.FromContext(ctx).WithError(err).Error("Failed to reconcile tickets with spreadsheets")
+			}
+		}
+	}
+}
+
+// LastReport returns the report of the last successful reconciliation, false if there was none.
+func (r *Reconciler) LastReport() (Report, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.lastReport == nil {
+		return Report{}, false
+	}
+
+	return *r.lastReport, true
+}
+
+// Reconcile compares tickets with rows of sheets of all tenants. Events which failed to republish are logged,
+// they are missing from Republished of the report.
+func (r *Reconciler) Reconcile(ctx context.Context) (Report, error) {
+	ctx = log.ContextWithCorrelationID(ctx, "reconciliation_"+watermill.NewShortUUID())
+	report := Report{StartedAt: r.now().UTC()}
+
+	// tickets due are confirmed or canceled before the grace period, within the window
+	cutoff := report.StartedAt.Add(-r.config.GracePeriod)
+	from := cutoff.Add(-r.config.Window)
+
+	tickets, err := r.repo.ListChanged(ctx, from, cutoff)
+	if err != nil {
+		runs.WithLabelValues("failure").Inc()
+		return Report{}, err
+	}
+
+	// tickets which should have their row, by tenant
+	toPrint := map[string][]Ticket{}
+	toRefund := map[string][]Ticket{}
+	for _, ticket := range tickets {
+		if due(ticket.Confirmed, ticket.ConfirmedRepublishedAt, from, cutoff) {
+			toPrint[ticket.TenantID] = append(toPrint[ticket.TenantID], ticket)
+		}
+		if due(ticket.Canceled, ticket.CanceledRepublishedAt, from, cutoff) {
+			toRefund[ticket.TenantID] = append(toRefund[ticket.TenantID], ticket)
+		}
+	}
+
+	for _, tenantID := range TenantIDs(r.tenants, tickets) {
+		tenantSheets := r.tenants.Spreadsheets(tenantID, r.sheets)
+
+		printReport, err := r.reconcileSheet(ctx, tenantID, tenantSheets.TicketsToPrint, sheets.TicketsToPrint.Header(), toPrint[tenantID], r.republishConfirmed)
+		if err != nil {
+			runs.WithLabelValues("failure").Inc()
+			return Report{}, err
+		}
+		refundReport, err := r.reconcileSheet(ctx, tenantID, tenantSheets.TicketsToRefund, sheets.TicketsToRefund.Header(), toRefund[tenantID], r.republishCanceled)
+		if err != nil {
+			runs.WithLabelValues("failure").Inc()
+			return Report{}, err
+		}
+
+		report.Sheets = append(report.Sheets, printReport, refundReport)
+	}
+
+	report.FinishedAt = r.now().UTC()
+
+	r.lock.Lock()
+	r.lastReport = &report
+	r.lock.Unlock()
+
+	runs.WithLabelValues("success").Inc()
+	lastSuccess.Set(float64(report.FinishedAt.Unix()))
+
+	log.FromContext(ctx).WithField("sheets", len(report.Sheets)).Info("Reconciled tickets with spreadsheets")
+
+	return report, nil
+}
+
+func (r *Reconciler) reconcileSheet(
+	ctx context.Context,
+	tenantID string,
+	sheetName string,
+	header []string,
+	tickets []Ticket,
+	republish func(ctx context.Context, ticket Ticket) error,
+) (SheetReport, error) {
+	logger := log.FromContext(ctx).WithField("sheet", sheetName)
+
+	rows, err := r.spreadsheets.Rows(ctx, sheetName)
+	if err != nil {
+		return SheetReport{}, fmt.Errorf("failed to read rows of %s: %w", sheetName, err)
+	}
+
+	rowsByTicket := map[string]int{}
+	ticketIDs := sheets.Values(rows, header, ticketIDColumn)
+	for _, ticketID := range ticketIDs {
+		rowsByTicket[ticketID]++
+	}
+
+	report := SheetReport{
+		TenantID: tenantID,
+		Sheet:    sheetName,
+		Tickets:  len(tickets),
+		Rows:     len(ticketIDs),
+	}
+
+	for ticketID, count := range rowsByTicket {
+		if count > 1 && ticketID != "" {
+			report.Duplicates = append(report.Duplicates, Duplicate{TicketID: ticketID, Rows: count})
+		}
+	}
+	sort.Slice(report.Duplicates, func(i, j int) bool {
+		return report.Duplicates[i].TicketID < report.Duplicates[j].TicketID
+	})
+
+	for _, ticket := range tickets {
+		if rowsByTicket[ticket.TicketID] > 0 {
+			continue
+		}
+
+		report.Missing = append(report.Missing, ticket.TicketID)
+
+		if !r.config.Republish {
+			continue
+		}
+		if err := republish(ctx, ticket); err != nil {
+			logger.WithError(err).WithField("ticket_id", ticket.TicketID).Error("Failed to republish event of ticket with missing row")
+			continue
+		}
+		report.Republished++
+		republishedEvents.WithLabelValues(sheetName).Inc()
+	}
+
+	missingRows.WithLabelValues(sheetName).Set(float64(len(report.Missing)))
+	duplicateRows.WithLabelValues(sheetName).Set(float64(len(report.Duplicates)))
+
+	if len(report.Missing) > 0 || len(report.Duplicates) > 0 {
+		logger.WithFields(logrus.Fields{
+			"missing":    len(report.Missing),
+			"duplicates": len(report.Duplicates),
+		}).Warn("Sheet doesn't match tickets")
+	}
+
+	return report, nil
+}
+
+// due tells if the row of the event should be in its sheet: the event was published within from and cutoff,
+// and wasn't republished since cutoff.
+func due(header *entities.EventHeader, republishedAt time.Time, from time.Time, cutoff time.Time) bool {
+	return header != nil &&
+		!header.PublishedAt.Before(from) &&
+		header.PublishedAt.Before(cutoff) &&
+		republishedAt.Before(cutoff)
+}
+
+func (r *Reconciler) republishConfirmed(ctx context.Context, ticket Ticket) error {
+	err := r.eventBus.Publish(ctx, entities.TicketBookingConfirmed{
+		Header:        *ticket.Confirmed,
+		TicketID:      ticket.TicketID,
+		CustomerEmail: ticket.CustomerEmail,
+		Price:         ticket.Price,
+		BookingID:     ticket.BookingID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.repo.MarkRepublished(ctx, ticket.TicketID, ticket.Confirmed.ID, r.now().UTC())
+}
+
+func (r *Reconciler) republishCanceled(ctx context.Context, ticket Ticket) error {
+	err := r.eventBus.Publish(ctx, entities.TicketBookingCanceled{
+		Header:        *ticket.Canceled,
+		TicketID:      ticket.TicketID,
+		CustomerEmail: ticket.CustomerEmail,
+		Price:         ticket.Price,
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.repo.MarkRepublished(ctx, ticket.TicketID, ticket.Canceled.ID, r.now().UTC())
+}
+
+// TenantIDs returns configured tenants and tenants of tickets, the default one first.
+func TenantIDs(tenants config.Tenants, tickets []Ticket) []string {
+	known := map[string]bool{tenant.DefaultID: true}
+	for tenantID := range tenants {
+		known[tenantID] = true
+	}
+	for _, ticket := range tickets {
+		known[ticket.TenantID] = true
+	}
+
+	ids := make([]string, 0, len(known))
+	for tenantID := range known {
+		if tenantID != tenant.DefaultID {
+			ids = append(ids, tenantID)
+		}
+	}
+	sort.Strings(ids)
+
+	return append([]string{tenant.DefaultID}, ids...)
+}
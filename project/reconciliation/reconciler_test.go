@@ -0,0 +1,203 @@
+package reconciliation_test
+
+import (
+	"context"
+	"testing"
+	"tickets/api"
+	"tickets/config"
+	"tickets/entities"
+	"tickets/message/event"
+	"tickets/reconciliation"
+	"tickets/sheets"
+	"tickets/tenant"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconciler(t *testing.T) {
+	ctx := tenant.ContextWithID(context.Background(), tenant.DefaultID)
+
+	repo := reconciliation.NewMemoryRepository()
+	recorder := reconciliation.NewRecorder(repo)
+
+	longAgo := time.Now().Add(-time.Hour).UTC()
+
+	appended := confirmed("ticket-appended", longAgo)
+	missing := confirmed("ticket-missing", longAgo)
+	// its row may be still appended
+	recent := confirmed("ticket-recent", time.Now().UTC())
+	// checked by runs before
+	outsideWindow := confirmed("ticket-outside-window", time.Now().Add(-48*time.Hour).UTC())
+	canceled := entities.TicketBookingCanceled{
+		Header:        entities.EventHeader{ID: "canceled-event", PublishedAt: longAgo, TenantID: tenant.DefaultID},
+		TicketID:      appended.TicketID,
+		CustomerEmail: appended.CustomerEmail,
+		Price:         appended.Price,
+	}
+
+	for _, event := range []entities.TicketBookingConfirmed{appended, missing, recent, outsideWindow} {
+		require.NoError(t, recorder.OnTicketBookingConfirmed(ctx, &event))
+	}
+	require.NoError(t, recorder.OnTicketBookingCanceled(ctx, &canceled))
+
+	// redelivered event doesn't replace the first one
+	redelivered := missing
+	redelivered.Header.ID = "other-event"
+	require.NoError(t, recorder.OnTicketBookingConfirmed(ctx, &redelivered))
+
+	spreadsheets := &api.SpreadsheetsMock{Sheets: map[string][][]string{
+		"tickets-to-print": {
+			sheets.TicketsToPrint.Header(),
+			row(appended.TicketID),
+			row("ticket-duplicated"),
+			row("ticket-duplicated"),
+		},
+	}}
+	eventBus := &event.BusMock{}
+
+	reconciler := reconciliation.NewReconciler(
+		repo,
+		spreadsheets,
+		eventBus,
+		config.Spreadsheets{TicketsToPrint: "tickets-to-print", TicketsToRefund: "tickets-to-refund"},
+		config.Tenants{},
+		config.Reconciliation{GracePeriod: time.Minute, Window: 24 * time.Hour, Republish: true},
+	)
+
+	_, ok := reconciler.LastReport()
+	assert.False(t, ok)
+
+	report, err := reconciler.Reconcile(ctx)
+	require.NoError(t, err)
+
+	require.Len(t, report.Sheets, 2)
+	assert.Equal(t, reconciliation.SheetReport{
+		TenantID:    tenant.DefaultID,
+		Sheet:       "tickets-to-print",
+		Tickets:     2,
+		Rows:        3,
+		Missing:     []string{missing.TicketID},
+		Duplicates:  []reconciliation.Duplicate{{TicketID: "ticket-duplicated", Rows: 2}},
+		Republished: 1,
+	}, report.Sheets[0])
+	assert.Equal(t, reconciliation.SheetReport{
+		TenantID:    tenant.DefaultID,
+		Sheet:       "tickets-to-refund",
+		Tickets:     1,
+		Missing:     []string{appended.TicketID},
+		Republished: 1,
+	}, report.Sheets[1])
+
+	assert.Equal(t, []any{missing, canceled}, eventBus.Published())
+
+	lastReport, ok := reconciler.LastReport()
+	require.True(t, ok)
+	assert.Equal(t, report, lastReport)
+}
+
+func TestReconciler_republishes_after_grace_period(t *testing.T) {
+	ctx := tenant.ContextWithID(context.Background(), tenant.DefaultID)
+	gracePeriod := 100 * time.Millisecond
+
+	repo := reconciliation.NewMemoryRepository()
+	missing := confirmed("ticket-missing", time.Now().Add(-time.Hour).UTC())
+	require.NoError(t, reconciliation.NewRecorder(repo).OnTicketBookingConfirmed(ctx, &missing))
+
+	eventBus := &event.BusMock{}
+
+	reconciler := reconciliation.NewReconciler(
+		repo,
+		&api.SpreadsheetsMock{},
+		eventBus,
+		config.Spreadsheets{TicketsToPrint: "tickets-to-print", TicketsToRefund: "tickets-to-refund"},
+		config.Tenants{},
+		config.Reconciliation{GracePeriod: gracePeriod, Window: 24 * time.Hour, Republish: true},
+	)
+
+	report, err := reconciler.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Sheets[0].Republished)
+
+	// the republished event may be still handled
+	report, err = reconciler.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Sheets[0].Tickets)
+	assert.Empty(t, report.Sheets[0].Missing)
+	assert.Equal(t, 0, report.Sheets[0].Republished)
+
+	time.Sleep(gracePeriod)
+
+	report, err = reconciler.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{missing.TicketID}, report.Sheets[0].Missing)
+	assert.Equal(t, 1, report.Sheets[0].Republished)
+
+	assert.Equal(t, []any{missing, missing}, eventBus.Published())
+}
+
+func TestReconciler_doesnt_republish_events_republished_by_other_replicas(t *testing.T) {
+	ctx := tenant.ContextWithID(context.Background(), tenant.DefaultID)
+
+	repo := reconciliation.NewMemoryRepository()
+	missing := confirmed("ticket-missing", time.Now().Add(-time.Hour).UTC())
+	require.NoError(t, reconciliation.NewRecorder(repo).OnTicketBookingConfirmed(ctx, &missing))
+
+	eventBus := &event.BusMock{}
+
+	newReplica := func() *reconciliation.Reconciler {
+		return reconciliation.NewReconciler(
+			repo,
+			&api.SpreadsheetsMock{},
+			eventBus,
+			config.Spreadsheets{TicketsToPrint: "tickets-to-print", TicketsToRefund: "tickets-to-refund"},
+			config.Tenants{},
+			config.Reconciliation{GracePeriod: time.Minute, Window: 24 * time.Hour, Republish: true},
+		)
+	}
+
+	report, err := newReplica().Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Sheets[0].Republished)
+
+	report, err = newReplica().Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Sheets[0].Republished)
+
+	assert.Equal(t, []any{missing}, eventBus.Published())
+}
+
+func TestReconciler_sheet_not_readable(t *testing.T) {
+	spreadsheets := &api.SpreadsheetsMock{}
+	spreadsheets.FailFirst(1, nil)
+
+	reconciler := reconciliation.NewReconciler(
+		reconciliation.NewMemoryRepository(),
+		spreadsheets,
+		&event.BusMock{},
+		config.Spreadsheets{TicketsToPrint: "tickets-to-print", TicketsToRefund: "tickets-to-refund"},
+		config.Tenants{},
+		config.Reconciliation{Window: 24 * time.Hour},
+	)
+
+	_, err := reconciler.Reconcile(context.Background())
+	assert.Error(t, err)
+
+	_, ok := reconciler.LastReport()
+	assert.False(t, ok, "failed reconciliation should not be reported")
+}
+
+func confirmed(ticketID string, publishedAt time.Time) entities.TicketBookingConfirmed {
+	return entities.TicketBookingConfirmed{
+		Header:        entities.EventHeader{ID: ticketID + "-event", PublishedAt: publishedAt, TenantID: tenant.DefaultID},
+		TicketID:      ticketID,
+		CustomerEmail: "customer@example.com",
+		Price:         entities.Money{Amount: "10.00", Currency: "EUR"},
+		BookingID:     "booking-1",
+	}
+}
+
+func row(ticketID string) []string {
+	return sheets.TicketsToPrint.Row(sheets.Layout{}, sheets.TicketToPrint{TicketID: ticketID})
+}
@@ -0,0 +1,28 @@
+package reconciliation
+
+import (
+	"context"
+	"tickets/entities"
+	"tickets/tenant"
+)
+
+// Recorder saves tickets confirmed and canceled by events, so they can be reconciled.
+type Recorder struct {
+	repo Repository
+}
+
+func NewRecorder(repo Repository) *Recorder {
+	if repo == nil {
+		panic("missing repo")
+	}
+
+	return &Recorder{repo: repo}
+}
+
+func (r *Recorder) OnTicketBookingConfirmed(ctx context.Context, event *entities.TicketBookingConfirmed) error {
+	return r.repo.SaveConfirmed(ctx, tenant.FromContext(ctx), *event)
+}
+
+func (r *Recorder) OnTicketBookingCanceled(ctx context.Context, event *entities.TicketBookingCanceled) error {
+	return r.repo.SaveCanceled(ctx, tenant.FromContext(ctx), *event)
+}
@@ -0,0 +1,51 @@
+// Package reconciliation checks that tickets confirmed and canceled by events have their rows
+// in the tracker spreadsheets, exactly once.
+package reconciliation
+
+import (
+	"context"
+	"tickets/entities"
+	"time"
+)
+
+// Ticket is a ticket confirmed or canceled by events.
+type Ticket struct {
+	TicketID      string
+	TenantID      string
+	CustomerEmail string
+	Price         entities.Money
+	BookingID     string
+
+	// Confirmed and Canceled are headers of the first events which confirmed and canceled the ticket,
+	// nil if there was no such event.
+	Confirmed *entities.EventHeader
+	Canceled  *entities.EventHeader
+
+	// ConfirmedRepublishedAt and CanceledRepublishedAt are when the events were last republished, zero if never.
+	ConfirmedRepublishedAt time.Time
+	CanceledRepublishedAt  time.Time
+}
+
+type Repository interface {
+	SaveConfirmed(ctx context.Context, tenantID string, event entities.TicketBookingConfirmed) error
+	SaveCanceled(ctx context.Context, tenantID string, event entities.TicketBookingCanceled) error
+	// ListChanged returns tickets of all tenants confirmed or canceled at from or later, but before to.
+	ListChanged(ctx context.Context, from time.Time, to time.Time) ([]Ticket, error)
+	// MarkRepublished saves when the event of the ticket was republished, so all replicas know it.
+	MarkRepublished(ctx context.Context, ticketID string, eventID string, at time.Time) error
+}
+
+// ConfirmedBetween tells if the ticket was confirmed at from or later, but before to.
+func (t Ticket) ConfirmedBetween(from time.Time, to time.Time) bool {
+	return changed(t.Confirmed, from, to)
+}
+
+// CanceledBetween tells if the ticket was canceled at from or later, but before to.
+func (t Ticket) CanceledBetween(from time.Time, to time.Time) bool {
+	return changed(t.Canceled, from, to)
+}
+
+// changed tells if the event happened at from or later, but before to.
+func changed(header *entities.EventHeader, from time.Time, to time.Time) bool {
+	return header != nil && !header.PublishedAt.Before(from) && header.PublishedAt.Before(to)
+}
@@ -0,0 +1,13 @@
+package sales
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var dailySummaries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "tickets",
+	Subsystem: "sales",
+	Name:      "daily_summaries_total",
+	Help:      "Number of daily sales summaries of tenants, by result.",
+}, []string{"result"})
@@ -0,0 +1,387 @@
+package sales
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"tickets/config"
+	"tickets/entities"
+	"tickets/locks"
+	"tickets/reconciliation"
+	"tickets/sheets"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	dateLayout     = time.DateOnly
+	dateColumn     = "Date"
+	currencyColumn = "Currency"
+	lockKey        = "daily-summary"
+)
+
+// summaryNamespace derives IDs of DailySalesSummarized events, so a summary published again has the same ID.
+var summaryNamespace = uuid.MustParse("6f1d8c52-3f0e-4b8a-9a57-7d0c2f1e9b34")
+
+type TicketsRepository interface {
+	ListChanged(ctx context.Context, from time.Time, to time.Time) ([]reconciliation.Ticket, error)
+}
+
+type SpreadsheetsAPI interface {
+	Rows(ctx context.Context, sheetName string) ([][]string, error)
+	AppendRows(ctx context.Context, sheetName string, rows [][]string) error
+}
+
+type EventBus interface {
+	Publish(ctx context.Context, event any) error
+}
+
+// DailySummarizer summarizes sales of the previous day of all tenants, once a day: it publishes
+// DailySalesSummarized and appends the summary to the daily-summary sheet of the tenant. Days missed
+// while no replica was running are summarized by the next run.
+//
+// Only one replica summarizes at a time, holding a lock. Rows of a day and currency already in the sheet
+// of a tenant aren't appended again, so a day is summarized once, even by many replicas, and a summary
+// appended only partially is completed by the next run.
+type DailySummarizer struct {
+	tickets      TicketsRepository
+	spreadsheets SpreadsheetsAPI
+	eventBus     EventBus
+	locker       locks.Locker
+	layouts      *sheets.Layouts
+	sheets       config.Spreadsheets
+	tenants      config.Tenants
+	config       config.DailySummary
+	now          func() time.Time
+
+	lock           sync.Mutex
+	lastSummarized string
+}
+
+func NewDailySummarizer(
+	tickets TicketsRepository,
+	spreadsheets SpreadsheetsAPI,
+	eventBus EventBus,
+	locker locks.Locker,
+	sheetsConfig config.Spreadsheets,
+	tenants config.Tenants,
+	cfg config.DailySummary,
+) *DailySummarizer {
+	if tickets == nil {
+		panic("missing tickets")
+	}
+	if spreadsheets == nil {
+		panic("missing spreadsheets")
+	}
+	if eventBus == nil {
+		panic("missing eventBus")
+	}
+	if locker == nil {
+		panic("missing locker")
+	}
+
+	return &DailySummarizer{
+		tickets:      tickets,
+		spreadsheets: spreadsheets,
+		eventBus:     eventBus,
+		locker:       locker,
+		layouts:      sheets.NewLayouts(spreadsheets, sheets.DefaultLayoutTTL),
+		sheets:       sheetsConfig,
+		tenants:      tenants,
+		config:       cfg,
+		now:          time.Now,
+	}
+}
+
+// Run summarizes previous days when it's due, checking every check interval, until ctx is done.
+func (s *DailySummarizer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := s.SummarizeDue(ctx); err != nil {
+				log.FromContext(ctx).WithError(err).Error("Failed to summarize daily sales")
+			}
+		}
+	}
+}
+
+// SummarizeDue summarizes the previous day once the run time of today has passed, catching up on days
+// since the last one in the sheet of each tenant.
+func (s *DailySummarizer) SummarizeDue(ctx context.Context) error {
+	now := s.now().In(s.config.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	if now.Before(today.Add(s.config.RunAfter())) {
+		return nil
+	}
+
+	day := today.AddDate(0, 0, -1)
+
+	s.lock.Lock()
+	summarized := s.lastSummarized == day.Format(dateLayout)
+	s.lock.Unlock()
+	if summarized {
+		return nil
+	}
+
+	return s.summarize(ctx, day, true)
+}
+
+// SummarizeDay summarizes the day starting at the midnight day, for tenants whose sheet doesn't have it yet.
+func (s *DailySummarizer) SummarizeDay(ctx context.Context, day time.Time) error {
+	return s.summarize(ctx, day, false)
+}
+
+// summarySheet is the daily-summary sheet of a tenant, read once per run.
+type summarySheet struct {
+	name   string
+	layout sheets.Layout
+	rows   [][]string
+	// first is the first day to summarize
+	first time.Time
+}
+
+// summarize summarizes days through the midnight last. With catchUp, days of a tenant start with the last one
+// in its sheet, which may be summarized only partially, otherwise only last is summarized.
+// When another replica holds the lock, it's left to summarize the days.
+func (s *DailySummarizer) summarize(ctx context.Context, last time.Time, catchUp bool) error {
+	ctx = log.ContextWithCorrelationID(ctx, "daily_summary_"+watermill.NewShortUUID())
+	logger := log.FromContext(ctx).WithField("date", last.Format(dateLayout))
+
+	lock, err := s.locker.Acquire(ctx, lockKey, s.config.LockTTL)
+	if errors.Is(err, locks.ErrNotAcquired) {
+		logger.Debug("Daily summary is being made by another replica")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to acquire daily summary lock: %w", err)
+	}
+	defer func() {
+		if releaseErr := lock.Release(context.WithoutCancel(ctx)); releaseErr != nil {
+			logger.WithError(releaseErr).Warn("Failed to release daily summary lock")
+		}
+	}()
+
+	tenantSheets := map[string]*summarySheet{}
+	openSheet := func(tenantID string) (*summarySheet, error) {
+		if sheet, ok := tenantSheets[tenantID]; ok {
+			return sheet, nil
+		}
+
+		sheet, err := s.openSheet(ctx, tenantID, last, catchUp)
+		if err != nil {
+			dailySummaries.WithLabelValues("failure").Inc()
+			return nil, fmt.Errorf("failed to summarize days of tenant %s: %w", tenantID, err)
+		}
+		tenantSheets[tenantID] = sheet
+
+		return sheet, nil
+	}
+
+	first := last
+	for _, tenantID := range reconciliation.TenantIDs(s.tenants, nil) {
+		sheet, err := openSheet(tenantID)
+		if err != nil {
+			return err
+		}
+		if sheet.first.Before(first) {
+			first = sheet.first
+		}
+	}
+
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		date := day.Format(dateLayout)
+		from, to := day, day.AddDate(0, 0, 1)
+
+		tickets, err := s.tickets.ListChanged(ctx, from, to)
+		if err != nil {
+			return err
+		}
+
+		byTenant := map[string][]reconciliation.Ticket{}
+		for _, ticket := range tickets {
+			byTenant[ticket.TenantID] = append(byTenant[ticket.TenantID], ticket)
+		}
+
+		for _, tenantID := range reconciliation.TenantIDs(s.tenants, tickets) {
+			sheet, err := openSheet(tenantID)
+			if err != nil {
+				return err
+			}
+			if day.Before(sheet.first) {
+				continue
+			}
+
+			if err := s.summarizeTenant(ctx, tenantID, sheet, date, byTenant[tenantID], from, to); err != nil {
+				dailySummaries.WithLabelValues("failure").Inc()
+				return fmt.Errorf("failed to summarize %s of tenant %s: %w", date, tenantID, err)
+			}
+		}
+	}
+
+	s.lock.Lock()
+	s.lastSummarized = last.Format(dateLayout)
+	s.lock.Unlock()
+
+	return nil
+}
+
+// openSheet reads the daily-summary sheet of the tenant. With catchUp, days are summarized from the latest one
+// in the sheet, unless there is none.
+func (s *DailySummarizer) openSheet(ctx context.Context, tenantID string, last time.Time, catchUp bool) (*summarySheet, error) {
+	name := s.tenants.Spreadsheets(tenantID, s.sheets).DailySummary
+
+	layout, rows, err := s.layouts.Prepare(ctx, name, sheets.DailySummary.Header())
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := &summarySheet{
+		name:   name,
+		layout: layout,
+		rows:   rows,
+		first:  last,
+	}
+
+	if !catchUp {
+		return sheet, nil
+	}
+
+	var latest time.Time
+	for _, date := range sheets.Values(rows, sheets.DailySummary.Header(), dateColumn) {
+		day, err := time.ParseInLocation(dateLayout, date, last.Location())
+		if err == nil && day.After(latest) {
+			latest = day
+		}
+	}
+	if !latest.IsZero() && latest.Before(last) {
+		sheet.first = latest
+	}
+
+	return sheet, nil
+}
+
+func (s *DailySummarizer) summarizeTenant(
+	ctx context.Context,
+	tenantID string,
+	sheet *summarySheet,
+	date string,
+	tickets []reconciliation.Ticket,
+	from time.Time,
+	to time.Time,
+) error {
+	logger := log.FromContext(ctx).WithFields(logrus.Fields{
+		"date":   date,
+		"tenant": tenantID,
+		"sheet":  sheet.name,
+	})
+
+	summary, err := Summarize(date, tickets, from, to)
+	if err != nil {
+		return err
+	}
+
+	missing := missingSales(summarySales(summary), summarizedCurrencies(sheet.rows, date))
+	if len(missing) == 0 {
+		logger.Debug("Day is already summarized")
+		dailySummaries.WithLabelValues("skipped").Inc()
+		return nil
+	}
+	summary.Header = entities.EventHeader{
+		ID:          uuid.NewSHA1(summaryNamespace, []byte(tenantID+"/"+date)).String(),
+		PublishedAt: s.now().UTC(),
+		TenantID:    tenantID,
+	}
+
+	// the event is published before the row is appended: when appending fails, the event is published again
+	// with the same ID on the next check, while the row would stop it from being published at all
+	if err := s.eventBus.Publish(ctx, summary); err != nil {
+		return fmt.Errorf("failed to publish DailySalesSummarized: %w", err)
+	}
+
+	missingRows := make([][]string, 0, len(missing))
+	for _, sales := range missing {
+		missingRows = append(missingRows, sheets.DailySummary.Row(sheet.layout, sales))
+	}
+
+	if err := s.spreadsheets.AppendRows(ctx, sheet.name, missingRows); err != nil {
+		return fmt.Errorf("failed to append rows to %s: %w", sheet.name, err)
+	}
+
+	dailySummaries.WithLabelValues("success").Inc()
+	logger.WithFields(logrus.Fields{
+		"confirmed": summary.ConfirmedTickets,
+		"canceled":  summary.CanceledTickets,
+	}).Info("Summarized daily sales")
+
+	return nil
+}
+
+// summarySales returns a row per currency, or a single row without currency for a day without sales.
+func summarySales(summary entities.DailySalesSummarized) []sheets.DailySales {
+	if len(summary.Currencies) == 0 {
+		return []sheets.DailySales{{
+			Date:    summary.Date,
+			Revenue: "0",
+			Refunds: "0",
+		}}
+	}
+
+	sales := make([]sheets.DailySales, 0, len(summary.Currencies))
+	for _, currency := range summary.Currencies {
+		sales = append(sales, sheets.DailySales{
+			Date:             summary.Date,
+			Currency:         currency.Currency,
+			ConfirmedTickets: currency.ConfirmedTickets,
+			CanceledTickets:  currency.CanceledTickets,
+			Revenue:          currency.Revenue,
+			Refunds:          currency.Refunds,
+		})
+	}
+
+	return sales
+}
+
+// summarizedCurrencies returns currencies of rows of the date in a daily-summary sheet.
+func summarizedCurrencies(rows [][]string, date string) map[string]bool {
+	header := sheets.DailySummary.Header()
+	dates := sheets.Values(rows, header, dateColumn)
+	currencies := sheets.Values(rows, header, currencyColumn)
+
+	summarized := map[string]bool{}
+	for i, value := range dates {
+		if value != date {
+			continue
+		}
+
+		currency := ""
+		if i < len(currencies) {
+			currency = currencies[i]
+		}
+		summarized[currency] = true
+	}
+
+	return summarized
+}
+
+// missingSales returns sales whose currency isn't summarized yet.
+func missingSales(sales []sheets.DailySales, summarized map[string]bool) []sheets.DailySales {
+	var missing []sheets.DailySales
+	for _, s := range sales {
+		if !summarized[s.Currency] {
+			missing = append(missing, s)
+		}
+	}
+
+	return missing
+}
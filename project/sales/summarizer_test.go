@@ -0,0 +1,242 @@
+package sales_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"tickets/api"
+	"tickets/config"
+	"tickets/entities"
+	"tickets/locks"
+	"tickets/message/event"
+	"tickets/reconciliation"
+	"tickets/sales"
+	"tickets/sheets"
+	"tickets/tenant"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDailySummarizer(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	repo := reconciliation.NewMemoryRepository()
+	save := func(ticketID string, amount string, currency string, confirmedAt time.Time) entities.TicketBookingConfirmed {
+		event := entities.TicketBookingConfirmed{
+			Header:   entities.EventHeader{ID: ticketID + "-confirmed", PublishedAt: confirmedAt},
+			TicketID: ticketID,
+			Price:    entities.Money{Amount: amount, Currency: currency},
+		}
+		require.NoError(t, repo.SaveConfirmed(ctx, tenant.DefaultID, event))
+		return event
+	}
+
+	save("ticket-1", "10", "EUR", day.Add(time.Hour))
+	save("ticket-2", "2.505", "EUR", day.Add(23*time.Hour))
+	save("ticket-3", "30.00", "USD", day.Add(2*time.Hour))
+	// confirmed the previous and the next day
+	canceled := save("ticket-4", "5.00", "EUR", day.Add(-time.Hour))
+	save("ticket-5", "7.00", "EUR", day.AddDate(0, 0, 1))
+
+	require.NoError(t, repo.SaveCanceled(ctx, tenant.DefaultID, entities.TicketBookingCanceled{
+		Header:   entities.EventHeader{ID: "ticket-4-canceled", PublishedAt: day.Add(5 * time.Hour)},
+		TicketID: canceled.TicketID,
+		Price:    canceled.Price,
+	}))
+
+	spreadsheets := &api.SpreadsheetsMock{}
+	eventBus := &event.BusMock{}
+	locker := locks.NewMemoryLocker()
+
+	summarizer := sales.NewDailySummarizer(
+		repo,
+		spreadsheets,
+		eventBus,
+		locker,
+		config.Spreadsheets{DailySummary: "daily-summary"},
+		config.Tenants{},
+		config.DailySummary{LockTTL: time.Minute},
+	)
+
+	require.NoError(t, summarizer.SummarizeDay(ctx, day))
+
+	events := eventBus.Published()
+	require.Len(t, events, 1)
+	summary := events[0].(entities.DailySalesSummarized)
+	assert.NotEmpty(t, summary.Header.ID)
+	assert.Equal(t, tenant.DefaultID, summary.Header.TenantID)
+	assert.Equal(t, "2024-03-10", summary.Date)
+	assert.Equal(t, 3, summary.ConfirmedTickets)
+	assert.Equal(t, 1, summary.CanceledTickets)
+	assert.Equal(t, []entities.CurrencySales{
+		{Currency: "EUR", ConfirmedTickets: 2, CanceledTickets: 1, Revenue: "12.505", Refunds: "5.000"},
+		{Currency: "USD", ConfirmedTickets: 1, CanceledTickets: 0, Revenue: "30.00", Refunds: "0.00"},
+	}, summary.Currencies)
+
+	assert.Equal(t, [][]string{
+		sheets.DailySummary.Header(),
+		{"2024-03-10", "EUR", "2", "1", "12.505", "5.000"},
+		{"2024-03-10", "USD", "1", "0", "30.00", "0.00"},
+	}, spreadsheets.SheetRows("daily-summary"))
+
+	t.Run("summarized_day_is_skipped", func(t *testing.T) {
+		// as by another replica, which doesn't know the day was summarized
+		other := sales.NewDailySummarizer(
+			repo,
+			spreadsheets,
+			eventBus,
+			locker,
+			config.Spreadsheets{DailySummary: "daily-summary"},
+			config.Tenants{},
+			config.DailySummary{LockTTL: time.Minute},
+		)
+		require.NoError(t, other.SummarizeDay(ctx, day))
+
+		assert.Len(t, eventBus.Published(), 1)
+		assert.Len(t, spreadsheets.SheetRows("daily-summary"), 3)
+	})
+
+	t.Run("held_lock_skips_summary", func(t *testing.T) {
+		lock, err := locker.Acquire(ctx, "daily-summary", time.Minute)
+		require.NoError(t, err)
+		defer lock.Release(ctx)
+
+		require.NoError(t, summarizer.SummarizeDay(ctx, day.AddDate(0, 0, 1)))
+
+		assert.Len(t, eventBus.Published(), 1)
+		assert.Len(t, spreadsheets.SheetRows("daily-summary"), 3)
+	})
+
+	t.Run("day_without_sales", func(t *testing.T) {
+		require.NoError(t, summarizer.SummarizeDay(ctx, day.AddDate(0, 0, -2)))
+
+		assert.Len(t, eventBus.Published(), 2)
+		assert.Equal(t, []string{"2024-03-08", "", "0", "0", "0.00", "0.00"}, spreadsheets.SheetRows("daily-summary")[3])
+	})
+}
+
+func TestDailySummarizer_completes_partially_appended_summary(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	repo := reconciliation.NewMemoryRepository()
+	for ticketID, currency := range map[string]string{"ticket-1": "EUR", "ticket-2": "USD"} {
+		require.NoError(t, repo.SaveConfirmed(ctx, tenant.DefaultID, entities.TicketBookingConfirmed{
+			Header:   entities.EventHeader{ID: ticketID + "-confirmed", PublishedAt: day.Add(time.Hour)},
+			TicketID: ticketID,
+			Price:    entities.Money{Amount: "10.00", Currency: currency},
+		}))
+	}
+
+	spreadsheets := &partialAppendsMock{}
+	eventBus := &event.BusMock{}
+
+	newSummarizer := func() *sales.DailySummarizer {
+		return sales.NewDailySummarizer(
+			repo,
+			spreadsheets,
+			eventBus,
+			locks.NewMemoryLocker(),
+			config.Spreadsheets{DailySummary: "daily-summary"},
+			config.Tenants{},
+			config.DailySummary{LockTTL: time.Minute},
+		)
+	}
+
+	spreadsheets.FailAfterFirstRow()
+	require.Error(t, newSummarizer().SummarizeDay(ctx, day))
+
+	assert.Equal(t, [][]string{
+		sheets.DailySummary.Header(),
+		{"2024-03-10", "EUR", "1", "0", "10.00", "0.00"},
+	}, spreadsheets.SheetRows("daily-summary"))
+
+	require.NoError(t, newSummarizer().SummarizeDay(ctx, day))
+
+	assert.Equal(t, [][]string{
+		sheets.DailySummary.Header(),
+		{"2024-03-10", "EUR", "1", "0", "10.00", "0.00"},
+		{"2024-03-10", "USD", "1", "0", "10.00", "0.00"},
+	}, spreadsheets.SheetRows("daily-summary"), "only the missing row should be appended")
+
+	events := eventBus.Published()
+	require.Len(t, events, 2, "the summary should be published again")
+	assert.Equal(t, events[0].(entities.DailySalesSummarized).Header.ID, events[1].(entities.DailySalesSummarized).Header.ID)
+
+	require.NoError(t, newSummarizer().SummarizeDay(ctx, day))
+	assert.Len(t, eventBus.Published(), 2)
+	assert.Len(t, spreadsheets.SheetRows("daily-summary"), 3)
+}
+
+func TestDailySummarizer_catches_up_on_days_since_the_last_summary(t *testing.T) {
+	ctx := context.Background()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	date := func(daysAgo int) string {
+		return today.AddDate(0, 0, -daysAgo).Format(time.DateOnly)
+	}
+
+	repo := reconciliation.NewMemoryRepository()
+	require.NoError(t, repo.SaveConfirmed(ctx, tenant.DefaultID, entities.TicketBookingConfirmed{
+		Header:   entities.EventHeader{ID: "ticket-1-confirmed", PublishedAt: today.AddDate(0, 0, -2).Add(time.Hour)},
+		TicketID: "ticket-1",
+		Price:    entities.Money{Amount: "10.00", Currency: "EUR"},
+	}))
+
+	spreadsheets := &api.SpreadsheetsMock{Sheets: map[string][][]string{
+		"daily-summary": {
+			sheets.DailySummary.Header(),
+			{date(4), "", "0", "0", "0.00", "0.00"},
+			{date(3), "", "0", "0", "0.00", "0.00"},
+		},
+	}}
+	eventBus := &event.BusMock{}
+
+	summarizer := sales.NewDailySummarizer(
+		repo,
+		spreadsheets,
+		eventBus,
+		locks.NewMemoryLocker(),
+		config.Spreadsheets{DailySummary: "daily-summary"},
+		config.Tenants{},
+		config.DailySummary{LockTTL: time.Minute},
+	)
+
+	require.NoError(t, summarizer.SummarizeDue(ctx))
+
+	assert.Equal(t, [][]string{
+		sheets.DailySummary.Header(),
+		{date(4), "", "0", "0", "0.00", "0.00"},
+		{date(3), "", "0", "0", "0.00", "0.00"},
+		{date(2), "EUR", "1", "0", "10.00", "0.00"},
+		{date(1), "", "0", "0", "0.00", "0.00"},
+	}, spreadsheets.SheetRows("daily-summary"))
+	assert.Len(t, eventBus.Published(), 2)
+	assert.Equal(t, 1, spreadsheets.Attempts(""), "the sheet should be read once")
+}
+
+// partialAppendsMock appends only the first of several rows once, like the gateway failing midway.
+type partialAppendsMock struct {
+	api.SpreadsheetsMock
+
+	failAfterFirstRow bool
+}
+
+func (m *partialAppendsMock) FailAfterFirstRow() {
+	m.failAfterFirstRow = true
+}
+
+func (m *partialAppendsMock) AppendRows(ctx context.Context, spreadsheetName string, rows [][]string) error {
+	if !m.failAfterFirstRow || len(rows) < 2 {
+		return m.SpreadsheetsMock.AppendRows(ctx, spreadsheetName, rows)
+	}
+	m.failAfterFirstRow = false
+
+	if err := m.SpreadsheetsMock.AppendRows(ctx, spreadsheetName, rows[:1]); err != nil {
+		return err
+	}
+
+	return &entities.AppendRowsError{Appended: 1, Err: api.StatusError{StatusCode: http.StatusInternalServerError}}
+}
@@ -0,0 +1,81 @@
+// Package sales summarizes sales of tickets.
+package sales
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"tickets/entities"
+	"tickets/reconciliation"
+	"time"
+)
+
+// Summarize summarizes tickets confirmed and canceled at from or later, but before to.
+func Summarize(date string, tickets []reconciliation.Ticket, from time.Time, to time.Time) (entities.DailySalesSummarized, error) {
+	summary := entities.DailySalesSummarized{Date: date}
+
+	byCurrency := map[string]*currencyTotals{}
+	totals := func(currency string) *currencyTotals {
+		if byCurrency[currency] == nil {
+			byCurrency[currency] = &currencyTotals{revenue: new(big.Rat), refunds: new(big.Rat), decimals: 2}
+		}
+		return byCurrency[currency]
+	}
+
+	for _, ticket := range tickets {
+		amount, ok := new(big.Rat).SetString(ticket.Price.Amount)
+		if !ok {
+			return entities.DailySalesSummarized{}, fmt.Errorf("invalid price amount %q of ticket %s", ticket.Price.Amount, ticket.TicketID)
+		}
+
+		if ticket.ConfirmedBetween(from, to) {
+			t := totals(ticket.Price.Currency)
+			t.confirmed++
+			t.revenue.Add(t.revenue, amount)
+			t.addDecimals(ticket.Price.Amount)
+			summary.ConfirmedTickets++
+		}
+		if ticket.CanceledBetween(from, to) {
+			t := totals(ticket.Price.Currency)
+			t.canceled++
+			t.refunds.Add(t.refunds, amount)
+			t.addDecimals(ticket.Price.Amount)
+			summary.CanceledTickets++
+		}
+	}
+
+	currencies := make([]string, 0, len(byCurrency))
+	for currency := range byCurrency {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	for _, currency := range currencies {
+		t := byCurrency[currency]
+		summary.Currencies = append(summary.Currencies, entities.CurrencySales{
+			Currency:         currency,
+			ConfirmedTickets: t.confirmed,
+			CanceledTickets:  t.canceled,
+			Revenue:          t.revenue.FloatString(t.decimals),
+			Refunds:          t.refunds.FloatString(t.decimals),
+		})
+	}
+
+	return summary, nil
+}
+
+type currencyTotals struct {
+	confirmed int
+	canceled  int
+	revenue   *big.Rat
+	refunds   *big.Rat
+	// decimals is the most decimal places of summed amounts, at least 2, so sums are exact
+	decimals int
+}
+
+func (t *currencyTotals) addDecimals(amount string) {
+	if _, fraction, ok := strings.Cut(amount, "."); ok && len(fraction) > t.decimals {
+		t.decimals = len(fraction)
+	}
+}
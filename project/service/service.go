@@ -2,79 +2,348 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
 	stdHTTP "net/http"
+	"tickets/booking"
+	"tickets/config"
+	"tickets/health"
 	ticketsHttp "tickets/http"
+	"tickets/locks"
 	"tickets/message"
+	"tickets/message/command"
 	"tickets/message/event"
+	"tickets/message/feed"
+	"tickets/message/scheduler"
+	"tickets/message/streams"
+	"tickets/message/transport"
+	"tickets/notifications"
+	"tickets/observability"
+	"tickets/reconciliation"
+	"tickets/sales"
+	"tickets/sheets"
+	"tickets/webhooks"
+	"time"
 
 	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	watermillMessage "github.com/ThreeDotsLabs/watermill/message"
+	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"golang.org/x/sync/errgroup"
 )
 
-func init() {
-	log.Init(logrus.InfoLevel)
-}
-
 type Service struct {
 	watermillRouter *watermillMessage.Router
 	echoRouter      *echo.Echo
+	publisher       watermillMessage.Publisher
+	drainer         *message.Drainer
+	// streamsTrimmer is nil when streams are not the transport
+	streamsTrimmer *streams.Trimmer
+	// redisFeed is nil when streams are not the transport
+	redisFeed *feed.RedisFeed
+	// bookingSaga is nil when it's disabled
+	bookingSaga *booking.Saga
+
+	scheduledMessagesReleaser *scheduler.Releaser
+	// reconciler runs periodically only when its interval is set
+	reconciler         *reconciliation.Reconciler
+	reconcilerInterval time.Duration
+	// dailySummarizer is nil when it's disabled
+	dailySummarizer *sales.DailySummarizer
+
+	httpAddr       string
+	shutdownConfig config.Shutdown
+}
+
+// SpreadsheetsAPI appends rows to spreadsheets, one by one from HTTP handlers and in batches from event handlers.
+type SpreadsheetsAPI interface {
+	event.SpreadsheetsAPI
+	ticketsHttp.SpreadsheetsAPI
+	sheets.SpreadsheetsAPI
 }
 
+// New builds the service. db and redisClient may be nil when they are not used by eventsTransport,
+// without db webhook subscriptions, booking sagas and tickets to reconcile are kept in memory.
+// Scheduled messages and locks are kept in Redis, or in db without it, or in memory without both.
+// Customers aren't emailed when notifier is nil.
 func New(
+	cfg config.Config,
+	db *sqlx.DB,
 	redisClient *redis.Client,
-	spreadsheetsService event.SpreadsheetsAPI,
-	receiptsService event.ReceiptsService,
+	eventsTransport transport.Transport,
+	spreadsheetsService SpreadsheetsAPI,
+	receiptsService command.ReceiptsService,
+	paymentsService command.PaymentsService,
+	notifier notifications.Notifier,
 ) Service {
+	log.Init(cfg.Log.ParsedLevel())
+
 	watermillLogger := log.NewWatermill(log.FromContext(context.Background()))
 
-	var redisPublisher watermillMessage.Publisher
-	redisPublisher = message.NewRedisPublisher(redisClient, watermillLogger)
-	redisPublisher = log.CorrelationPublisherDecorator{Publisher: redisPublisher}
+	var scheduledMessages scheduler.Store
+	if redisClient != nil {
+		scheduledMessages = scheduler.NewRedisStore(redisClient)
+	} else if db != nil {
+		scheduledMessages = scheduler.NewPostgresStore(db)
+	} else {
+		scheduledMessages = scheduler.NewMemoryStore()
+	}
+
+	// messages are scheduled after correlation and tracing metadata are set, so they keep it when released
+	publisher := message.NewPublisher(scheduler.NewPublisher(eventsTransport.Publisher(), scheduledMessages))
+	scheduledMessagesReleaser := scheduler.NewReleaser(scheduledMessages, eventsTransport.Publisher(), cfg.Scheduler)
 
-	eventBus := event.NewBus(redisPublisher)
+	marshaler, err := event.NewMarshaler(cfg.Messaging.ProtobufEvents, cfg.Messaging.CloudEventsMode, observability.ServiceName)
+	if err != nil {
+		panic(err)
+	}
+
+	eventBus := event.NewBus(publisher, marshaler, cfg.Tenants)
+	commandBus := command.NewBus(publisher, marshaler)
+
+	var webhooksRepository webhooks.Repository
+	if db != nil {
+		webhooksRepository = webhooks.NewPostgresRepository(db)
+	} else {
+		webhooksRepository = webhooks.NewMemoryRepository()
+	}
+
+	webhookAddresses := webhooks.AddressPolicy{AllowPrivate: cfg.Webhooks.AllowPrivateAddresses}
+	webhooksDeliverer := webhooks.NewDeliverer(
+		webhooksRepository,
+		commandBus,
+		&stdHTTP.Client{Transport: otelhttp.NewTransport(webhookAddresses.Transport())},
+		cfg.Webhooks,
+	)
 
 	eventsHandler := event.NewHandler(
 		spreadsheetsService,
 		receiptsService,
+		webhooksDeliverer,
+		sheets.NewLayouts(spreadsheetsService, sheets.DefaultLayoutTTL),
+		cfg.Spreadsheets,
+		cfg.Tenants,
+	)
+
+	eventProcessorConfig := event.NewProcessorConfig(eventsTransport, marshaler, cfg.Messaging.ConsumerGroupPrefix, "", watermillLogger)
+
+	dedicatedTenantsConfigs := map[string]cqrs.EventProcessorConfig{}
+	for _, tenantID := range cfg.Tenants.WithDedicatedTopics() {
+		dedicatedTenantsConfigs[tenantID] = event.NewProcessorConfig(eventsTransport, marshaler, cfg.Messaging.ConsumerGroupPrefix, tenantID, watermillLogger)
+	}
+
+	var bookingSaga *booking.Saga
+	var commandHandler command.Handler
+	if cfg.BookingSaga.Enabled {
+		var sagaRepository booking.Repository
+		var ticketsRepository command.TicketsRepository
+		if db != nil {
+			sagaRepository = booking.NewPostgresRepository(db)
+			ticketsRepository = booking.NewPostgresTicketsRepository(db)
+		} else {
+			sagaRepository = booking.NewMemoryRepository()
+			ticketsRepository = booking.NewMemoryTicketsRepository()
+		}
+
+		bookingSaga = booking.NewSaga(sagaRepository, commandBus, eventBus, cfg.BookingSaga)
+		commandHandler = command.NewHandler(eventBus, ticketsRepository, paymentsService, receiptsService)
+	}
+
+	var customerNotifications *notifications.Mailer
+	if notifier != nil {
+		var sentNotifications notifications.SentStore
+		if redisClient != nil {
+			sentNotifications = notifications.NewRedisSentStore(redisClient)
+		} else {
+			sentNotifications = notifications.NewMemorySentStore()
+		}
+
+		customerNotifications = notifications.NewMailer(notifier, sentNotifications, cfg.Notifications)
+	}
+
+	var reconciliationRepository reconciliation.Repository
+	if db != nil {
+		reconciliationRepository = reconciliation.NewPostgresRepository(db)
+	} else {
+		reconciliationRepository = reconciliation.NewMemoryRepository()
+	}
+
+	ticketsRecorder := reconciliation.NewRecorder(reconciliationRepository)
+	reconciler := reconciliation.NewReconciler(
+		reconciliationRepository,
+		spreadsheetsService,
+		eventBus,
+		cfg.Spreadsheets,
+		cfg.Tenants,
+		cfg.Reconciliation,
 	)
 
-	eventProcessorConfig := event.NewProcessorConfig(redisClient, watermillLogger)
+	var dailySummarizer *sales.DailySummarizer
+	if cfg.DailySummary.Enabled {
+		var locker locks.Locker
+		if redisClient != nil {
+			locker = locks.NewRedisLocker(redisClient)
+		} else if db != nil {
+			locker = locks.NewPostgresLocker(db)
+		} else {
+			locker = locks.NewMemoryLocker()
+		}
+
+		dailySummarizer = sales.NewDailySummarizer(
+			reconciliationRepository,
+			spreadsheetsService,
+			eventBus,
+			locker,
+			cfg.Spreadsheets,
+			cfg.Tenants,
+			cfg.DailySummary,
+		)
+	}
+
+	commandProcessorConfig := command.NewProcessorConfig(eventsTransport, marshaler, cfg.Messaging.ConsumerGroupPrefix, watermillLogger)
+
+	drainer := message.NewDrainer()
+
+	spreadsheetsBatchConfig := cfg.Messaging.SpreadsheetsBatch
+	if !transport.CompetingConsumers(eventsTransport) {
+		// messages of a batch are delivered concurrently, other transports deliver them one by one
+		spreadsheetsBatchConfig.MaxSize = 1
+	}
 
 	watermillRouter := message.NewWatermillRouter(
 		eventProcessorConfig,
+		dedicatedTenantsConfigs,
 		eventsHandler,
+		bookingSaga,
+		customerNotifications,
+		ticketsRecorder,
+		commandProcessorConfig,
+		commandHandler,
+		webhooksDeliverer,
+		drainer,
+		cfg.Messaging.Retry,
+		spreadsheetsBatchConfig,
+		cfg.Shutdown.CloseTimeout,
 		watermillLogger,
 	)
 
+	runningHandlers := &health.RunningHandlers{}
+	watermillRouter.AddSubscriberDecorators(runningHandlers.SubscriberDecorator())
+
+	readinessChecks := []health.Check{
+		health.RouterCheck(watermillRouter, runningHandlers),
+	}
+	if db != nil {
+		readinessChecks = append(readinessChecks, health.PostgresCheck(db))
+	}
+	if redisClient != nil {
+		readinessChecks = append(readinessChecks, health.RedisCheck(redisClient))
+	}
+	if cfg.Health.CheckGateway {
+		readinessChecks = append(readinessChecks, health.GatewayCheck(cfg.Gateway.Addr))
+	}
+
+	var streamsInspector ticketsHttp.StreamsInspector
+	var streamsTrimmer *streams.Trimmer
+	var redisFeed *feed.RedisFeed
+	var eventsFeed ticketsHttp.EventsFeed
+	if redisClient != nil && cfg.Messaging.Transport == transport.Redis {
+		streamsInspector = streams.NewInspector(redisClient, cfg.Messaging.ConsumerGroupPrefix)
+		streamsTrimmer = streams.NewTrimmer(redisClient, cfg.Messaging.ConsumerGroupPrefix, cfg.Retention)
+		redisFeed = feed.NewRedisFeed(redisClient, cfg.EventsStream.MaxConnections)
+		eventsFeed = redisFeed
+	}
+	if cfg.Messaging.Transport == transport.GoChannel {
+		// every GoChannel subscription gets all messages
+		subscriber, err := eventsTransport.Subscriber("")
+		if err != nil {
+			panic(err)
+		}
+		eventsFeed = feed.NewSubscriberFeed(subscriber)
+	}
+
+	var ticketsStatusVerifier ticketsHttp.RequestVerifier
+	if len(cfg.InboundWebhooks.Secrets) > 0 {
+		var nonces webhooks.NonceStore
+		if redisClient != nil {
+			nonces = webhooks.NewRedisNonceStore(redisClient)
+		} else {
+			nonces = webhooks.NewMemoryNonceStore()
+		}
+		ticketsStatusVerifier = webhooks.NewVerifier(cfg.InboundWebhooks.Secrets, cfg.InboundWebhooks.Tolerance, nonces)
+	} else if cfg.InboundWebhooks.AllowUnsigned {
+		log.FromContext(context.Background()).Warn("Inbound webhook secrets not configured, /tickets-status requests are not verified")
+	} else {
+		panic("inbound webhook secrets are required, unless unsigned requests are allowed")
+	}
+
 	echoRouter := ticketsHttp.NewHttpRouter(
 		eventBus,
 		spreadsheetsService,
+		readinessChecks,
+		cfg.Health.CheckTimeout,
+		streamsInspector,
+		scheduledMessages,
+		reconciler,
+		webhooksRepository,
+		webhookAddresses,
+		eventsFeed,
+		cfg.EventsStream.HeartbeatInterval,
+		cfg.HTTP.StrictResponseValidation,
+		ticketsStatusVerifier,
+		cfg.Tenants,
+		cfg.Auth,
 	)
 
 	return Service{
-		watermillRouter,
-		echoRouter,
+		watermillRouter: watermillRouter,
+		echoRouter:      echoRouter,
+		publisher:       publisher,
+		drainer:         drainer,
+		streamsTrimmer:  streamsTrimmer,
+		redisFeed:       redisFeed,
+		bookingSaga:     bookingSaga,
+
+		scheduledMessagesReleaser: scheduledMessagesReleaser,
+		reconciler:                reconciler,
+		reconcilerInterval:        cfg.Reconciliation.Interval,
+		dailySummarizer:           dailySummarizer,
+
+		httpAddr:       cfg.HTTP.Addr(),
+		shutdownConfig: cfg.Shutdown,
 	}
 }
 
+// HTTPAddr returns the address HTTP server listens on, or nil if it's not listening yet.
+func (s Service) HTTPAddr() net.Addr {
+	return s.echoRouter.ListenerAddr()
+}
+
 func (s Service) Run(
 	ctx context.Context,
 ) error {
 	errgrp, ctx := errgroup.WithContext(ctx)
 
 	errgrp.Go(func() error {
-		return s.watermillRouter.Run(ctx)
+		// router is closed explicitly in shutdown, after in-flight messages are drained:
+		// cancelling its context would cancel the handlers as well
+		return s.watermillRouter.Run(context.Background())
 	})
 
 	errgrp.Go(func() error {
 		// we don't want to start HTTP server before Watermill router (so service won't be healthy before it's ready)
-		<-s.watermillRouter.Running()
+		select {
+		case <-s.watermillRouter.Running():
+		case <-ctx.Done():
+			return nil
+		}
 
-		err := s.echoRouter.Start(":8080")
+		err := s.echoRouter.Start(s.httpAddr)
 
 		if err != nil && err != stdHTTP.ErrServerClosed {
 			return err
@@ -83,10 +352,94 @@ func (s Service) Run(
 		return nil
 	})
 
+	if s.streamsTrimmer != nil {
+		errgrp.Go(func() error {
+			return s.streamsTrimmer.Run(ctx)
+		})
+	}
+
+	errgrp.Go(func() error {
+		return s.scheduledMessagesReleaser.Run(ctx)
+	})
+
+	if s.reconcilerInterval > 0 {
+		errgrp.Go(func() error {
+			return s.reconciler.Run(ctx)
+		})
+	}
+
+	if s.dailySummarizer != nil {
+		errgrp.Go(func() error {
+			return s.dailySummarizer.Run(ctx)
+		})
+	}
+
+	if s.bookingSaga != nil {
+		errgrp.Go(func() error {
+			return s.bookingSaga.Run(ctx)
+		})
+	}
+
 	errgrp.Go(func() error {
 		<-ctx.Done()
-		return s.echoRouter.Shutdown(context.Background())
+		return s.shutdown()
 	})
 
 	return errgrp.Wait()
 }
+
+// shutdown stops the service in order: HTTP server, events feed, in-flight messages, router with its subscribers, publisher.
+func (s Service) shutdown() error {
+	logger := log.FromContext(context.Background())
+
+	var errs []error
+
+	logger.WithField("timeout", s.shutdownConfig.HTTPTimeout).Info("Shutting down HTTP server")
+
+	httpCtx, cancel := context.WithTimeout(context.Background(), s.shutdownConfig.HTTPTimeout)
+	defer cancel()
+
+	if err := s.echoRouter.Shutdown(httpCtx); err != nil {
+		logger.WithError(err).Error("In-flight HTTP requests abandoned")
+		errs = append(errs, fmt.Errorf("failed to shut down HTTP server: %w", err))
+	}
+
+	if s.redisFeed != nil {
+		if err := s.redisFeed.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close events feed: %w", err))
+		}
+	}
+
+	logger.WithField("timeout", s.shutdownConfig.DrainTimeout).Info("Draining in-flight messages")
+
+	abandoned := s.drainer.Drain(s.shutdownConfig.DrainTimeout)
+	for _, msg := range abandoned {
+		logger.WithFields(logrus.Fields{
+			"message_uuid": msg.MessageUUID,
+			"handler":      msg.HandlerName,
+			"running_for":  time.Since(msg.StartedAt).String(),
+		}).Warn("In-flight message abandoned, it will be redelivered")
+	}
+
+	logger.Info("Closing router and subscribers")
+
+	if err := s.watermillRouter.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close router: %w", err))
+	}
+
+	logger.Info("Closing publisher")
+
+	if err := s.publisher.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close publisher: %w", err))
+	}
+
+	if len(abandoned) > 0 {
+		errs = append(errs, fmt.Errorf("abandoned %d in-flight messages", len(abandoned)))
+	}
+
+	if len(errs) == 0 {
+		logger.Info("Service stopped gracefully")
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,442 @@
+// Package servicetest runs the whole service in-process, on in-memory Pub/Sub and API mocks,
+// so component tests don't need Redis, PostgreSQL or the gateway.
+package servicetest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"tickets/api"
+	"tickets/config"
+	"tickets/entities"
+	"tickets/fakegateway"
+	"tickets/message/command"
+	"tickets/message/event"
+	"tickets/message/transport"
+	"tickets/notifications"
+	"tickets/service"
+	"tickets/tenant"
+	"tickets/webhooks"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/google/uuid"
+	"github.com/lithammer/shortuuid/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	waitTimeout  = 10 * time.Second
+	pollInterval = 50 * time.Millisecond
+)
+
+// AdminKey authorizes requests to the /admin endpoints of the service.
+const AdminKey = "test-admin-key-0123456789"
+
+// TenantKey returns the API key of the tenant, for tenants without keys set with WithConfig.
+func TenantKey(tenantID string) string {
+	return "test-tenant-key-" + tenantID
+}
+
+// tenantKeys returns keys of cfg, with TenantKey of known tenants without keys.
+func tenantKeys(cfg config.Config) map[string][]string {
+	keys := map[string][]string{}
+	for id, tenantKeys := range cfg.Auth.TenantKeys {
+		keys[id] = tenantKeys
+	}
+
+	ids := []string{tenant.DefaultID}
+	for id := range cfg.Tenants {
+		ids = append(ids, id)
+	}
+	for _, id := range ids {
+		if len(keys[id]) == 0 {
+			keys[id] = []string{TenantKey(id)}
+		}
+	}
+
+	return keys
+}
+
+type Harness struct {
+	t testing.TB
+
+	Config config.Config
+
+	// Spreadsheets, Receipts and Payments are set unless the harness runs with WithFakeGateway.
+	Spreadsheets *api.SpreadsheetsMock
+	Receipts     *api.ReceiptsMock
+	Payments     *api.PaymentsMock
+
+	// Notifications records emails sent to customers.
+	Notifications *notifications.NotifierMock
+
+	// Gateway is set when the harness runs with WithFakeGateway.
+	Gateway *fakegateway.Server
+
+	// BaseURL is the address of the service HTTP API, for example http://127.0.0.1:41234
+	BaseURL string
+
+	transport *transport.GoChannelTransport
+}
+
+type Option func(o *options)
+
+type options struct {
+	configure   []func(cfg *config.Config)
+	fakeGateway bool
+	sheetRows   map[string][][]string
+}
+
+// WithConfig changes the config the service is started with.
+func WithConfig(configure func(cfg *config.Config)) Option {
+	return func(o *options) {
+		o.configure = append(o.configure, configure)
+	}
+}
+
+// WithFakeGateway makes the service call a fake gateway over HTTP with the real API clients, instead of using mocks.
+func WithFakeGateway() Option {
+	return func(o *options) {
+		o.fakeGateway = true
+	}
+}
+
+// WithSheetRows puts rows in the sheet before the service starts, for example a header row.
+// It's not supported with WithFakeGateway.
+func WithSheetRows(sheetName string, rows ...[]string) Option {
+	return func(o *options) {
+		if o.sheetRows == nil {
+			o.sheetRows = map[string][][]string{}
+		}
+		o.sheetRows[sheetName] = append(o.sheetRows[sheetName], rows...)
+	}
+}
+
+// New starts the service and stops it when the test finishes.
+func New(t testing.TB, opts ...Option) *Harness {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.HTTP.Port = 0
+	// responses drifting from the spec fail tests
+	cfg.HTTP.StrictResponseValidation = true
+	cfg.Messaging.Transport = transport.GoChannel
+	cfg.Shutdown.HTTPTimeout = time.Second
+	cfg.Shutdown.DrainTimeout = 5 * time.Second
+	cfg.Shutdown.CloseTimeout = time.Second
+	cfg.Auth.AdminKeys = []string{AdminKey}
+	// tests send /tickets-status requests unsigned, unless they configure secrets
+	cfg.InboundWebhooks.AllowUnsigned = true
+	// webhook receivers of tests listen on localhost
+	cfg.Webhooks.AllowPrivateAddresses = true
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	for _, configure := range o.configure {
+		configure(&cfg)
+	}
+	cfg.Auth.TenantKeys = tenantKeys(cfg)
+
+	h := &Harness{
+		t:             t,
+		Config:        cfg,
+		Notifications: &notifications.NotifierMock{},
+		// persistent, so WaitForEvent sees events published before it was called
+		transport: transport.NewGoChannel(
+			gochannel.Config{Persistent: true},
+			log.NewWatermill(log.FromContext(context.Background())),
+		),
+	}
+
+	var spreadsheetsService service.SpreadsheetsAPI
+	var receiptsService command.ReceiptsService
+	var paymentsService command.PaymentsService
+
+	if o.fakeGateway {
+		h.Gateway = fakegateway.NewServer()
+
+		gatewayServer := httptest.NewServer(h.Gateway.Handler())
+		t.Cleanup(gatewayServer.Close)
+
+		apiClients, err := api.NewGatewayClients(gatewayServer.URL, gatewayServer.Client())
+		require.NoError(t, err)
+
+		spreadsheetsService = api.NewSpreadsheetsAPIClient(apiClients)
+		receiptsService = api.NewReceiptsServiceClient(apiClients)
+		paymentsService = api.NewPaymentsServiceClient(apiClients)
+	} else {
+		h.Spreadsheets = &api.SpreadsheetsMock{Sheets: o.sheetRows}
+		h.Receipts = &api.ReceiptsMock{}
+		h.Payments = &api.PaymentsMock{}
+
+		spreadsheetsService = h.Spreadsheets
+		receiptsService = h.Receipts
+		paymentsService = h.Payments
+	}
+
+	svc := service.New(
+		cfg,
+		nil,
+		nil,
+		h.transport,
+		spreadsheetsService,
+		receiptsService,
+		paymentsService,
+		h.Notifications,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- svc.Run(ctx)
+	}()
+
+	t.Cleanup(func() {
+		// the client may keep a connection it dialed but never used, which the server
+		// counts as active for seconds, so shutting down the HTTP server would time out
+		http.DefaultClient.CloseIdleConnections()
+		cancel()
+
+		select {
+		case err := <-stopped:
+			assert.NoError(t, err, "service did not shut down cleanly")
+		case <-time.After(cfg.Shutdown.HTTPTimeout + cfg.Shutdown.DrainTimeout + cfg.Shutdown.CloseTimeout + time.Second):
+			t.Error("service did not shut down in time")
+		}
+	})
+
+	deadline := time.After(waitTimeout)
+	for svc.HTTPAddr() == nil {
+		select {
+		case err := <-stopped:
+			require.FailNow(t, "service stopped before starting HTTP server", "%v", err)
+		case <-deadline:
+			require.FailNow(t, "HTTP server not started")
+		case <-time.After(pollInterval):
+		}
+	}
+
+	h.BaseURL = "http://" + svc.HTTPAddr().String()
+
+	return h
+}
+
+type TicketsStatusRequest struct {
+	Tickets []TicketStatus `json:"tickets"`
+}
+
+type TicketStatus struct {
+	TicketID      string `json:"ticket_id"`
+	Status        string `json:"status"`
+	Price         Money  `json:"price"`
+	CustomerEmail string `json:"customer_email"`
+	BookingID     string `json:"booking_id"`
+}
+
+type Money struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// SendTicketsStatus posts to /tickets-status and requires it to succeed.
+// It returns the correlation ID sent with the request.
+func (h *Harness) SendTicketsStatus(req TicketsStatusRequest) string {
+	h.t.Helper()
+
+	return h.SendTenantTicketsStatus("", req)
+}
+
+// SendTenantTicketsStatus is SendTicketsStatus with the Tenant-ID header, unless tenantID is empty.
+func (h *Harness) SendTenantTicketsStatus(tenantID string, req TicketsStatusRequest) string {
+	h.t.Helper()
+
+	payload, err := json.Marshal(req)
+	require.NoError(h.t, err)
+
+	correlationID := shortuuid.New()
+
+	httpReq, err := http.NewRequest(
+		http.MethodPost,
+		h.BaseURL+"/tickets-status",
+		bytes.NewBuffer(payload),
+	)
+	require.NoError(h.t, err)
+
+	httpReq.Header.Set("Correlation-ID", correlationID)
+	httpReq.Header.Set("Content-Type", "application/json")
+	if tenantID != "" {
+		httpReq.Header.Set(tenant.HTTPHeader, tenantID)
+	}
+	if secrets := h.Config.InboundWebhooks.Secrets; len(secrets) > 0 {
+		SignRequest(httpReq, secrets[0], payload)
+	}
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	require.NoError(h.t, err)
+	defer resp.Body.Close()
+
+	require.Equal(h.t, http.StatusOK, resp.StatusCode)
+
+	return correlationID
+}
+
+// SignRequest sets the headers of an inbound webhook request signed with secret.
+// The Tenant-ID header is signed too, so it must be set before.
+func SignRequest(req *http.Request, secret string, body []byte) {
+	id := uuid.NewString()
+	timestamp := time.Now().Unix()
+
+	req.Header.Set(webhooks.HeaderID, id)
+	req.Header.Set(webhooks.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhooks.HeaderSignature, "sha256="+webhooks.SignRequest(secret, id, timestamp, req.Header.Get(tenant.HTTPHeader), body))
+}
+
+// PublishEvent publishes the event, like services upstream of this one do.
+func (h *Harness) PublishEvent(e any) {
+	h.t.Helper()
+
+	eventBus := event.NewBus(h.transport.Publisher(), event.DefaultMarshaler(), h.Config.Tenants)
+	require.NoError(h.t, eventBus.Publish(context.Background(), e))
+}
+
+// WaitForEvent waits until an event of type T matching the predicate is published and returns it.
+func WaitForEvent[T any](h *Harness, match func(event T) bool) T {
+	h.t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), waitTimeout)
+	defer cancel()
+
+	var zero T
+	topic := cqrs.StructName(&zero)
+
+	// a unique group, so this wait doesn't compete with other subscribers
+	subscriber, err := h.transport.Subscriber("servicetest." + shortuuid.New())
+	require.NoError(h.t, err)
+
+	messages, err := subscriber.Subscribe(ctx, topic)
+	require.NoError(h.t, err)
+
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				require.FailNow(h.t, "subscription closed", "waiting for %s", topic)
+			}
+			msg.Ack()
+
+			var received T
+			require.NoError(h.t, event.DefaultMarshaler().Unmarshal(msg, &received))
+
+			if match(received) {
+				return received
+			}
+		case <-ctx.Done():
+			require.FailNow(h.t, "event not published", "waiting for %s", topic)
+		}
+	}
+}
+
+// AssertSheetRowAdded waits until a row containing all values is appended to the sheet.
+func (h *Harness) AssertSheetRowAdded(sheetName string, values ...string) []string {
+	h.t.Helper()
+
+	var found []string
+
+	require.EventuallyWithT(
+		h.t,
+		func(t *assert.CollectT) {
+			for _, row := range h.sheetRows(sheetName) {
+				if rowContains(row, values) {
+					found = row
+					return
+				}
+			}
+
+			assert.Fail(t, "row not found", "sheet %s has no row with %v", sheetName, values)
+		},
+		waitTimeout,
+		pollInterval,
+	)
+
+	return found
+}
+
+// AssertReceiptIssued waits until a receipt for the ticket is issued.
+func (h *Harness) AssertReceiptIssued(ticketID string) entities.IssueReceiptRequest {
+	h.t.Helper()
+
+	var found entities.IssueReceiptRequest
+
+	require.EventuallyWithT(
+		h.t,
+		func(t *assert.CollectT) {
+			for _, receipt := range h.issuedReceipts() {
+				if receipt.TicketID == ticketID {
+					found = receipt
+					return
+				}
+			}
+
+			assert.Fail(t, "receipt not issued", "no receipt for ticket %s", ticketID)
+		},
+		waitTimeout,
+		pollInterval,
+	)
+
+	return found
+}
+
+func (h *Harness) sheetRows(sheetName string) [][]string {
+	if h.Gateway != nil {
+		return h.Gateway.State().Sheets[sheetName]
+	}
+
+	return h.Spreadsheets.SheetRows(sheetName)
+}
+
+func (h *Harness) issuedReceipts() []entities.IssueReceiptRequest {
+	if h.Gateway == nil {
+		return h.Receipts.Issued()
+	}
+
+	var issued []entities.IssueReceiptRequest
+	for _, receipt := range h.Gateway.State().Receipts {
+		issued = append(issued, entities.IssueReceiptRequest{
+			TicketID: receipt.TicketId,
+			Price: entities.Money{
+				Amount:   receipt.Price.MoneyAmount,
+				Currency: receipt.Price.MoneyCurrency,
+			},
+		})
+	}
+
+	return issued
+}
+
+func rowContains(row []string, values []string) bool {
+	for _, value := range values {
+		contains := false
+		for _, col := range row {
+			if col == value {
+				contains = true
+				break
+			}
+		}
+		if !contains {
+			return false
+		}
+	}
+
+	return true
+}
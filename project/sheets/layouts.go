@@ -0,0 +1,213 @@
+package sheets
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+)
+
+// Layout is the order of columns in a sheet, taken from its header row.
+// The zero Layout is the default order of the schema.
+type Layout struct {
+	columns []string
+}
+
+type SpreadsheetsAPI interface {
+	Rows(ctx context.Context, sheetName string) ([][]string, error)
+	AppendRows(ctx context.Context, sheetName string, rows [][]string) error
+}
+
+// DefaultLayoutTTL is how long layouts are kept before header rows are read again.
+const DefaultLayoutTTL = time.Minute
+
+// Layouts keeps layouts of sheets, so rows are appended in the order of their header rows,
+// even after columns were reordered or added in the sheet.
+type Layouts struct {
+	api SpreadsheetsAPI
+	ttl time.Duration
+
+	lock    sync.Mutex
+	layouts map[string]cachedLayout
+	// sheetLocks keep each sheet from being prepared concurrently, which would create its header row twice
+	sheetLocks map[string]*sync.Mutex
+}
+
+type cachedLayout struct {
+	layout     Layout
+	preparedAt time.Time
+}
+
+// NewLayouts returns Layouts keeping each layout for ttl, so columns reordered in a sheet are picked up
+// without restarting the service.
+func NewLayouts(api SpreadsheetsAPI, ttl time.Duration) *Layouts {
+	if api == nil {
+		panic("missing api")
+	}
+
+	return &Layouts{
+		api:        api,
+		ttl:        ttl,
+		layouts:    map[string]cachedLayout{},
+		sheetLocks: map[string]*sync.Mutex{},
+	}
+}
+
+// Prepare reads the header row of the sheet, or appends header as one when the sheet has none.
+// It returns the layout and the rows of the sheet, with the header row.
+//
+// Columns are matched by name, so columns of the sheet may be reordered, and columns may be added
+// to the sheet and to header. Columns of header missing in the sheet are not appended until they
+// are added to the header row. The header row is the first row with any of the columns: the spreadsheets
+// API only appends rows, so in a sheet which had none, rows above it are in the order of header.
+func (l *Layouts) Prepare(ctx context.Context, sheetName string, header []string) (Layout, [][]string, error) {
+	logger := log.FromContext(ctx).WithField("sheet", sheetName)
+
+	rows, err := l.api.Rows(ctx, sheetName)
+	if err != nil {
+		return Layout{}, nil, fmt.Errorf("failed to read header row of %s: %w", sheetName, err)
+	}
+
+	i := headerRow(rows, header)
+	if i == -1 {
+		if err := l.api.AppendRows(ctx, sheetName, [][]string{header}); err != nil {
+			return Layout{}, nil, fmt.Errorf("failed to create header row of %s: %w", sheetName, err)
+		}
+
+		if len(rows) == 0 {
+			logger.Info("Created header row")
+		} else {
+			logger.Warn("Sheet had no header row, appended one below rows in the default column order")
+		}
+
+		return l.set(sheetName, Layout{columns: header}), append(rows, header), nil
+	}
+
+	sheetHeader := append([]string(nil), rows[i]...)
+	if missing := missingColumns(sheetHeader, header); len(missing) > 0 {
+		logger.WithField("columns", missing).Warn("Columns missing in the header row are not appended")
+	}
+
+	return l.set(sheetName, Layout{columns: sheetHeader}), rows, nil
+}
+
+// Ensure returns the layout of the sheet, preparing the sheet with header when it wasn't prepared
+// in the last ttl. Failed preparation is tried again on the next call.
+func (l *Layouts) Ensure(ctx context.Context, sheetName string, header []string) (Layout, error) {
+	if layout, ok := l.layout(sheetName); ok {
+		return layout, nil
+	}
+
+	sheetLock := l.sheetLock(sheetName)
+	sheetLock.Lock()
+	defer sheetLock.Unlock()
+
+	if layout, ok := l.layout(sheetName); ok {
+		return layout, nil
+	}
+
+	layout, _, err := l.Prepare(ctx, sheetName, header)
+
+	return layout, err
+}
+
+// layout returns the layout of the sheet, unless it wasn't prepared or expired.
+func (l *Layouts) layout(sheetName string) (Layout, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	cached, ok := l.layouts[sheetName]
+	if !ok || time.Since(cached.preparedAt) >= l.ttl {
+		return Layout{}, false
+	}
+
+	return cached.layout, true
+}
+
+func (l *Layouts) set(sheetName string, layout Layout) Layout {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.layouts[sheetName] = cachedLayout{layout: layout, preparedAt: time.Now()}
+
+	return layout
+}
+
+func (l *Layouts) sheetLock(sheetName string) *sync.Mutex {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	sheetLock, ok := l.sheetLocks[sheetName]
+	if !ok {
+		sheetLock = &sync.Mutex{}
+		l.sheetLocks[sheetName] = sheetLock
+	}
+
+	return sheetLock
+}
+
+// Values returns values of the named column in rows of a sheet, without the header row.
+// Like rows appended to it, rows of a sheet above its header row, or without one, have columns in the order of header.
+func Values(rows [][]string, header []string, name string) []string {
+	i := headerRow(rows, header)
+	if i == -1 {
+		return columnValues(rows, header, name)
+	}
+
+	return append(columnValues(rows[:i], header, name), columnValues(rows[i+1:], rows[i], name)...)
+}
+
+func columnValues(rows [][]string, columns []string, name string) []string {
+	index := -1
+	for i, column := range columns {
+		if column == name {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return nil
+	}
+
+	values := make([]string, 0, len(rows))
+	for _, row := range rows {
+		value := ""
+		if index < len(row) {
+			value = row[index]
+		}
+		values = append(values, value)
+	}
+
+	return values
+}
+
+// headerRow returns the index of the header row of a sheet: the first row with any of the columns of header,
+// or -1 when the sheet has none.
+func headerRow(rows [][]string, header []string) int {
+	for i, row := range rows {
+		if len(missingColumns(row, header)) < len(header) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// missingColumns returns columns of header which are not in a row of a sheet.
+func missingColumns(row []string, header []string) []string {
+	inSheet := map[string]bool{}
+	for _, name := range row {
+		inSheet[name] = true
+	}
+
+	var missing []string
+	for _, name := range header {
+		if !inSheet[name] {
+			missing = append(missing, name)
+		}
+	}
+
+	return missing
+}
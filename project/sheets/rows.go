@@ -0,0 +1,58 @@
+package sheets
+
+import (
+	"strconv"
+	"tickets/entities"
+	"time"
+)
+
+// TicketToPrint is a row of the tickets-to-print sheet.
+type TicketToPrint struct {
+	TicketID      string
+	CustomerEmail string
+	Price         entities.Money
+	ConfirmedAt   time.Time
+}
+
+var TicketsToPrint = NewSchema(
+	Column[TicketToPrint]{Name: "Ticket ID", Value: func(r TicketToPrint) string { return r.TicketID }},
+	Column[TicketToPrint]{Name: "Customer email", Value: func(r TicketToPrint) string { return r.CustomerEmail }},
+	Column[TicketToPrint]{Name: "Price", Value: func(r TicketToPrint) string { return FormatAmount(r.Price.Amount) }},
+	Column[TicketToPrint]{Name: "Currency", Value: func(r TicketToPrint) string { return r.Price.Currency }},
+	Column[TicketToPrint]{Name: "Confirmed at", Value: func(r TicketToPrint) string { return FormatTime(r.ConfirmedAt) }},
+)
+
+// TicketToRefund is a row of the tickets-to-refund sheet.
+type TicketToRefund struct {
+	TicketID      string
+	CustomerEmail string
+	Price         entities.Money
+	CanceledAt    time.Time
+}
+
+var TicketsToRefund = NewSchema(
+	Column[TicketToRefund]{Name: "Ticket ID", Value: func(r TicketToRefund) string { return r.TicketID }},
+	Column[TicketToRefund]{Name: "Customer email", Value: func(r TicketToRefund) string { return r.CustomerEmail }},
+	Column[TicketToRefund]{Name: "Price", Value: func(r TicketToRefund) string { return FormatAmount(r.Price.Amount) }},
+	Column[TicketToRefund]{Name: "Currency", Value: func(r TicketToRefund) string { return r.Price.Currency }},
+	Column[TicketToRefund]{Name: "Canceled at", Value: func(r TicketToRefund) string { return FormatTime(r.CanceledAt) }},
+)
+
+// DailySales is a row of the daily-summary sheet, sales of a day in a currency.
+type DailySales struct {
+	Date             string
+	Currency         string
+	ConfirmedTickets int
+	CanceledTickets  int
+	Revenue          string
+	Refunds          string
+}
+
+var DailySummary = NewSchema(
+	Column[DailySales]{Name: "Date", Value: func(r DailySales) string { return r.Date }},
+	Column[DailySales]{Name: "Currency", Value: func(r DailySales) string { return r.Currency }},
+	Column[DailySales]{Name: "Confirmed tickets", Value: func(r DailySales) string { return strconv.Itoa(r.ConfirmedTickets) }},
+	Column[DailySales]{Name: "Canceled tickets", Value: func(r DailySales) string { return strconv.Itoa(r.CanceledTickets) }},
+	Column[DailySales]{Name: "Revenue", Value: func(r DailySales) string { return FormatAmount(r.Revenue) }},
+	Column[DailySales]{Name: "Refunds", Value: func(r DailySales) string { return FormatAmount(r.Refunds) }},
+)
@@ -0,0 +1,94 @@
+// Package sheets defines rows appended to spreadsheets, and keeps them aligned with the header rows of the sheets.
+package sheets
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Column is a named column of rows of type T.
+type Column[T any] struct {
+	Name  string
+	Value func(row T) string
+}
+
+// Schema is the columns of a sheet in their default order, which is the header row of a new sheet.
+type Schema[T any] struct {
+	columns []Column[T]
+}
+
+// NewSchema panics when column names are empty or not unique, they identify columns in the header row.
+func NewSchema[T any](columns ...Column[T]) Schema[T] {
+	names := map[string]bool{}
+	for _, column := range columns {
+		if column.Name == "" {
+			panic("column name is empty")
+		}
+		if names[column.Name] {
+			panic(fmt.Sprintf("duplicated column %q", column.Name))
+		}
+		if column.Value == nil {
+			panic(fmt.Sprintf("missing value of column %q", column.Name))
+		}
+		names[column.Name] = true
+	}
+
+	return Schema[T]{columns: columns}
+}
+
+// Header returns column names in the default order.
+func (s Schema[T]) Header() []string {
+	header := make([]string, 0, len(s.columns))
+	for _, column := range s.columns {
+		header = append(header, column.Name)
+	}
+
+	return header
+}
+
+// Row returns values of row in the order of layout. Columns missing in the layout are left out,
+// and columns of the layout unknown to the schema are left empty.
+func (s Schema[T]) Row(layout Layout, row T) []string {
+	if layout.columns == nil {
+		layout.columns = s.Header()
+	}
+
+	values := make(map[string]string, len(s.columns))
+	for _, column := range s.columns {
+		values[column.Name] = column.Value(row)
+	}
+
+	cells := make([]string, len(layout.columns))
+	for i, name := range layout.columns {
+		cells[i] = values[name]
+	}
+
+	return cells
+}
+
+// FormatAmount formats a money amount with at least two decimal places, keeping more when it has them.
+// Amounts which are not numbers are returned as they are, so the row is still appended.
+func FormatAmount(amount string) string {
+	value, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return amount
+	}
+
+	decimals := 2
+	if _, fraction, ok := strings.Cut(amount, "."); ok && len(fraction) > decimals {
+		decimals = len(fraction)
+	}
+
+	return value.FloatString(decimals)
+}
+
+// FormatTime formats t in UTC, in a format spreadsheets recognize as a date. The zero time is empty.
+func FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.DateTime)
+}
@@ -0,0 +1,155 @@
+package sheets_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"tickets/api"
+	"tickets/entities"
+	"tickets/sheets"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayouts(t *testing.T) {
+	row := sheets.TicketToPrint{
+		TicketID:      "ticket-1",
+		CustomerEmail: "customer@example.com",
+		Price:         entities.Money{Amount: "50.3", Currency: "GBP"},
+		ConfirmedAt:   time.Date(2024, 5, 1, 12, 30, 0, 0, time.FixedZone("CEST", 2*60*60)),
+	}
+
+	testCases := []struct {
+		name          string
+		rows          [][]string
+		expectedSheet [][]string
+	}{
+		{
+			name: "empty_sheet",
+			expectedSheet: [][]string{
+				{"Ticket ID", "Customer email", "Price", "Currency", "Confirmed at"},
+				{"ticket-1", "customer@example.com", "50.30", "GBP", "2024-05-01 10:30:00"},
+			},
+		},
+		{
+			name: "reordered_columns",
+			rows: [][]string{
+				{"Currency", "Price", "Ticket ID", "Notes", "Customer email", "Confirmed at"},
+			},
+			expectedSheet: [][]string{
+				{"Currency", "Price", "Ticket ID", "Notes", "Customer email", "Confirmed at"},
+				{"GBP", "50.30", "ticket-1", "", "customer@example.com", "2024-05-01 10:30:00"},
+			},
+		},
+		{
+			name: "column_not_added_to_sheet_yet",
+			rows: [][]string{
+				{"Ticket ID", "Customer email", "Price", "Currency"},
+			},
+			expectedSheet: [][]string{
+				{"Ticket ID", "Customer email", "Price", "Currency"},
+				{"ticket-1", "customer@example.com", "50.30", "GBP"},
+			},
+		},
+		{
+			name: "no_header_row",
+			rows: [][]string{
+				{"ticket-0", "customer@example.com", "10.00", "GBP"},
+			},
+			expectedSheet: [][]string{
+				{"ticket-0", "customer@example.com", "10.00", "GBP"},
+				{"Ticket ID", "Customer email", "Price", "Currency", "Confirmed at"},
+				{"ticket-1", "customer@example.com", "50.30", "GBP", "2024-05-01 10:30:00"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			spreadsheets := &api.SpreadsheetsMock{}
+			for _, r := range tc.rows {
+				require.NoError(t, spreadsheets.AppendRow(ctx, "tickets-to-print", r))
+			}
+
+			layouts := sheets.NewLayouts(spreadsheets, sheets.DefaultLayoutTTL)
+			layout, rows, err := layouts.Prepare(ctx, "tickets-to-print", sheets.TicketsToPrint.Header())
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedSheet[:len(tc.expectedSheet)-1], rows)
+
+			values := sheets.TicketsToPrint.Row(layout, row)
+			require.NoError(t, spreadsheets.AppendRow(ctx, "tickets-to-print", values))
+
+			assert.Equal(t, tc.expectedSheet, spreadsheets.SheetRows("tickets-to-print"))
+		})
+	}
+}
+
+func TestLayouts_Ensure(t *testing.T) {
+	ctx := context.Background()
+	spreadsheets := &api.SpreadsheetsMock{}
+	layouts := sheets.NewLayouts(spreadsheets, sheets.DefaultLayoutTTL)
+
+	spreadsheets.FailFirst(1, nil)
+	_, err := layouts.Ensure(ctx, "tickets-to-print", sheets.TicketsToPrint.Header())
+	require.Error(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := layouts.Ensure(ctx, "tickets-to-print", sheets.TicketsToPrint.Header())
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, [][]string{sheets.TicketsToPrint.Header()}, spreadsheets.SheetRows("tickets-to-print"), "header row should be created once")
+	assert.Len(t, spreadsheets.Calls(), 3, "prepared sheets should not be read again")
+}
+
+func TestLayouts_Ensure_reads_reordered_header_row_again(t *testing.T) {
+	ctx := context.Background()
+	spreadsheets := &api.SpreadsheetsMock{Sheets: map[string][][]string{
+		"tickets-to-print": {sheets.TicketsToPrint.Header()},
+	}}
+	layouts := sheets.NewLayouts(spreadsheets, 50*time.Millisecond)
+
+	layout, err := layouts.Ensure(ctx, "tickets-to-print", sheets.TicketsToPrint.Header())
+	require.NoError(t, err)
+	assert.Equal(t, "ticket-1", sheets.TicketsToPrint.Row(layout, sheets.TicketToPrint{TicketID: "ticket-1"})[0])
+
+	// columns reordered while the service runs
+	spreadsheets.Sheets["tickets-to-print"] = [][]string{{"Currency", "Ticket ID", "Customer email", "Price", "Confirmed at"}}
+
+	require.EventuallyWithT(t, func(t *assert.CollectT) {
+		layout, err := layouts.Ensure(ctx, "tickets-to-print", sheets.TicketsToPrint.Header())
+		if assert.NoError(t, err) {
+			assert.Equal(t, "ticket-1", sheets.TicketsToPrint.Row(layout, sheets.TicketToPrint{TicketID: "ticket-1"})[1])
+		}
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestValues(t *testing.T) {
+	header := sheets.TicketsToPrint.Header()
+
+	// the header row was appended below a row appended before, and columns were reordered since
+	rows := [][]string{
+		{"ticket-0", "customer@example.com", "10.00", "GBP"},
+		{"Currency", "Ticket ID", "Customer email", "Price", "Confirmed at"},
+		{"GBP", "ticket-1", "customer@example.com", "50.30", "2024-05-01 10:30:00"},
+	}
+
+	assert.Equal(t, []string{"ticket-0", "ticket-1"}, sheets.Values(rows, header, "Ticket ID"))
+	assert.Equal(t, []string{"GBP", "GBP"}, sheets.Values(rows, header, "Currency"))
+}
+
+func TestFormatAmount(t *testing.T) {
+	assert.Equal(t, "50.00", sheets.FormatAmount("50"))
+	assert.Equal(t, "50.30", sheets.FormatAmount("50.3"))
+	assert.Equal(t, "0.125", sheets.FormatAmount("0.125"))
+	assert.Equal(t, "n/a", sheets.FormatAmount("n/a"))
+}
@@ -0,0 +1,52 @@
+// Package tenant identifies the ticket reseller that requests and events belong to.
+//
+// The tenant comes from the Tenant-ID header of HTTP requests, is kept in the header of published events
+// and in the tenant_id metadata of their messages, and is available in the contexts of handlers.
+package tenant
+
+import (
+	"context"
+	"regexp"
+)
+
+const (
+	// DefaultID is the tenant of requests without the header and of messages published before tenants were introduced.
+	DefaultID = "default"
+
+	HTTPHeader  = "Tenant-ID"
+	MetadataKey = "tenant_id"
+	// LogField is the log field with the tenant ID.
+	LogField = "tenant_id"
+)
+
+// IDs are parts of topic, consumer group and sheet names, so they are restricted to a safe subset of characters.
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+func ValidID(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+type ctxKey struct{}
+
+func ContextWithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the tenant of the request or message handled with ctx, DefaultID if there is none.
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(ctxKey{}).(string); ok && id != "" {
+		return id
+	}
+
+	return DefaultID
+}
+
+// Topic returns the topic of events named eventName. Tenants with dedicated topics have their events
+// published to "<eventName>.<tenant ID>", others share the eventName topic.
+func Topic(eventName string, dedicatedTenantID string) string {
+	if dedicatedTenantID == "" {
+		return eventName
+	}
+
+	return eventName + "." + dedicatedTenantID
+}
@@ -0,0 +1,150 @@
+package tests_test
+
+import (
+	"testing"
+	"tickets/config"
+	"tickets/entities"
+	"tickets/servicetest"
+	"tickets/tenant"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookingSaga(t *testing.T) {
+	h := servicetest.New(t, withBookingSaga(time.Minute))
+
+	booking := newBookingMade(2)
+	h.PublishEvent(booking)
+
+	bookingID := booking.BookingID.String()
+
+	var confirmed []entities.TicketBookingConfirmed
+	for i := 0; i < 2; i++ {
+		confirmed = append(confirmed, servicetest.WaitForEvent(h, func(event entities.TicketBookingConfirmed) bool {
+			if event.BookingID != bookingID {
+				return false
+			}
+			for _, c := range confirmed {
+				if c.TicketID == event.TicketID {
+					return false
+				}
+			}
+			return true
+		}))
+	}
+
+	for _, ticket := range confirmed {
+		assert.Equal(t, booking.TicketPrice, ticket.Price)
+		assert.Equal(t, booking.CustomerEmail, ticket.CustomerEmail)
+
+		h.AssertReceiptIssued(ticket.TicketID)
+		h.AssertSheetRowAdded("tickets-to-print", ticket.TicketID)
+	}
+
+	captured := h.Payments.Captured()
+	require.Len(t, captured, 1)
+	assert.Equal(t, bookingID, captured[0].BookingID)
+	assert.Equal(t, entities.Money{Amount: "50.00", Currency: "EUR"}, captured[0].Amount)
+}
+
+func TestBookingSaga_compensates_declined_payment(t *testing.T) {
+	h := servicetest.New(t, withBookingSaga(time.Minute))
+
+	booking := newBookingMade(1)
+	bookingID := booking.BookingID.String()
+
+	h.Payments.FailForTicket(bookingID, entities.ErrPaymentDeclined)
+	h.PublishEvent(booking)
+
+	failed := servicetest.WaitForEvent(h, func(event entities.BookingFailed) bool {
+		return event.BookingID == bookingID
+	})
+	assert.Contains(t, failed.Reason, "capture_payment failed")
+
+	servicetest.WaitForEvent(h, func(event entities.TicketsReserved) bool {
+		return event.BookingID == bookingID
+	})
+
+	// the payment wasn't captured, so there is nothing else to compensate
+	assert.Empty(t, h.Payments.Refunded())
+	assert.Empty(t, h.Receipts.Issued())
+}
+
+func TestBookingSaga_fails_booking_when_the_gateway_cant_capture_payments(t *testing.T) {
+	h := servicetest.New(t, withBookingSaga(time.Minute), servicetest.WithFakeGateway())
+
+	booking := newBookingMade(1)
+	bookingID := booking.BookingID.String()
+
+	h.PublishEvent(booking)
+
+	// the gateway's payments API supports only refunds, the capture must not be retried forever
+	failed := servicetest.WaitForEvent(h, func(event entities.BookingFailed) bool {
+		return event.BookingID == bookingID
+	})
+	assert.Contains(t, failed.Reason, "capture_payment failed")
+	assert.Contains(t, failed.Reason, entities.ErrCaptureNotSupported.Error())
+}
+
+func TestBookingSaga_compensates_timed_out_booking(t *testing.T) {
+	h := servicetest.New(t, withBookingSaga(500*time.Millisecond))
+
+	booking := newBookingMade(2)
+	bookingID := booking.BookingID.String()
+
+	// receipts can't be issued until the booking times out
+	h.Receipts.FailFirst(1000, nil)
+	h.PublishEvent(booking)
+
+	failed := servicetest.WaitForEvent(h, func(event entities.BookingFailed) bool {
+		return event.BookingID == bookingID
+	})
+	assert.Equal(t, "timed out in issuing_receipts", failed.Reason)
+
+	require.EventuallyWithT(t, func(t *assert.CollectT) {
+		refunded := h.Payments.Refunded()
+		if assert.Len(t, refunded, 1) {
+			assert.Equal(t, bookingID, refunded[0].PaymentReference)
+			assert.Equal(t, bookingID, refunded[0].IdempotencyKey)
+		}
+	}, 10*time.Second, 50*time.Millisecond)
+
+	// receipts issued after the booking failed are voided as well
+	h.Receipts.FailFirst(0, nil)
+
+	require.EventuallyWithT(t, func(t *assert.CollectT) {
+		voided := map[string]int{}
+		for _, receipt := range h.Receipts.Voided() {
+			voided[receipt.TicketID]++
+		}
+
+		issued := h.Receipts.Issued()
+		if assert.Len(t, issued, 2) {
+			for _, receipt := range issued {
+				assert.GreaterOrEqual(t, voided[receipt.TicketID], 1, "receipt of ticket %s not voided", receipt.TicketID)
+			}
+		}
+	}, 10*time.Second, 50*time.Millisecond)
+}
+
+func withBookingSaga(timeout time.Duration) servicetest.Option {
+	return servicetest.WithConfig(func(cfg *config.Config) {
+		cfg.BookingSaga.Enabled = true
+		cfg.BookingSaga.Timeout = timeout
+		cfg.BookingSaga.CheckInterval = 50 * time.Millisecond
+	})
+}
+
+func newBookingMade(numberOfTickets int) entities.BookingMade {
+	return entities.BookingMade{
+		Header:          entities.NewEventHeader(tenant.DefaultID),
+		NumberOfTickets: numberOfTickets,
+		BookingID:       uuid.New(),
+		CustomerEmail:   "email@example.com",
+		ShowId:          uuid.New(),
+		TicketPrice:     entities.Money{Amount: "25.00", Currency: "EUR"},
+	}
+}
@@ -1,188 +1,224 @@
 package tests_test
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"net/http"
-	"os"
 	"testing"
 	"tickets/api"
+	"tickets/config"
 	"tickets/entities"
-	"tickets/message"
-	"tickets/service"
+	"tickets/health"
+	"tickets/observability"
+	"tickets/servicetest"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/lithammer/shortuuid/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestComponent(t *testing.T) {
-	redisClient := message.NewRedisClient(os.Getenv("REDIS_ADDR"))
-	defer redisClient.Close()
+	traceProvider, spanExporter := observability.ConfigureInMemoryTraceProvider()
+	defer traceProvider.Shutdown(context.Background())
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	h := servicetest.New(t)
 
-	spreadsheetsService := &api.SpreadsheetsMock{}
-	receiptsService := &api.ReceiptsMock{}
+	assertReadinessChecksPass(t, h, "router")
 
-	go func() {
-		svc := service.New(
-			redisClient,
-			spreadsheetsService,
-			receiptsService,
-		)
-		assert.NoError(t, svc.Run(ctx))
-	}()
-
-	waitForHttpServer(t)
-
-	ticket := TicketStatus{
+	ticket := servicetest.TicketStatus{
 		TicketID: uuid.NewString(),
 		Status:   "confirmed",
-		Price: Money{
+		Price: servicetest.Money{
 			Amount:   "50.30",
 			Currency: "GBP",
 		},
-		Email:     "email@example.com",
-		BookingID: uuid.NewString(),
+		CustomerEmail: "email@example.com",
+		BookingID:     uuid.NewString(),
 	}
 
-	sendTicketsStatus(t, TicketsStatusRequest{Tickets: []TicketStatus{ticket}})
+	h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{ticket}})
+
+	confirmed := servicetest.WaitForEvent(h, func(event entities.TicketBookingConfirmed) bool {
+		return event.TicketID == ticket.TicketID
+	})
+	assert.Equal(t, ticket.BookingID, confirmed.BookingID)
 
-	assertReceiptForTicketIssued(t, receiptsService, ticket)
-	assertRowToSheetAdded(t, spreadsheetsService, ticket, "tickets-to-print")
+	receipt := h.AssertReceiptIssued(ticket.TicketID)
+	assert.Equal(t, ticket.Price.Amount, receipt.Price.Amount)
+	assert.Equal(t, ticket.Price.Currency, receipt.Price.Currency)
 
-	sendTicketsStatus(t, TicketsStatusRequest{Tickets: []TicketStatus{
+	h.AssertSheetRowAdded("tickets-to-print", ticket.TicketID, ticket.CustomerEmail)
+	assertHandlerSpansHaveParent(t, spanExporter, "/tickets-status", "AppendToTracker", "IssueReceipt")
+
+	h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{
 		{
-			TicketID: ticket.TicketID,
-			Status:   "canceled",
-			Email:    ticket.Email,
+			TicketID:      ticket.TicketID,
+			Status:        "canceled",
+			CustomerEmail: ticket.CustomerEmail,
 		},
 	}})
 
-	assertRowToSheetAdded(t, spreadsheetsService, ticket, "tickets-to-refund")
+	h.AssertSheetRowAdded("tickets-to-refund", ticket.TicketID)
 }
 
-func assertRowToSheetAdded(t *testing.T, spreadsheetsService *api.SpreadsheetsMock, ticket TicketStatus, sheetName string) bool {
-	return assert.EventuallyWithT(
-		t,
-		func(t *assert.CollectT) {
-			rows, ok := spreadsheetsService.Rows[sheetName]
-			if !assert.True(t, ok, "sheet %s not found", sheetName) {
-				return
-			}
-
-			allValues := []string{}
+func TestComponent_retries_failed_gateway_calls(t *testing.T) {
+	h := servicetest.New(t)
 
-			for _, row := range rows {
-				for _, col := range row {
-					allValues = append(allValues, col)
-				}
-			}
+	h.Receipts.FailFirst(2, api.StatusError{StatusCode: http.StatusServiceUnavailable})
+	h.Spreadsheets.FailFirst(1, nil)
 
-			assert.Contains(t, allValues, ticket.TicketID, "ticket id not found in sheet %s", sheetName)
+	ticket := servicetest.TicketStatus{
+		TicketID: uuid.NewString(),
+		Status:   "confirmed",
+		Price: servicetest.Money{
+			Amount:   "10.00",
+			Currency: "EUR",
 		},
-		10*time.Second,
-		100*time.Millisecond,
-	)
+		CustomerEmail: "email@example.com",
+		BookingID:     uuid.NewString(),
+	}
+
+	h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{ticket}})
+
+	h.AssertReceiptIssued(ticket.TicketID)
+	h.AssertSheetRowAdded("tickets-to-print", ticket.TicketID)
+
+	calls := h.Receipts.Calls()
+	require.Len(t, calls, 3)
+	assert.Equal(t, 3, h.Receipts.Attempts(ticket.TicketID))
+	assert.ErrorAs(t, calls[0].Err, &api.StatusError{})
+	assert.NoError(t, calls[2].Err)
+	assert.True(t, calls[2].At.After(calls[0].At))
+
+	// the first call reads the header row of the sheet, before the row is appended
+	spreadsheetsCalls := h.Spreadsheets.Calls()
+	require.NotEmpty(t, spreadsheetsCalls)
+	assert.Error(t, spreadsheetsCalls[0].Err)
+	assert.Equal(t, 1, h.Spreadsheets.Attempts(ticket.TicketID))
 }
 
-func assertReceiptForTicketIssued(t *testing.T, receiptsService *api.ReceiptsMock, ticket TicketStatus) {
-	assert.EventuallyWithT(
-		t,
-		func(collectT *assert.CollectT) {
-			issuedReceipts := len(receiptsService.IssuedReceipts)
-			t.Log("issued receipts", issuedReceipts)
+func TestComponent_with_fake_gateway(t *testing.T) {
+	h := servicetest.New(t, servicetest.WithFakeGateway())
 
-			assert.Greater(collectT, issuedReceipts, 0, "no receipts issued")
+	ticket := servicetest.TicketStatus{
+		TicketID: uuid.NewString(),
+		Status:   "confirmed",
+		Price: servicetest.Money{
+			Amount:   "25.00",
+			Currency: "USD",
 		},
-		10*time.Second,
-		100*time.Millisecond,
-	)
-
-	var receipt entities.IssueReceiptRequest
-	var ok bool
-	for _, issuedReceipt := range receiptsService.IssuedReceipts {
-		if issuedReceipt.TicketID != ticket.TicketID {
-			continue
-		}
-		receipt = issuedReceipt
-		ok = true
-		break
+		CustomerEmail: "email@example.com",
+		BookingID:     uuid.NewString(),
 	}
-	require.Truef(t, ok, "receipt for ticket %s not found", ticket.TicketID)
 
-	assert.Equal(t, ticket.TicketID, receipt.TicketID)
-	assert.Equal(t, ticket.Price.Amount, receipt.Price.Amount)
-	assert.Equal(t, ticket.Price.Currency, receipt.Price.Currency)
-}
+	// header rows of sheets are prepared on startup, without a correlation ID
+	startupRequests := len(h.Gateway.State().Requests)
 
-type TicketsStatusRequest struct {
-	Tickets []TicketStatus `json:"tickets"`
-}
+	correlationID := h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{ticket}})
 
-type TicketStatus struct {
-	TicketID  string `json:"ticket_id"`
-	Status    string `json:"status"`
-	Price     Money  `json:"price"`
-	Email     string `json:"email"`
-	BookingID string `json:"booking_id"`
-}
+	h.AssertReceiptIssued(ticket.TicketID)
+	h.AssertSheetRowAdded("tickets-to-print", ticket.TicketID, ticket.Price.Amount)
 
-type Money struct {
-	Amount   string `json:"amount"`
-	Currency string `json:"currency"`
+	for _, req := range h.Gateway.State().Requests[startupRequests:] {
+		assert.Equal(t, correlationID, req.CorrelationID, "correlation ID not passed to %s %s", req.Method, req.Path)
+	}
 }
 
-func sendTicketsStatus(t *testing.T, req TicketsStatusRequest) {
-	t.Helper()
+func TestComponent_protobuf_events(t *testing.T) {
+	h := servicetest.New(t, servicetest.WithConfig(func(cfg *config.Config) {
+		cfg.Messaging.ProtobufEvents = []string{"TicketBookingConfirmed", "TicketBookingCanceled"}
+	}))
 
-	payload, err := json.Marshal(req)
-	require.NoError(t, err)
+	ticket := servicetest.TicketStatus{
+		TicketID: uuid.NewString(),
+		Status:   "confirmed",
+		Price: servicetest.Money{
+			Amount:   "42.00",
+			Currency: "PLN",
+		},
+		CustomerEmail: "email@example.com",
+		BookingID:     uuid.NewString(),
+	}
 
-	correlationID := shortuuid.New()
+	h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{ticket}})
 
-	ticketIDs := make([]string, 0, len(req.Tickets))
-	for _, ticket := range req.Tickets {
-		ticketIDs = append(ticketIDs, ticket.TicketID)
-	}
+	confirmed := servicetest.WaitForEvent(h, func(event entities.TicketBookingConfirmed) bool {
+		return event.TicketID == ticket.TicketID
+	})
+	assert.Equal(t, ticket.BookingID, confirmed.BookingID)
+	assert.False(t, confirmed.Header.PublishedAt.IsZero())
 
-	httpReq, err := http.NewRequest(
-		http.MethodPost,
-		"http://localhost:8080/tickets-status",
-		bytes.NewBuffer(payload),
-	)
-	require.NoError(t, err)
+	receipt := h.AssertReceiptIssued(ticket.TicketID)
+	assert.Equal(t, ticket.Price.Amount, receipt.Price.Amount)
 
-	httpReq.Header.Set("Correlation-ID", correlationID)
-	httpReq.Header.Set("Content-Type", "application/json")
+	h.AssertSheetRowAdded("tickets-to-print", ticket.TicketID, ticket.CustomerEmail, ticket.Price.Amount)
 
-	resp, err := http.DefaultClient.Do(httpReq)
-	require.NoError(t, err)
-	require.Equal(t, http.StatusOK, resp.StatusCode)
+	h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{
+		{
+			TicketID:      ticket.TicketID,
+			Status:        "canceled",
+			CustomerEmail: ticket.CustomerEmail,
+		},
+	}})
+
+	h.AssertSheetRowAdded("tickets-to-refund", ticket.TicketID)
 }
 
-func waitForHttpServer(t *testing.T) {
+func assertHandlerSpansHaveParent(t *testing.T, spanExporter *tracetest.InMemoryExporter, parentSpanName string, handlerNames ...string) {
 	t.Helper()
 
-	require.EventuallyWithT(
+	assert.EventuallyWithT(
 		t,
 		func(t *assert.CollectT) {
-			resp, err := http.Get("http://localhost:8080/health")
-			if !assert.NoError(t, err) {
+			spans := spanExporter.GetSpans()
+
+			var parent *tracetest.SpanStub
+			for i := range spans {
+				if spans[i].Name == parentSpanName {
+					parent = &spans[i]
+					break
+				}
+			}
+			if !assert.NotNil(t, parent, "span %s not found", parentSpanName) {
 				return
 			}
-			defer resp.Body.Close()
 
-			if assert.Less(t, resp.StatusCode, 300, "API not ready, http status: %d", resp.StatusCode) {
-				return
+			for _, handlerName := range handlerNames {
+				found := false
+				for _, span := range spans {
+					if span.Name != handlerName {
+						continue
+					}
+					found = true
+
+					assert.Equal(t, parent.SpanContext.TraceID(), span.SpanContext.TraceID(), "span %s is in a different trace", handlerName)
+					assert.True(t, span.Parent.IsValid(), "span %s has no parent", handlerName)
+				}
+
+				assert.True(t, found, "span %s not found", handlerName)
 			}
 		},
-		time.Second*10,
-		time.Millisecond*50,
+		10*time.Second,
+		100*time.Millisecond,
 	)
 }
+
+func assertReadinessChecksPass(t *testing.T, h *servicetest.Harness, checkNames ...string) {
+	t.Helper()
+
+	resp, err := http.Get(h.BaseURL + "/health/ready")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var report health.Report
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+
+	for _, name := range checkNames {
+		check, ok := report.Checks[name]
+		if assert.True(t, ok, "check %s not reported", name) {
+			assert.Equal(t, health.StatusOK, check.Status, "check %s failed: %s", name, check.Error)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package tests_test
+
+import (
+	"testing"
+	"tickets/config"
+	"tickets/entities"
+	"tickets/servicetest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDailySummary(t *testing.T) {
+	h := servicetest.New(
+		t,
+		servicetest.WithConfig(func(cfg *config.Config) {
+			cfg.DailySummary.Enabled = true
+			cfg.DailySummary.RunAt = "00:00"
+			cfg.DailySummary.CheckInterval = 50 * time.Millisecond
+		}),
+	)
+
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+
+	summary := servicetest.WaitForEvent(h, func(event entities.DailySalesSummarized) bool {
+		return event.Date == yesterday
+	})
+	assert.Equal(t, 0, summary.ConfirmedTickets)
+	assert.Empty(t, summary.Currencies)
+
+	h.AssertSheetRowAdded("daily-summary", yesterday, "0.00")
+}
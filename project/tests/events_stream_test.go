@@ -0,0 +1,146 @@
+package tests_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"tickets/config"
+	"tickets/servicetest"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventsStream(t *testing.T) {
+	h := servicetest.New(t, servicetest.WithConfig(func(cfg *config.Config) {
+		cfg.EventsStream.HeartbeatInterval = 50 * time.Millisecond
+	}))
+
+	ticketID := uuid.NewString()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		h.BaseURL+"/events/stream?types=TicketBookingConfirmed,TicketBookingCanceled&ticket_id="+ticketID,
+		nil,
+	)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+defaultTenantKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// another ticket, filtered out
+	h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{{
+		TicketID:      uuid.NewString(),
+		Status:        "confirmed",
+		Price:         servicetest.Money{Amount: "10.00", Currency: "EUR"},
+		CustomerEmail: "other@example.com",
+	}}})
+	h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{{
+		TicketID:      ticketID,
+		Status:        "confirmed",
+		Price:         servicetest.Money{Amount: "10.00", Currency: "EUR"},
+		CustomerEmail: "email@example.com",
+	}}})
+	h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{{
+		TicketID:      ticketID,
+		Status:        "canceled",
+		CustomerEmail: "email@example.com",
+	}}})
+
+	events, heartbeats := readServerSentEvents(t, resp, 2)
+
+	assert.Equal(t, "TicketBookingConfirmed", events[0].Name)
+	assert.Equal(t, "TicketBookingCanceled", events[1].Name)
+	for _, e := range events {
+		assert.NotEmpty(t, e.ID)
+
+		var data struct {
+			TicketID string `json:"ticket_id"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(e.Data), &data))
+		assert.Equal(t, ticketID, data.TicketID)
+	}
+
+	_, heartbeats = readServerSentEvents(t, resp, 0)
+	assert.Positive(t, heartbeats)
+}
+
+func TestEventsStream_unknown_type(t *testing.T) {
+	h := servicetest.New(t)
+
+	resp, err := doRequest(http.MethodGet, h.BaseURL+"/events/stream?types=TicketPrinted", defaultTenantKey)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestEventsStream_requires_tenant_key(t *testing.T) {
+	h := servicetest.New(t)
+
+	resp, err := doRequest(http.MethodGet, h.BaseURL+"/events/stream", "")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+type serverSentEvent struct {
+	ID   string
+	Name string
+	Data string
+}
+
+// readServerSentEvents reads until count events are received, or a heartbeat when count is 0.
+func readServerSentEvents(t *testing.T, resp *http.Response, count int) ([]serverSentEvent, int) {
+	t.Helper()
+
+	var events []serverSentEvent
+	var heartbeats int
+
+	scanner := bufio.NewScanner(resp.Body)
+
+	var current serverSentEvent
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, ":"):
+			heartbeats++
+			if count == 0 {
+				return nil, heartbeats
+			}
+		case strings.HasPrefix(line, "id: "):
+			current.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			current.Name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.Data = strings.TrimPrefix(line, "data: ")
+		case line == "" && current.Name != "":
+			events = append(events, current)
+			current = serverSentEvent{}
+
+			if len(events) == count {
+				return events, heartbeats
+			}
+		}
+	}
+
+	require.FailNow(t, "events stream ended", "received %d of %d events: %v", len(events), count, scanner.Err())
+
+	return nil, 0
+}
@@ -0,0 +1,99 @@
+package tests_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"tickets/entities"
+	"tickets/notifications"
+	"tickets/servicetest"
+	"tickets/tenant"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifications(t *testing.T) {
+	h := servicetest.New(t)
+
+	ticket := servicetest.TicketStatus{
+		TicketID: uuid.NewString(),
+		Status:   "confirmed",
+		Price: servicetest.Money{
+			Amount:   "50.30",
+			Currency: "GBP",
+		},
+		CustomerEmail: "customer@example.com",
+		BookingID:     uuid.NewString(),
+	}
+	h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{ticket}})
+
+	confirmed := waitForEmail(t, h, ticket.TicketID, "Your ticket is confirmed")
+	assert.Equal(t, ticket.CustomerEmail, confirmed.To)
+	assert.Contains(t, confirmed.Body, "50.30 GBP")
+	assert.Contains(t, confirmed.Body, ticket.BookingID)
+
+	ticket.Status = "canceled"
+	h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{ticket}})
+
+	canceled := waitForEmail(t, h, ticket.TicketID, "Your ticket was canceled")
+	assert.Equal(t, ticket.CustomerEmail, canceled.To)
+}
+
+func TestNotifications_sends_once_per_event(t *testing.T) {
+	h := servicetest.New(t)
+
+	// sending fails until the event is retried
+	h.Notifications.SetErr(errors.New("SMTP server unavailable"))
+
+	event := entities.TicketBookingConfirmed{
+		Header:        entities.NewEventHeader(tenant.DefaultID),
+		TicketID:      uuid.NewString(),
+		CustomerEmail: "customer@example.com",
+		Price:         entities.Money{Amount: "10.00", Currency: "EUR"},
+	}
+	h.PublishEvent(event)
+
+	time.Sleep(200 * time.Millisecond)
+	h.Notifications.SetErr(nil)
+
+	waitForEmail(t, h, event.TicketID, "Your ticket is confirmed")
+
+	// redelivered event
+	h.PublishEvent(event)
+	h.AssertReceiptIssued(event.TicketID)
+
+	assert.Never(t, func() bool {
+		return len(emailsOfTicket(h, event.TicketID)) > 1
+	}, time.Second, 50*time.Millisecond, "email sent more than once")
+}
+
+func waitForEmail(t *testing.T, h *servicetest.Harness, ticketID string, subject string) notifications.Email {
+	t.Helper()
+
+	var email notifications.Email
+	require.EventuallyWithT(t, func(t *assert.CollectT) {
+		for _, e := range emailsOfTicket(h, ticketID) {
+			if e.Subject == subject {
+				email = e
+				return
+			}
+		}
+		assert.Fail(t, "email not sent", "subject %q", subject)
+	}, 10*time.Second, 50*time.Millisecond)
+
+	return email
+}
+
+func emailsOfTicket(h *servicetest.Harness, ticketID string) []notifications.Email {
+	var emails []notifications.Email
+	for _, email := range h.Notifications.Sent() {
+		if strings.Contains(email.Body, ticketID) {
+			emails = append(emails, email)
+		}
+	}
+
+	return emails
+}
@@ -0,0 +1,94 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"tickets/servicetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPI_serves_spec(t *testing.T) {
+	h := servicetest.New(t)
+
+	var spec struct {
+		OpenAPI string         `json:"openapi"`
+		Paths   map[string]any `json:"paths"`
+	}
+	require.True(t, getJSON(t, h.BaseURL+"/openapi.json", "", &spec))
+
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+	assert.Contains(t, spec.Paths, "/tickets-status")
+	assert.Contains(t, spec.Paths, "/webhooks/subscriptions/{id}")
+}
+
+func TestOpenAPI_rejects_invalid_requests(t *testing.T) {
+	h := servicetest.New(t)
+
+	testCases := []struct {
+		name          string
+		method        string
+		path          string
+		body          string
+		expectedError string
+	}{
+		{
+			name:          "unknown_ticket_status",
+			method:        http.MethodPost,
+			path:          "/tickets-status",
+			body:          `{"tickets":[{"ticket_id":"1","status":"printed","customer_email":"email@example.com"}]}`,
+			expectedError: "/tickets/0/status",
+		},
+		{
+			name:          "missing_ticket_id",
+			method:        http.MethodPost,
+			path:          "/tickets-status",
+			body:          `{"tickets":[{"status":"confirmed","customer_email":"email@example.com"}]}`,
+			expectedError: "ticket_id",
+		},
+		{
+			name:          "missing_body",
+			method:        http.MethodPost,
+			path:          "/tickets-status",
+			expectedError: "request body",
+		},
+		{
+			name:          "unknown_webhook_event_type",
+			method:        http.MethodPost,
+			path:          "/webhooks/subscriptions",
+			body:          `{"url":"http://localhost/hook","event_types":["TicketPrinted"]}`,
+			expectedError: "/event_types/0",
+		},
+		{
+			name:          "deliveries_limit_out_of_range",
+			method:        http.MethodGet,
+			path:          "/webhooks/subscriptions/0b1f6f5e-32ea-4bb1-8d2d-6b8c3f1e0e4a/deliveries?limit=0",
+			expectedError: "limit",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, h.BaseURL+tc.path, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			if tc.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+			var body struct {
+				Error string `json:"error"`
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Contains(t, body.Error, tc.expectedError)
+		})
+	}
+}
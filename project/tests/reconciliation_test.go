@@ -0,0 +1,101 @@
+package tests_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"tickets/config"
+	"tickets/servicetest"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reconciliationReport struct {
+	Sheets []struct {
+		Sheet      string   `json:"sheet"`
+		Tickets    int      `json:"tickets"`
+		Missing    []string `json:"missing"`
+		Duplicates []struct {
+			TicketID string `json:"ticket_id"`
+			Rows     int    `json:"rows"`
+		} `json:"duplicates"`
+	} `json:"sheets"`
+}
+
+func TestReconciliation(t *testing.T) {
+	duplicatedTicketID := uuid.NewString()
+
+	h := servicetest.New(
+		t,
+		servicetest.WithConfig(func(cfg *config.Config) {
+			cfg.Reconciliation.GracePeriod = 0
+		}),
+		servicetest.WithSheetRows(
+			"tickets-to-print",
+			[]string{"Ticket ID", "Customer email", "Price", "Currency", "Confirmed at"},
+			[]string{duplicatedTicketID, "email@example.com", "10.00", "EUR", ""},
+			[]string{duplicatedTicketID, "email@example.com", "10.00", "EUR", ""},
+		),
+	)
+
+	resp, err := doRequest(http.MethodPost, h.BaseURL+"/admin/reconciliation", "")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = doRequest(http.MethodGet, h.BaseURL+"/admin/reconciliation", servicetest.AdminKey)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "nothing was reconciled yet")
+
+	ticket := servicetest.TicketStatus{
+		TicketID: uuid.NewString(),
+		Status:   "confirmed",
+		Price: servicetest.Money{
+			Amount:   "25.00",
+			Currency: "EUR",
+		},
+		CustomerEmail: "email@example.com",
+		BookingID:     uuid.NewString(),
+	}
+	h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{ticket}})
+	h.AssertSheetRowAdded("tickets-to-print", ticket.TicketID)
+
+	var report reconciliationReport
+	require.EventuallyWithT(t, func(t *assert.CollectT) {
+		report = reconcile(t, h)
+		if assert.Len(t, report.Sheets, 2) {
+			assert.Equal(t, 1, report.Sheets[0].Tickets, "ticket should be recorded")
+		}
+	}, 10*time.Second, 50*time.Millisecond)
+
+	printSheet := report.Sheets[0]
+	assert.Equal(t, "tickets-to-print", printSheet.Sheet)
+	assert.Empty(t, printSheet.Missing)
+	require.Len(t, printSheet.Duplicates, 1)
+	assert.Equal(t, duplicatedTicketID, printSheet.Duplicates[0].TicketID)
+	assert.Equal(t, 2, printSheet.Duplicates[0].Rows)
+
+	var lastReport reconciliationReport
+	require.True(t, getJSON(t, h.BaseURL+"/admin/reconciliation", servicetest.AdminKey, &lastReport))
+	assert.Equal(t, report, lastReport)
+}
+
+func reconcile(t assert.TestingT, h *servicetest.Harness) reconciliationReport {
+	var report reconciliationReport
+
+	resp, err := doRequest(http.MethodPost, h.BaseURL+"/admin/reconciliation", servicetest.AdminKey)
+	if !assert.NoError(t, err) {
+		return report
+	}
+	defer resp.Body.Close()
+
+	if assert.Equal(t, http.StatusOK, resp.StatusCode) {
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	}
+
+	return report
+}
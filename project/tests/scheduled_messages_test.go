@@ -0,0 +1,40 @@
+package tests_test
+
+import (
+	"net/http"
+	"testing"
+	"tickets/servicetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduledMessages_admin(t *testing.T) {
+	h := servicetest.New(t)
+
+	var messages []map[string]any
+	require.True(t, getJSON(t, h.BaseURL+"/admin/scheduled-messages?limit=10", servicetest.AdminKey, &messages))
+	assert.Empty(t, messages)
+
+	resp, err := doRequest(http.MethodDelete, h.BaseURL+"/admin/scheduled-messages/show-reminder-1", servicetest.AdminKey)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestScheduledMessages_admin_requires_key(t *testing.T) {
+	h := servicetest.New(t)
+
+	for _, apiKey := range []string{"", "not-the-admin-key-0123"} {
+		resp, err := doRequest(http.MethodGet, h.BaseURL+"/admin/scheduled-messages", apiKey)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, err = doRequest(http.MethodDelete, h.BaseURL+"/admin/scheduled-messages/show-reminder-1", apiKey)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+}
@@ -0,0 +1,68 @@
+package tests_test
+
+import (
+	"testing"
+	"tickets/servicetest"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSheets_header_rows_created(t *testing.T) {
+	h := servicetest.New(t)
+
+	// the service doesn't need the gateway to start, sheets are prepared when rows are appended
+	h.Spreadsheets.FailFirst(1, nil)
+
+	ticket := servicetest.TicketStatus{
+		TicketID:      uuid.NewString(),
+		Status:        "confirmed",
+		Price:         servicetest.Money{Amount: "12.5", Currency: "EUR"},
+		CustomerEmail: "email@example.com",
+		BookingID:     uuid.NewString(),
+	}
+	h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{ticket}})
+	h.AssertSheetRowAdded("tickets-to-print", ticket.TicketID)
+
+	ticket.Status = "canceled"
+	h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{ticket}})
+	h.AssertSheetRowAdded("tickets-to-refund", ticket.TicketID)
+
+	assert.Equal(
+		t,
+		[]string{"Ticket ID", "Customer email", "Price", "Currency", "Confirmed at"},
+		h.Spreadsheets.SheetRows("tickets-to-print")[0],
+	)
+	assert.Equal(
+		t,
+		[]string{"Ticket ID", "Customer email", "Price", "Currency", "Canceled at"},
+		h.Spreadsheets.SheetRows("tickets-to-refund")[0],
+	)
+}
+
+func TestSheets_rows_follow_reordered_columns(t *testing.T) {
+	h := servicetest.New(t, servicetest.WithSheetRows(
+		"tickets-to-print",
+		[]string{"Customer email", "Ticket ID", "Currency", "Price"},
+	))
+
+	ticket := servicetest.TicketStatus{
+		TicketID: uuid.NewString(),
+		Status:   "confirmed",
+		Price: servicetest.Money{
+			Amount:   "12.5",
+			Currency: "EUR",
+		},
+		CustomerEmail: "email@example.com",
+		BookingID:     uuid.NewString(),
+	}
+	h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{ticket}})
+
+	row := h.AssertSheetRowAdded("tickets-to-print", ticket.TicketID)
+	assert.Equal(t, []string{ticket.CustomerEmail, ticket.TicketID, "EUR", "12.50"}, row)
+
+	rows := h.Spreadsheets.SheetRows("tickets-to-print")
+	require.NotEmpty(t, rows)
+	assert.Equal(t, []string{"Customer email", "Ticket ID", "Currency", "Price"}, rows[0], "header row should be kept")
+}
@@ -0,0 +1,162 @@
+package tests_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"tickets/config"
+	"tickets/servicetest"
+	"tickets/tenant"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenants(t *testing.T) {
+	h := servicetest.New(t, servicetest.WithConfig(func(cfg *config.Config) {
+		cfg.Tenants = config.Tenants{
+			"reseller-a": {DedicatedTopics: true},
+			"reseller-b": {Spreadsheets: config.Spreadsheets{TicketsToPrint: "reseller-b-printing"}},
+		}
+	}))
+
+	confirmed := func() servicetest.TicketStatus {
+		return servicetest.TicketStatus{
+			TicketID:      uuid.NewString(),
+			Status:        "confirmed",
+			Price:         servicetest.Money{Amount: "10.00", Currency: "EUR"},
+			CustomerEmail: "email@example.com",
+		}
+	}
+
+	defaultTicket := confirmed()
+	h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{defaultTicket}})
+	h.AssertSheetRowAdded("tickets-to-print", defaultTicket.TicketID)
+
+	// events of reseller-a go through its dedicated topics
+	ticketA := confirmed()
+	h.SendTenantTicketsStatus("reseller-a", servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{ticketA}})
+	h.AssertSheetRowAdded("reseller-a-tickets-to-print", ticketA.TicketID)
+	h.AssertReceiptIssued(ticketA.TicketID)
+
+	ticketB := confirmed()
+	h.SendTenantTicketsStatus("reseller-b", servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{ticketB}})
+	h.AssertSheetRowAdded("reseller-b-printing", ticketB.TicketID)
+
+	ticketB.Status = "canceled"
+	h.SendTenantTicketsStatus("reseller-b", servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{ticketB}})
+	h.AssertSheetRowAdded("reseller-b-tickets-to-refund", ticketB.TicketID)
+}
+
+func TestTenants_unknown_tenant(t *testing.T) {
+	h := servicetest.New(t)
+
+	req, err := http.NewRequest(
+		http.MethodPost,
+		h.BaseURL+"/tickets-status",
+		strings.NewReader(`{"tickets":[]}`),
+	)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(tenant.HTTPHeader, "reseller-a")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestTenants_webhook_subscriptions_are_scoped(t *testing.T) {
+	h := servicetest.New(t, servicetest.WithConfig(func(cfg *config.Config) {
+		cfg.Tenants = config.Tenants{"reseller-a": {}}
+	}))
+
+	subscriptionID := createWebhookSubscription(t, h, map[string]any{
+		"url":         "http://localhost/hook",
+		"event_types": []string{"TicketBookingConfirmed"},
+	})
+
+	tenantGet := func(path string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, h.BaseURL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set(tenant.HTTPHeader, "reseller-a")
+		req.Header.Set("Authorization", "Bearer "+servicetest.TenantKey("reseller-a"))
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+
+		return resp
+	}
+
+	resp := tenantGet("/webhooks/subscriptions/" + subscriptionID)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = tenantGet("/webhooks/subscriptions")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var subscriptions []map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&subscriptions))
+	assert.Empty(t, subscriptions)
+
+	var defaultSubscriptions []map[string]any
+	require.True(t, getJSON(t, h.BaseURL+"/webhooks/subscriptions", defaultTenantKey, &defaultSubscriptions))
+	assert.Len(t, defaultSubscriptions, 1)
+}
+
+func TestTenants_webhook_subscriptions_require_tenant_key(t *testing.T) {
+	h := servicetest.New(t, servicetest.WithConfig(func(cfg *config.Config) {
+		cfg.Tenants = config.Tenants{"reseller-a": {}}
+	}))
+
+	testCases := []struct {
+		name           string
+		apiKey         string
+		tenantHeader   string
+		expectedStatus int
+	}{
+		{
+			name:           "no_key",
+			tenantHeader:   "reseller-a",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "admin_key",
+			apiKey:         servicetest.AdminKey,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "key_of_another_tenant",
+			apiKey:         servicetest.TenantKey(tenant.DefaultID),
+			tenantHeader:   "reseller-a",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "key_without_header",
+			apiKey:         servicetest.TenantKey("reseller-a"),
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, h.BaseURL+"/webhooks/subscriptions", nil)
+			require.NoError(t, err)
+			if tc.apiKey != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.apiKey)
+			}
+			if tc.tenantHeader != "" {
+				req.Header.Set(tenant.HTTPHeader, tc.tenantHeader)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"tickets/config"
+	"tickets/servicetest"
+	"tickets/tenant"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTicketsStatus_signature_verification(t *testing.T) {
+	currentSecret := "current-secret-0123456789"
+	previousSecret := "previous-secret-0123456789"
+
+	h := servicetest.New(t, servicetest.WithConfig(func(cfg *config.Config) {
+		cfg.InboundWebhooks.Secrets = []string{currentSecret, previousSecret}
+		cfg.Tenants = config.Tenants{"reseller-a": {}}
+	}))
+
+	ticket := servicetest.TicketStatus{
+		TicketID:      uuid.NewString(),
+		Status:        "confirmed",
+		Price:         servicetest.Money{Amount: "10.00", Currency: "EUR"},
+		CustomerEmail: "email@example.com",
+	}
+	body, err := json.Marshal(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{ticket}})
+	require.NoError(t, err)
+
+	unsigned := newTicketsStatusRequest(t, h, body)
+	assertUnauthorized(t, unsigned, "missing Webhook-Id header")
+
+	wrongSecret := newTicketsStatusRequest(t, h, body)
+	servicetest.SignRequest(wrongSecret, "unknown-secret-0123456789", body)
+	assertUnauthorized(t, wrongSecret, "signature doesn't match")
+
+	tampered := newTicketsStatusRequest(t, h, bytes.ReplaceAll(body, []byte("10.00"), []byte("0.01")))
+	servicetest.SignRequest(tampered, currentSecret, body)
+	assertUnauthorized(t, tampered, "signature doesn't match")
+
+	otherTenant := newTicketsStatusRequest(t, h, body)
+	servicetest.SignRequest(otherTenant, currentSecret, body)
+	otherTenant.Header.Set(tenant.HTTPHeader, "reseller-a")
+	assertUnauthorized(t, otherTenant, "signature doesn't match")
+
+	// senders which didn't switch to the current secret yet are accepted
+	signed := newTicketsStatusRequest(t, h, body)
+	servicetest.SignRequest(signed, previousSecret, body)
+
+	resp, err := http.DefaultClient.Do(signed)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	h.AssertSheetRowAdded("tickets-to-print", ticket.TicketID)
+
+	replayed := newTicketsStatusRequest(t, h, body)
+	replayed.Header = signed.Header.Clone()
+	assertUnauthorized(t, replayed, "request ID was already used")
+
+	tooLarge := newTicketsStatusRequest(t, h, bytes.Repeat([]byte(" "), 2<<20))
+	servicetest.SignRequest(tooLarge, currentSecret, body)
+
+	resp, err = http.DefaultClient.Do(tooLarge)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
+
+func newTicketsStatusRequest(t *testing.T, h *servicetest.Harness, body []byte) *http.Request {
+	req, err := http.NewRequest(http.MethodPost, h.BaseURL+"/tickets-status", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	return req
+}
+
+func assertUnauthorized(t *testing.T, req *http.Request, expectedReason string) {
+	t.Helper()
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	var body struct {
+		Error string `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, expectedReason, body.Error)
+}
@@ -0,0 +1,265 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"tickets/config"
+	"tickets/servicetest"
+	"tickets/tenant"
+	"tickets/webhooks"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks_delivers_signed_events(t *testing.T) {
+	h := servicetest.New(t)
+
+	receiver := newWebhookReceiver(t, http.StatusOK)
+	secret := "test-secret-0123456789"
+
+	subscriptionID := createWebhookSubscription(t, h, map[string]any{
+		"url":         receiver.server.URL,
+		"event_types": []string{"TicketBookingConfirmed"},
+		"secret":      secret,
+	})
+
+	ticket := servicetest.TicketStatus{
+		TicketID:      uuid.NewString(),
+		Status:        "confirmed",
+		Price:         servicetest.Money{Amount: "30.00", Currency: "EUR"},
+		CustomerEmail: "email@example.com",
+		BookingID:     uuid.NewString(),
+	}
+	h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{ticket}})
+
+	var request receivedWebhook
+	require.Eventually(t, func() bool {
+		requests := receiver.requests()
+		if len(requests) == 0 {
+			return false
+		}
+		request = requests[0]
+		return true
+	}, 10*time.Second, 50*time.Millisecond)
+
+	timestamp, err := strconv.ParseInt(request.header.Get(webhooks.HeaderTimestamp), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, "sha256="+webhooks.Sign(secret, timestamp, request.body), request.header.Get(webhooks.HeaderSignature))
+	assert.Equal(t, "TicketBookingConfirmed", request.header.Get(webhooks.HeaderEventType))
+
+	var payload struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			TicketID string `json:"ticket_id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(request.body, &payload))
+	assert.Equal(t, request.header.Get(webhooks.HeaderEventID), payload.ID)
+	assert.Equal(t, "TicketBookingConfirmed", payload.Type)
+	assert.Equal(t, ticket.TicketID, payload.Data.TicketID)
+
+	// not subscribed to cancellations
+	h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{
+		{TicketID: ticket.TicketID, Status: "canceled", CustomerEmail: ticket.CustomerEmail},
+	}})
+	h.AssertSheetRowAdded("tickets-to-refund", ticket.TicketID)
+
+	// the delivery is logged after the response is received
+	require.EventuallyWithT(t, func(t *assert.CollectT) {
+		var deliveries []struct {
+			EventType string `json:"event_type"`
+			Succeeded bool   `json:"succeeded"`
+		}
+		if !getJSON(t, h.BaseURL+"/webhooks/subscriptions/"+subscriptionID+"/deliveries", defaultTenantKey, &deliveries) {
+			return
+		}
+		if assert.Len(t, deliveries, 1) {
+			assert.Equal(t, "TicketBookingConfirmed", deliveries[0].EventType)
+			assert.True(t, deliveries[0].Succeeded)
+		}
+	}, 10*time.Second, 50*time.Millisecond)
+}
+
+func TestWebhooks_disables_failing_subscription(t *testing.T) {
+	h := servicetest.New(t, servicetest.WithConfig(func(cfg *config.Config) {
+		cfg.Webhooks.MaxAttempts = 2
+		cfg.Webhooks.InitialBackoff = time.Millisecond
+		cfg.Webhooks.MaxBackoff = time.Millisecond
+		cfg.Webhooks.DisableAfterFailures = 2
+	}))
+
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError)
+	healthyReceiver := newWebhookReceiver(t, http.StatusOK)
+
+	subscriptionID := createWebhookSubscription(t, h, map[string]any{
+		"url":         receiver.server.URL,
+		"event_types": []string{"TicketBookingConfirmed"},
+	})
+	createWebhookSubscription(t, h, map[string]any{
+		"url":         healthyReceiver.server.URL,
+		"event_types": []string{"TicketBookingConfirmed"},
+	})
+
+	sendConfirmed := func() {
+		h.SendTicketsStatus(servicetest.TicketsStatusRequest{Tickets: []servicetest.TicketStatus{{
+			TicketID:      uuid.NewString(),
+			Status:        "confirmed",
+			Price:         servicetest.Money{Amount: "30.00", Currency: "EUR"},
+			CustomerEmail: "email@example.com",
+		}}})
+	}
+
+	sendConfirmed()
+	sendConfirmed()
+
+	require.EventuallyWithT(t, func(t *assert.CollectT) {
+		var subscription struct {
+			Enabled        bool   `json:"enabled"`
+			DisabledReason string `json:"disabled_reason"`
+		}
+		if getJSON(t, h.BaseURL+"/webhooks/subscriptions/"+subscriptionID, defaultTenantKey, &subscription) {
+			assert.False(t, subscription.Enabled)
+			assert.NotEmpty(t, subscription.DisabledReason)
+		}
+	}, 10*time.Second, 50*time.Millisecond)
+
+	sendConfirmed()
+
+	// all events were handled once the healthy subscription got them
+	require.Eventually(t, func() bool {
+		return len(healthyReceiver.requests()) == 3
+	}, 10*time.Second, 50*time.Millisecond)
+
+	// 2 events with 2 attempts each, the third event isn't delivered to a disabled subscription
+	assert.Len(t, receiver.requests(), 4)
+}
+
+func TestWebhooks_refuses_private_addresses(t *testing.T) {
+	h := servicetest.New(t, servicetest.WithConfig(func(cfg *config.Config) {
+		cfg.Webhooks.AllowPrivateAddresses = false
+	}))
+
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.1/hook",
+		"http://[::1]/hook",
+	} {
+		resp := postWebhookSubscription(t, h, map[string]any{
+			"url":         url,
+			"event_types": []string{"TicketBookingConfirmed"},
+		})
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, url)
+	}
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+type webhookReceiver struct {
+	server *httptest.Server
+
+	lock     sync.Mutex
+	received []receivedWebhook
+}
+
+func newWebhookReceiver(t *testing.T, status int) *webhookReceiver {
+	r := &webhookReceiver{}
+
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.lock.Lock()
+		r.received = append(r.received, receivedWebhook{header: req.Header.Clone(), body: body})
+		r.lock.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.server.Close)
+
+	return r
+}
+
+func (r *webhookReceiver) requests() []receivedWebhook {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]receivedWebhook(nil), r.received...)
+}
+
+var defaultTenantKey = servicetest.TenantKey(tenant.DefaultID)
+
+func createWebhookSubscription(t *testing.T, h *servicetest.Harness, request map[string]any) string {
+	t.Helper()
+
+	resp := postWebhookSubscription(t, h, request)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		ID     string `json:"id"`
+		Secret string `json:"secret"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.NotEmpty(t, created.Secret)
+
+	return created.ID
+}
+
+func postWebhookSubscription(t *testing.T, h *servicetest.Harness, request map[string]any) *http.Response {
+	t.Helper()
+
+	body, err := json.Marshal(request)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, h.BaseURL+"/webhooks/subscriptions", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+defaultTenantKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	return resp
+}
+
+// getJSON authorizes the request with apiKey, unless it's empty.
+func getJSON(t assert.TestingT, url string, apiKey string, target any) bool {
+	resp, err := doRequest(http.MethodGet, url, apiKey)
+	if !assert.NoError(t, err) {
+		return false
+	}
+	defer resp.Body.Close()
+
+	return assert.Equal(t, http.StatusOK, resp.StatusCode) &&
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(target))
+}
+
+// doRequest sends a request without body, authorized with apiKey unless it's empty.
+func doRequest(method string, url string, apiKey string) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	return http.DefaultClient.Do(req)
+}
@@ -0,0 +1,120 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrRefusedAddress is returned for addresses subscriptions may not deliver to.
+var ErrRefusedAddress = errors.New("address is not public")
+
+// nonPublicNetworks are reserved networks not covered by the net.IP predicates.
+var nonPublicNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"198.18.0.0/15",
+	"240.0.0.0/4",
+	"64:ff9b::/96",
+)
+
+// AddressPolicy keeps deliveries from reaching the service's own network: loopback, private, link-local
+// and other non-public addresses are refused, unless AllowPrivate is set.
+type AddressPolicy struct {
+	AllowPrivate bool
+}
+
+// CheckURL refuses URLs which aren't absolute http or https URLs, or whose host resolves to a refused address.
+// The host may resolve to other addresses by the time events are delivered, so Transport checks them again.
+func (p AddressPolicy) CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+
+	if p.AllowPrivate {
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("url host %s can't be resolved", u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !publicIP(addr.IP) {
+			return fmt.Errorf("url host %s resolves to %s: %w", u.Hostname(), addr.IP, ErrRefusedAddress)
+		}
+	}
+
+	return nil
+}
+
+// Transport returns a transport which connects only to allowed addresses. It doesn't use proxies,
+// which would connect to refused addresses on behalf of the service.
+func (p AddressPolicy) Transport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   p.control,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return transport
+}
+
+// control checks the address after it was resolved, right before connecting to it.
+func (p AddressPolicy) control(network string, address string, _ syscall.RawConn) error {
+	if p.AllowPrivate {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return fmt.Errorf("refused to connect to %s: %w", host, ErrRefusedAddress)
+	}
+
+	return nil
+}
+
+func publicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks
+}
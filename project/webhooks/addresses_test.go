@@ -0,0 +1,47 @@
+package webhooks_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"tickets/webhooks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressPolicy_CheckURL(t *testing.T) {
+	ctx := context.Background()
+	policy := webhooks.AddressPolicy{}
+
+	for _, url := range []string{
+		"http://127.0.0.1/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://192.168.1.10/hook",
+		"http://100.64.0.1/hook",
+		"http://[::ffff:10.0.0.1]/hook",
+		"ftp://93.184.216.34/hook",
+		"/hook",
+	} {
+		assert.Error(t, policy.CheckURL(ctx, url), url)
+	}
+
+	assert.NoError(t, policy.CheckURL(ctx, "https://93.184.216.34/hook"))
+	assert.NoError(t, webhooks.AddressPolicy{AllowPrivate: true}.CheckURL(ctx, "http://127.0.0.1/hook"))
+}
+
+func TestAddressPolicy_Transport_checks_address_when_connecting(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := &http.Client{Transport: webhooks.AddressPolicy{}.Transport()}
+	_, err := client.Get(server.URL)
+	assert.ErrorIs(t, err, webhooks.ErrRefusedAddress)
+
+	client = &http.Client{Transport: webhooks.AddressPolicy{AllowPrivate: true}.Transport()}
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
@@ -0,0 +1,257 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"tickets/config"
+	"tickets/entities"
+	"tickets/message/scheduler"
+	"tickets/tenant"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Headers of delivery requests.
+const (
+	// HeaderEventID is the same for all attempts of delivering an event, receivers may use it for deduplication.
+	HeaderEventID   = "Webhook-Event-Id"
+	HeaderEventType = "Webhook-Event-Type"
+	HeaderTimestamp = "Webhook-Timestamp"
+	// HeaderSignature is "sha256=" followed by Sign result.
+	HeaderSignature = "Webhook-Signature"
+)
+
+// Sign returns hex-encoded HMAC-SHA256 of "<timestamp>.<body>", keyed with the subscription secret.
+// Receivers should reject requests with a timestamp far from the current time, to prevent replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Payload is the body of delivery requests.
+type Payload struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+type CommandBus interface {
+	Send(ctx context.Context, command any) error
+}
+
+// Deliverer sends events to the subscriptions of their type.
+//
+// Each attempt of delivering an event to a subscription is a DeliverWebhook command, and failed attempts
+// are retried with a command scheduled after the backoff, so slow or failing subscriptions don't hold up
+// handling of events.
+type Deliverer struct {
+	repo       Repository
+	commandBus CommandBus
+	client     *http.Client
+	config     config.Webhooks
+}
+
+func NewDeliverer(repo Repository, commandBus CommandBus, client *http.Client, cfg config.Webhooks) *Deliverer {
+	if repo == nil {
+		panic("missing repo")
+	}
+	if commandBus == nil {
+		panic("missing commandBus")
+	}
+	if client == nil {
+		panic("missing client")
+	}
+
+	return &Deliverer{
+		repo:       repo,
+		commandBus: commandBus,
+		client:     client,
+		config:     cfg,
+	}
+}
+
+// Deliver sends a command delivering the event to each enabled subscription of eventType of the tenant from ctx.
+// It doesn't wait for the deliveries.
+func (d *Deliverer) Deliver(ctx context.Context, eventType string, eventID string, event any) error {
+	tenantID := tenant.FromContext(ctx)
+
+	subscriptions, err := d.repo.ListSubscriptionsForEvent(ctx, tenantID, eventType)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(Payload{ID: eventID, Type: eventType, Data: event})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	for _, subscription := range subscriptions {
+		err := d.commandBus.Send(ctx, entities.DeliverWebhook{
+			Header:         entities.NewEventHeader(tenantID),
+			SubscriptionID: subscription.ID,
+			EventID:        eventID,
+			EventType:      eventType,
+			Payload:        body,
+			Attempt:        1,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to send webhook delivery to subscription %s: %w", subscription.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// DeliverAttempt handles the DeliverWebhook command. Failures are recorded in the delivery log, and retried
+// with the next attempt scheduled after the backoff, until MaxAttempts. Subscriptions disabled or deleted
+// in the meantime are skipped.
+func (d *Deliverer) DeliverAttempt(ctx context.Context, command *entities.DeliverWebhook) error {
+	logger := log.FromContext(ctx).WithFields(logrus.Fields{
+		"subscription_id": command.SubscriptionID,
+		"event_type":      command.EventType,
+		"event_id":        command.EventID,
+		"attempt":         command.Attempt,
+	})
+
+	subscription, err := d.repo.GetSubscription(ctx, command.SubscriptionID)
+	if errors.Is(err, ErrSubscriptionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if subscription.Disabled {
+		return nil
+	}
+
+	delivery := d.send(ctx, subscription, command.EventType, command.EventID, command.Payload)
+	delivery.Attempt = command.Attempt
+
+	if err := d.repo.AddDelivery(ctx, delivery); err != nil {
+		logger.WithError(err).Error("Failed to record webhook delivery")
+	}
+
+	if delivery.Succeeded {
+		d.updateFailures(ctx, logger, subscription.ID, true)
+		return nil
+	}
+
+	if ctx.Err() != nil {
+		// the command will be redelivered, it doesn't count as a failure
+		return ctx.Err()
+	}
+
+	logger.WithFields(logrus.Fields{
+		"status_code": delivery.StatusCode,
+		"error":       delivery.Error,
+	}).Warn("Webhook delivery failed")
+
+	if command.Attempt < d.config.MaxAttempts {
+		next := *command
+		next.Header = entities.NewEventHeader(command.Header.TenantID)
+		next.Attempt++
+
+		return d.commandBus.Send(scheduler.DeliverAfter(ctx, d.backoff(command.Attempt)), next)
+	}
+
+	d.updateFailures(ctx, logger, subscription.ID, false)
+
+	return nil
+}
+
+// backoff is how long to wait after the failed attempt, it doubles with each attempt up to MaxBackoff.
+func (d *Deliverer) backoff(attempt int) time.Duration {
+	backoff := d.config.InitialBackoff
+	for i := 1; i < attempt && backoff < d.config.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, d.config.MaxBackoff)
+}
+
+func (d *Deliverer) updateFailures(ctx context.Context, logger *logrus.Entry, subscriptionID string, delivered bool) {
+	_, err := d.repo.UpdateSubscription(ctx, subscriptionID, func(subscription *Subscription) error {
+		if delivered {
+			subscription.ConsecutiveFailures = 0
+			return nil
+		}
+
+		subscription.ConsecutiveFailures++
+		if subscription.ConsecutiveFailures >= d.config.DisableAfterFailures && !subscription.Disabled {
+			subscription.Disabled = true
+			subscription.DisabledReason = fmt.Sprintf("%d consecutive events could not be delivered", subscription.ConsecutiveFailures)
+
+			logger.WithField("reason", subscription.DisabledReason).Warn("Webhook subscription disabled")
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.WithError(err).Error("Failed to update webhook subscription")
+	}
+}
+
+func (d *Deliverer) send(ctx context.Context, subscription Subscription, eventType string, eventID string, body []byte) Delivery {
+	delivery := Delivery{
+		ID:             uuid.NewString(),
+		SubscriptionID: subscription.ID,
+		EventID:        eventID,
+		EventType:      eventType,
+		AttemptedAt:    time.Now().UTC(),
+	}
+
+	timestamp := delivery.AttemptedAt.Unix()
+
+	reqCtx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, eventID)
+	req.Header.Set(HeaderEventType, eventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, "sha256="+Sign(subscription.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	delivery.Duration = time.Since(delivery.AttemptedAt)
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	defer resp.Body.Close()
+
+	// drain, so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	delivery.StatusCode = resp.StatusCode
+	delivery.Succeeded = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !delivery.Succeeded {
+		delivery.Error = "unexpected status " + resp.Status
+	}
+
+	return delivery
+}
@@ -0,0 +1,140 @@
+package webhooks
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// maxMemoryDeliveries is how many of the latest deliveries MemoryRepository keeps per subscription.
+const maxMemoryDeliveries = 100
+
+// MemoryRepository keeps subscriptions in memory, for running without PostgreSQL.
+type MemoryRepository struct {
+	lock          sync.Mutex
+	subscriptions map[string]Subscription
+	deliveries    map[string][]Delivery
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		subscriptions: map[string]Subscription{},
+		deliveries:    map[string][]Delivery{},
+	}
+}
+
+func (r *MemoryRepository) AddSubscription(ctx context.Context, subscription Subscription) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.subscriptions[subscription.ID] = copySubscription(subscription)
+
+	return nil
+}
+
+func (r *MemoryRepository) GetSubscription(ctx context.Context, id string) (Subscription, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	subscription, ok := r.subscriptions[id]
+	if !ok {
+		return Subscription{}, ErrSubscriptionNotFound
+	}
+
+	return copySubscription(subscription), nil
+}
+
+func (r *MemoryRepository) ListSubscriptions(ctx context.Context, tenantID string) ([]Subscription, error) {
+	return r.list(func(subscription Subscription) bool {
+		return subscription.TenantID == tenantID
+	}), nil
+}
+
+func (r *MemoryRepository) UpdateSubscription(ctx context.Context, id string, update func(subscription *Subscription) error) (Subscription, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	subscription, ok := r.subscriptions[id]
+	if !ok {
+		return Subscription{}, ErrSubscriptionNotFound
+	}
+
+	subscription = copySubscription(subscription)
+	if err := update(&subscription); err != nil {
+		return Subscription{}, err
+	}
+
+	r.subscriptions[id] = copySubscription(subscription)
+
+	return subscription, nil
+}
+
+func (r *MemoryRepository) DeleteSubscription(ctx context.Context, id string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.subscriptions[id]; !ok {
+		return ErrSubscriptionNotFound
+	}
+
+	delete(r.subscriptions, id)
+	delete(r.deliveries, id)
+
+	return nil
+}
+
+func (r *MemoryRepository) ListSubscriptionsForEvent(ctx context.Context, tenantID string, eventType string) ([]Subscription, error) {
+	return r.list(func(subscription Subscription) bool {
+		return subscription.TenantID == tenantID && subscription.Receives(eventType)
+	}), nil
+}
+
+func (r *MemoryRepository) AddDelivery(ctx context.Context, delivery Delivery) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	deliveries := append(r.deliveries[delivery.SubscriptionID], delivery)
+	if len(deliveries) > maxMemoryDeliveries {
+		deliveries = deliveries[len(deliveries)-maxMemoryDeliveries:]
+	}
+	r.deliveries[delivery.SubscriptionID] = deliveries
+
+	return nil
+}
+
+func (r *MemoryRepository) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]Delivery, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	deliveries := r.deliveries[subscriptionID]
+
+	var latest []Delivery
+	for i := len(deliveries) - 1; i >= 0 && len(latest) < limit; i-- {
+		latest = append(latest, deliveries[i])
+	}
+
+	return latest, nil
+}
+
+func (r *MemoryRepository) list(match func(Subscription) bool) []Subscription {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var subscriptions []Subscription
+	for _, subscription := range r.subscriptions {
+		if match(subscription) {
+			subscriptions = append(subscriptions, copySubscription(subscription))
+		}
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+
+	return subscriptions
+}
+
+func copySubscription(subscription Subscription) Subscription {
+	subscription.EventTypes = append([]string(nil), subscription.EventTypes...)
+	return subscription
+}
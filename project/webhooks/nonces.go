@@ -0,0 +1,74 @@
+package webhooks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisNonceKeyPrefix = "tickets:inbound-webhooks:nonce:"
+
+// RedisNonceStore keeps nonces in Redis, so they are shared by all service instances.
+type RedisNonceStore struct {
+	client *redis.Client
+}
+
+func NewRedisNonceStore(client *redis.Client) *RedisNonceStore {
+	if client == nil {
+		panic("missing redis client")
+	}
+
+	return &RedisNonceStore{client: client}
+}
+
+func (s *RedisNonceStore) Use(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, redisNonceKeyPrefix+nonce, 1, ttl).Result()
+}
+
+// memorySweepInterval is how often MemoryNonceStore removes expired nonces. Nonces are checked
+// for expiry when used, so sweeping only bounds the memory expired nonces take.
+const memorySweepInterval = time.Minute
+
+// MemoryNonceStore keeps nonces in memory, for single instance deployments without Redis.
+type MemoryNonceStore struct {
+	lock      sync.Mutex
+	expires   map[string]time.Time
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{
+		expires: map[string]time.Time{},
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryNonceStore) Use(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+
+	if !now.Before(s.nextSweep) {
+		s.sweep(now)
+		s.nextSweep = now.Add(memorySweepInterval)
+	}
+
+	if expires, ok := s.expires[nonce]; ok && now.Before(expires) {
+		return false, nil
+	}
+	s.expires[nonce] = now.Add(ttl)
+
+	return true, nil
+}
+
+func (s *MemoryNonceStore) sweep(now time.Time) {
+	for n, expires := range s.expires {
+		if !now.Before(expires) {
+			delete(s.expires, n)
+		}
+	}
+}
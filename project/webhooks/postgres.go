@@ -0,0 +1,254 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PostgresRepository keeps subscriptions in tables created by db/schema.sql.
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	if db == nil {
+		panic("missing db")
+	}
+
+	return &PostgresRepository{db: db}
+}
+
+type subscriptionRow struct {
+	ID                  string         `db:"id"`
+	TenantID            string         `db:"tenant_id"`
+	URL                 string         `db:"url"`
+	EventTypes          pq.StringArray `db:"event_types"`
+	Secret              string         `db:"secret"`
+	Disabled            bool           `db:"disabled"`
+	DisabledReason      string         `db:"disabled_reason"`
+	ConsecutiveFailures int            `db:"consecutive_failures"`
+	CreatedAt           time.Time      `db:"created_at"`
+}
+
+func (r subscriptionRow) subscription() Subscription {
+	return Subscription{
+		ID:                  r.ID,
+		TenantID:            r.TenantID,
+		URL:                 r.URL,
+		EventTypes:          r.EventTypes,
+		Secret:              r.Secret,
+		Disabled:            r.Disabled,
+		DisabledReason:      r.DisabledReason,
+		ConsecutiveFailures: r.ConsecutiveFailures,
+		CreatedAt:           r.CreatedAt,
+	}
+}
+
+const subscriptionColumns = `id, tenant_id, url, event_types, secret, disabled, disabled_reason, consecutive_failures, created_at`
+
+func (r *PostgresRepository) AddSubscription(ctx context.Context, subscription Subscription) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO webhook_subscriptions (`+subscriptionColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		subscription.ID,
+		subscription.TenantID,
+		subscription.URL,
+		pq.StringArray(subscription.EventTypes),
+		subscription.Secret,
+		subscription.Disabled,
+		subscription.DisabledReason,
+		subscription.ConsecutiveFailures,
+		subscription.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) GetSubscription(ctx context.Context, id string) (Subscription, error) {
+	return getSubscription(ctx, r.db, id, "")
+}
+
+func (r *PostgresRepository) ListSubscriptions(ctx context.Context, tenantID string) ([]Subscription, error) {
+	return r.listSubscriptions(
+		ctx,
+		`SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE tenant_id = $1 ORDER BY created_at`,
+		tenantID,
+	)
+}
+
+func (r *PostgresRepository) UpdateSubscription(ctx context.Context, id string, update func(subscription *Subscription) error) (Subscription, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	subscription, err := getSubscription(ctx, tx, id, "FOR UPDATE")
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	if err := update(&subscription); err != nil {
+		return Subscription{}, err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE webhook_subscriptions
+		SET url = $2, event_types = $3, secret = $4, disabled = $5, disabled_reason = $6, consecutive_failures = $7
+		WHERE id = $1`,
+		subscription.ID,
+		subscription.URL,
+		pq.StringArray(subscription.EventTypes),
+		subscription.Secret,
+		subscription.Disabled,
+		subscription.DisabledReason,
+		subscription.ConsecutiveFailures,
+	)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Subscription{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return subscription, nil
+}
+
+func (r *PostgresRepository) DeleteSubscription(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	if deleted == 0 {
+		return ErrSubscriptionNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) ListSubscriptionsForEvent(ctx context.Context, tenantID string, eventType string) ([]Subscription, error) {
+	return r.listSubscriptions(
+		ctx,
+		`SELECT `+subscriptionColumns+` FROM webhook_subscriptions
+		WHERE tenant_id = $1 AND NOT disabled AND $2 = ANY(event_types)
+		ORDER BY created_at`,
+		tenantID,
+		eventType,
+	)
+}
+
+func (r *PostgresRepository) AddDelivery(ctx context.Context, delivery Delivery) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO webhook_deliveries
+		(id, subscription_id, event_id, event_type, attempt, status_code, error, succeeded, duration_ms, attempted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		delivery.ID,
+		delivery.SubscriptionID,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.Attempt,
+		delivery.StatusCode,
+		delivery.Error,
+		delivery.Succeeded,
+		delivery.Duration.Milliseconds(),
+		delivery.AttemptedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]Delivery, error) {
+	var rows []struct {
+		ID             string    `db:"id"`
+		SubscriptionID string    `db:"subscription_id"`
+		EventID        string    `db:"event_id"`
+		EventType      string    `db:"event_type"`
+		Attempt        int       `db:"attempt"`
+		StatusCode     int       `db:"status_code"`
+		Error          string    `db:"error"`
+		Succeeded      bool      `db:"succeeded"`
+		DurationMs     int64     `db:"duration_ms"`
+		AttemptedAt    time.Time `db:"attempted_at"`
+	}
+
+	err := r.db.SelectContext(
+		ctx,
+		&rows,
+		`SELECT id, subscription_id, event_id, event_type, attempt, status_code, error, succeeded, duration_ms, attempted_at
+		FROM webhook_deliveries
+		WHERE subscription_id = $1
+		ORDER BY attempted_at DESC
+		LIMIT $2`,
+		subscriptionID,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	deliveries := make([]Delivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, Delivery{
+			ID:             row.ID,
+			SubscriptionID: row.SubscriptionID,
+			EventID:        row.EventID,
+			EventType:      row.EventType,
+			Attempt:        row.Attempt,
+			StatusCode:     row.StatusCode,
+			Error:          row.Error,
+			Succeeded:      row.Succeeded,
+			Duration:       time.Duration(row.DurationMs) * time.Millisecond,
+			AttemptedAt:    row.AttemptedAt,
+		})
+	}
+
+	return deliveries, nil
+}
+
+func (r *PostgresRepository) listSubscriptions(ctx context.Context, query string, args ...any) ([]Subscription, error) {
+	var rows []subscriptionRow
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	subscriptions := make([]Subscription, 0, len(rows))
+	for _, row := range rows {
+		subscriptions = append(subscriptions, row.subscription())
+	}
+
+	return subscriptions, nil
+}
+
+func getSubscription(ctx context.Context, q sqlx.QueryerContext, id string, lock string) (Subscription, error) {
+	var row subscriptionRow
+
+	err := sqlx.GetContext(ctx, q, &row, `SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE id = $1 `+lock, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Subscription{}, ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return Subscription{}, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+
+	return row.subscription(), nil
+}
@@ -0,0 +1,82 @@
+// Package webhooks pushes ticket events to URLs partners subscribed to.
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// EventTypes that can be subscribed to.
+var EventTypes = []string{
+	"TicketBookingConfirmed",
+	"TicketBookingCanceled",
+	"TicketRefunded",
+}
+
+var ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+
+type Subscription struct {
+	ID string
+	// TenantID is the tenant whose events the subscription receives.
+	TenantID   string
+	URL        string
+	EventTypes []string
+	// Secret is the key of request signatures.
+	Secret string
+
+	// Disabled subscriptions don't receive events, until they are enabled again.
+	Disabled       bool
+	DisabledReason string
+	// ConsecutiveFailures counts events that couldn't be delivered since the last successful delivery.
+	ConsecutiveFailures int
+
+	CreatedAt time.Time
+}
+
+// Receives tells if the subscription should get events of eventType.
+func (s Subscription) Receives(eventType string) bool {
+	if s.Disabled {
+		return false
+	}
+
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Delivery is a single attempt of sending an event to a subscription.
+type Delivery struct {
+	ID             string
+	SubscriptionID string
+	EventID        string
+	EventType      string
+	Attempt        int
+	// StatusCode is 0 if no response was received.
+	StatusCode  int
+	Error       string
+	Succeeded   bool
+	Duration    time.Duration
+	AttemptedAt time.Time
+}
+
+type Repository interface {
+	AddSubscription(ctx context.Context, subscription Subscription) error
+	// GetSubscription returns ErrSubscriptionNotFound if there is no such subscription.
+	GetSubscription(ctx context.Context, id string) (Subscription, error)
+	ListSubscriptions(ctx context.Context, tenantID string) ([]Subscription, error)
+	// UpdateSubscription applies update to the subscription atomically.
+	UpdateSubscription(ctx context.Context, id string, update func(subscription *Subscription) error) (Subscription, error)
+	// DeleteSubscription deletes the subscription with its deliveries.
+	DeleteSubscription(ctx context.Context, id string) error
+	// ListSubscriptionsForEvent returns enabled subscriptions of the tenant to eventType.
+	ListSubscriptionsForEvent(ctx context.Context, tenantID string, eventType string) ([]Subscription, error)
+
+	AddDelivery(ctx context.Context, delivery Delivery) error
+	// ListDeliveries returns up to limit of the latest deliveries of the subscription, newest first.
+	ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]Delivery, error)
+}
@@ -0,0 +1,140 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"tickets/tenant"
+	"time"
+)
+
+// HeaderID identifies an inbound request. It's signed, and accepted only once, so a captured request can't be replayed.
+const HeaderID = "Webhook-Id"
+
+// SignRequest returns hex-encoded HMAC-SHA256 of "<id>.<timestamp>.<tenant ID>.<body>", the signature of inbound requests.
+// The tenant ID is the Tenant-ID header as sent, empty without the header, so a request can't be moved to another tenant.
+func SignRequest(secret string, id string, timestamp int64, tenantID string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(id))
+	mac.Write([]byte("."))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write([]byte(tenantID))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerificationError tells why an inbound request was rejected.
+type VerificationError struct {
+	Reason string
+}
+
+func (e VerificationError) Error() string {
+	return e.Reason
+}
+
+// NonceStore remembers IDs of accepted requests.
+type NonceStore interface {
+	// Use records the nonce for ttl, and returns false if it was already recorded.
+	Use(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// Verifier checks signatures of inbound requests, signed with SignRequest.
+//
+// Any of the secrets may sign a request, so a new secret can be added before senders switch to it,
+// and the old one removed after. Senders may also send signatures with several secrets, comma-separated.
+type Verifier struct {
+	secrets   []string
+	tolerance time.Duration
+	nonces    NonceStore
+	now       func() time.Time
+}
+
+func NewVerifier(secrets []string, tolerance time.Duration, nonces NonceStore) *Verifier {
+	if len(secrets) == 0 {
+		panic("missing secrets")
+	}
+	if nonces == nil {
+		panic("missing nonces")
+	}
+
+	return &Verifier{
+		secrets:   secrets,
+		tolerance: tolerance,
+		nonces:    nonces,
+		now:       time.Now,
+	}
+}
+
+// Verify returns VerificationError if the request isn't signed with any of the secrets,
+// its timestamp is outside the tolerance, or its ID was already used.
+func (v *Verifier) Verify(ctx context.Context, header http.Header, body []byte) error {
+	id := header.Get(HeaderID)
+	if id == "" {
+		return VerificationError{Reason: "missing " + HeaderID + " header"}
+	}
+
+	timestampHeader := header.Get(HeaderTimestamp)
+	if timestampHeader == "" {
+		return VerificationError{Reason: "missing " + HeaderTimestamp + " header"}
+	}
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return VerificationError{Reason: "invalid " + HeaderTimestamp + " header, expected Unix seconds"}
+	}
+
+	signatures := header.Get(HeaderSignature)
+	if signatures == "" {
+		return VerificationError{Reason: "missing " + HeaderSignature + " header"}
+	}
+
+	age := v.now().Sub(time.Unix(timestamp, 0))
+	if age > v.tolerance || age < -v.tolerance {
+		return VerificationError{Reason: fmt.Sprintf("timestamp is more than %s away from the current time", v.tolerance)}
+	}
+
+	if !v.validSignature(signatures, id, timestamp, header.Get(tenant.HTTPHeader), body) {
+		return VerificationError{Reason: "signature doesn't match"}
+	}
+
+	// The timestamp check rejects requests older than the tolerance, so their IDs don't need to be kept longer.
+	// It's doubled for timestamps ahead of the current time.
+	fresh, err := v.nonces.Use(ctx, id, 2*v.tolerance)
+	if err != nil {
+		return fmt.Errorf("failed to record request ID: %w", err)
+	}
+	if !fresh {
+		return VerificationError{Reason: "request ID was already used"}
+	}
+
+	return nil
+}
+
+func (v *Verifier) validSignature(signatures string, id string, timestamp int64, tenantID string, body []byte) bool {
+	for _, signature := range strings.Split(signatures, ",") {
+		signature, ok := strings.CutPrefix(strings.TrimSpace(signature), "sha256=")
+		if !ok {
+			continue
+		}
+		received, err := hex.DecodeString(signature)
+		if err != nil {
+			continue
+		}
+
+		for _, secret := range v.secrets {
+			expected, _ := hex.DecodeString(SignRequest(secret, id, timestamp, tenantID, body))
+			if hmac.Equal(received, expected) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
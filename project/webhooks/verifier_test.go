@@ -0,0 +1,166 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"tickets/tenant"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifier_Verify(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"tickets":[]}`)
+
+	signed := func(secret string, id string, timestamp time.Time) http.Header {
+		header := http.Header{}
+		header.Set(HeaderID, id)
+		header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+		header.Set(HeaderSignature, "sha256="+SignRequest(secret, id, timestamp.Unix(), "", body))
+		return header
+	}
+
+	testCases := []struct {
+		name           string
+		header         http.Header
+		expectedReason string
+	}{
+		{
+			name:   "current_secret",
+			header: signed("new-secret-0123456789", "1", now),
+		},
+		{
+			name:   "previous_secret",
+			header: signed("old-secret-0123456789", "2", now.Add(-4*time.Minute)),
+		},
+		{
+			name: "several_signatures",
+			header: func() http.Header {
+				header := signed("unknown-secret-012345", "3", now)
+				header.Set(HeaderSignature, header.Get(HeaderSignature)+", sha256="+SignRequest("new-secret-0123456789", "3", now.Unix(), "", body))
+				return header
+			}(),
+		},
+		{
+			name:           "unknown_secret",
+			header:         signed("unknown-secret-012345", "4", now),
+			expectedReason: "signature doesn't match",
+		},
+		{
+			name: "other_id",
+			header: func() http.Header {
+				header := signed("new-secret-0123456789", "5", now)
+				header.Set(HeaderID, "6")
+				return header
+			}(),
+			expectedReason: "signature doesn't match",
+		},
+		{
+			name: "other_tenant",
+			header: func() http.Header {
+				header := signed("new-secret-0123456789", "10", now)
+				header.Set(tenant.HTTPHeader, "reseller-a")
+				return header
+			}(),
+			expectedReason: "signature doesn't match",
+		},
+		{
+			name:           "expired",
+			header:         signed("new-secret-0123456789", "7", now.Add(-6*time.Minute)),
+			expectedReason: "timestamp is more than 5m0s away from the current time",
+		},
+		{
+			name:           "from_future",
+			header:         signed("new-secret-0123456789", "8", now.Add(6*time.Minute)),
+			expectedReason: "timestamp is more than 5m0s away from the current time",
+		},
+		{
+			name:           "replayed",
+			header:         signed("new-secret-0123456789", "1", now),
+			expectedReason: "request ID was already used",
+		},
+		{
+			name: "missing_signature",
+			header: func() http.Header {
+				header := signed("new-secret-0123456789", "9", now)
+				header.Del(HeaderSignature)
+				return header
+			}(),
+			expectedReason: "missing Webhook-Signature header",
+		},
+		{
+			name: "invalid_timestamp",
+			header: func() http.Header {
+				header := signed("new-secret-0123456789", "10", now)
+				header.Set(HeaderTimestamp, now.Format(time.RFC3339))
+				return header
+			}(),
+			expectedReason: "invalid Webhook-Timestamp header, expected Unix seconds",
+		},
+	}
+
+	nonces := NewMemoryNonceStore()
+	verifier := NewVerifier([]string{"new-secret-0123456789", "old-secret-0123456789"}, 5*time.Minute, nonces)
+	verifier.now = func() time.Time { return now }
+
+	// cases run in order, so a request is replayed after it was accepted
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifier.Verify(context.Background(), tc.header, body)
+
+			if tc.expectedReason == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var verificationErr VerificationError
+			require.ErrorAs(t, err, &verificationErr)
+			assert.Equal(t, tc.expectedReason, verificationErr.Reason)
+		})
+	}
+}
+
+func TestMemoryNonceStore_Use(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	store := NewMemoryNonceStore()
+	store.now = func() time.Time { return now }
+
+	fresh, err := store.Use(context.Background(), "1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, fresh)
+
+	fresh, err = store.Use(context.Background(), "1", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, fresh)
+
+	now = now.Add(time.Minute)
+
+	fresh, err = store.Use(context.Background(), "1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, fresh, "expired nonces can be used again")
+}
+
+func TestMemoryNonceStore_sweeps_periodically(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	store := NewMemoryNonceStore()
+	store.now = func() time.Time { return now }
+
+	_, err := store.Use(context.Background(), "1", time.Second)
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Second)
+	_, err = store.Use(context.Background(), "2", time.Second)
+	require.NoError(t, err)
+	assert.Len(t, store.expires, 2, "expired nonces are kept until the next sweep")
+
+	now = now.Add(memorySweepInterval)
+	_, err = store.Use(context.Background(), "3", time.Hour)
+	require.NoError(t, err)
+	assert.Len(t, store.expires, 1)
+}